@@ -0,0 +1,61 @@
+package main
+
+import (
+	"auth-service/internal/service"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const clientsUsage = "usage: clients list | clients register [--public] <name> <redirect_uri>... | clients delete <client_id>"
+
+// runClientsCommand управляет клиентами OpenID-провайдера
+func runClientsCommand(oidcService *service.OIDCService, args []string) error {
+	if len(args) == 0 {
+		return errors.New(clientsUsage)
+	}
+	switch args[0] {
+	case "list":
+		clients, err := oidcService.ListClients()
+		if err != nil {
+			return err
+		}
+		for _, c := range clients {
+			kind := "confidential"
+			if c.SecretHash == "" {
+				kind = "public"
+			}
+			fmt.Printf("%s %s %q %s\n", c.ClientID, kind, c.Name, strings.Join(c.RedirectURIs, " "))
+		}
+		return nil
+	case "register":
+		args = args[1:]
+		public := len(args) > 0 && args[0] == "--public"
+		if public {
+			args = args[1:]
+		}
+		if len(args) < 2 {
+			return errors.New(clientsUsage)
+		}
+		client, secret, err := oidcService.RegisterClient(args[0], args[1:], public)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("client_id: %s\n", client.ClientID)
+		if secret != "" {
+			fmt.Printf("client_secret: %s\n", secret)
+		}
+		return nil
+	case "delete":
+		if len(args) != 2 {
+			return errors.New(clientsUsage)
+		}
+		if err := oidcService.DeleteClient(args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("client %s deleted\n", args[1])
+		return nil
+	default:
+		return fmt.Errorf("unknown clients command %q", args[0])
+	}
+}
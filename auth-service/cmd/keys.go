@@ -0,0 +1,46 @@
+package main
+
+import (
+	"auth-service/internal/service"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// runKeysCommand выполняет административные операции со связками ключей подписи
+func runKeysCommand(keyService *service.KeyService, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: keys list | keys promote <access|refresh> <kid>")
+	}
+	switch args[0] {
+	case "list":
+		rings := keyService.Rings()
+		names := make([]string, 0, len(rings))
+		for name := range rings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			ring := rings[name]
+			for _, kid := range ring.IDs() {
+				mark := " "
+				if kid == ring.ActiveID() {
+					mark = "*"
+				}
+				fmt.Printf("%s %s %s\n", mark, name, kid)
+			}
+		}
+		return nil
+	case "promote":
+		if len(args) != 3 {
+			return errors.New("usage: keys promote <access|refresh> <kid>")
+		}
+		if err := keyService.Promote(args[1], args[2]); err != nil {
+			return err
+		}
+		fmt.Printf("%s: active key is now %s\n", args[1], args[2])
+		return nil
+	default:
+		return fmt.Errorf("unknown keys command %q", args[0])
+	}
+}
@@ -2,19 +2,30 @@ package main
 
 import (
 	"auth-service/config"
+	"auth-service/internal/clientip"
+	"auth-service/internal/jwt"
 	"auth-service/internal/maintenance"
+	"auth-service/internal/oauthclient"
+	"auth-service/internal/outbox"
 	"auth-service/internal/producer"
 	"auth-service/internal/repository"
 	"auth-service/internal/service"
 	"auth-service/internal/storage"
+	"auth-service/internal/totp"
+	"auth-service/internal/webauthn"
 	"auth-service/pkg/logger"
 	"context"
+	"errors"
+	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	grpcserver "auth-service/internal/transport/grpc"
+	httpserver "auth-service/internal/transport/http"
 
 	authv1 "github.com/Anabol1ks/linkvault-proto/auth/v1"
 
@@ -46,21 +57,92 @@ func main() {
 
 	storage.Migrate(db, log)
 
-	emailProducer := producer.NewEmailProducer(cfg.KafkaBrokers, cfg.KafkaTopic)
-	defer emailProducer.Close()
+	kafkaProducer := producer.NewKafkaProducer(cfg.KafkaBrokers)
+	defer kafkaProducer.Close()
 
+	tx := repository.NewTransactor(db)
 	userRepo := repository.NewUserRepository(db)
 	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
 	emailTokenRepo := repository.NewEmailVerificationTokenRepository(db)
 	passwordResetRepo := repository.NewPasswordResetTokenRepository(db)
+	magicLinkRepo := repository.NewMagicLinkTokenRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	revocationRepo := repository.NewAccessRevocationRepository(db)
+	mfaRepo := repository.NewMFARepository(db)
+	webauthnRepo := repository.NewWebAuthnRepository(db)
+	oauthRepo := repository.NewOAuthRepository(db)
+	oidcRepo := repository.NewOIDCRepository(db)
+	loginThrottleRepo := repository.NewLoginThrottleRepository(db)
+
+	accessKeys, refreshKeys, err := loadKeyRings(cfg, isDev, log)
+	if err != nil {
+		log.Fatal("signing key error", zap.Error(err))
+	}
+	keyService := service.NewKeyService(repository.NewSigningKeyRepository(db), accessKeys, refreshKeys, cfg.JWT.AccessExp, cfg.JWT.RefreshExp, log)
+	if err := keyService.Reload(); err != nil {
+		log.Fatal("signing key state error", zap.Error(err))
+	}
+
+	// Административные команды: auth-service keys list | keys promote <ring> <kid>
+	if len(os.Args) > 1 && os.Args[1] == "keys" {
+		if err := runKeysCommand(keyService, os.Args[2:]); err != nil {
+			log.Fatal("keys command failed", zap.Error(err))
+		}
+		storage.CloseDB(db, log)
+		return
+	}
+
+	var mfaCipher *totp.Cipher
+	if cfg.MFA.EncryptionKey != "" {
+		mfaCipher, err = totp.NewCipher(cfg.MFA.EncryptionKey)
+		if err != nil {
+			log.Fatal("mfa key error", zap.Error(err))
+		}
+	} else {
+		log.Warn("MFA_ENCRYPTION_KEY не задан: подключение двухфакторной аутентификации недоступно")
+	}
+	mfaService := service.NewMFAService(mfaRepo, userRepo, tx, mfaCipher, cfg.MFA, log)
+
+	loginGuard := service.NewLoginGuard(loginThrottleRepo, cfg.Login, log)
 
-	userService := service.NewUserService(userRepo, refreshTokenRepo, emailTokenRepo, passwordResetRepo, cfg, emailProducer, log)
+	userService := service.NewUserService(userRepo, refreshTokenRepo, emailTokenRepo, passwordResetRepo, magicLinkRepo, outboxRepo, revocationRepo, mfaService, loginGuard, tx, accessKeys, refreshKeys, cfg, log)
 
-	scheduler := maintenance.NewScheduler(log, refreshTokenRepo, emailTokenRepo, passwordResetRepo)
+	webauthnService := service.NewWebAuthnService(webauthnRepo, userRepo, userService, relyingParty(cfg, log), cfg.WebAuthn.Timeout, log)
+
+	providers, err := oauthProviders(cfg)
+	if err != nil {
+		log.Fatal("oauth provider config error", zap.Error(err))
+	}
+	oauthService := service.NewOAuthService(providers, oauthRepo, userRepo, userService, tx, cfg.OAuth.StateTTL, log)
+
+	oidcService := service.NewOIDCService(oidcRepo, userService, cfg.OIDC, log)
+
+	// Административные команды: auth-service clients list | clients register | clients delete
+	if len(os.Args) > 1 && os.Args[1] == "clients" {
+		if err := runClientsCommand(oidcService, os.Args[2:]); err != nil {
+			log.Fatal("clients command failed", zap.Error(err))
+		}
+		storage.CloseDB(db, log)
+		return
+	}
+	if cfg.OIDC.Issuer == "" {
+		log.Info("OIDC_ISSUER не задан: эндпоинты OpenID-провайдера выключены")
+		oidcService = nil
+	}
+
+	scheduler := maintenance.NewScheduler(log, refreshTokenRepo, emailTokenRepo, passwordResetRepo, magicLinkRepo, outboxRepo, revocationRepo, mfaRepo, webauthnRepo, oauthRepo, oidcRepo, loginThrottleRepo, cfg.Outbox.Retention, cfg.Login.FailureWindow)
 	appCtx, cancelScheduler := context.WithCancel(context.Background())
 	if err := scheduler.Start(appCtx); err != nil {
 		log.Error("Не удалось запустить планировщик", zap.Error(err))
 	}
+	go keyService.Run(appCtx, cfg.JWT.KeyReload)
+
+	relay := outbox.NewRelay(tx, outboxRepo, kafkaProducer, outbox.Config{
+		Interval:    cfg.Outbox.RelayInterval,
+		BatchSize:   cfg.Outbox.BatchSize,
+		MaxAttempts: cfg.Outbox.MaxAttempts,
+	}, log)
+	go relay.Run(appCtx)
 
 	// gRPC server setup
 	lis, err := net.Listen("tcp", cfg.Port)
@@ -69,7 +151,7 @@ func main() {
 	}
 
 	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(grpcserver.AuthInterceptor(&cfg.JWT)),
+		grpc.UnaryInterceptor(grpcserver.AuthInterceptor(userService)),
 	)
 
 	// gRPC health check service
@@ -79,7 +161,8 @@ func main() {
 
 	reflection.Register(grpcServer)
 
-	authv1.RegisterAuthServiceServer(grpcServer, grpcserver.NewAuthServer(userService, log))
+	clients := clientip.NewResolver(cfg.TrustedProxies, log)
+	authv1.RegisterAuthServiceServer(grpcServer, grpcserver.NewAuthServer(userService, clients, log))
 
 	go func() {
 		log.Info("Starting gRPC server", zap.String("addr", cfg.Port))
@@ -88,13 +171,79 @@ func main() {
 		}
 	}()
 
+	jwksServer := httpserver.NewServer(cfg, accessKeys, httpserver.Services{Users: userService, MFA: mfaService, WebAuthn: webauthnService, OAuth: oauthService, OIDC: oidcService}, clients, log)
+	go func() {
+		log.Info("Starting HTTP server", zap.String("addr", cfg.JWKSPort))
+		if err := jwksServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("HTTP server failed", zap.Error(err))
+		}
+	}()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Info("Shutting down gRPC server...")
 
 	grpcServer.GracefulStop()
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	_ = jwksServer.Shutdown(shutdownCtx)
 	cancelScheduler()
 	storage.CloseDB(db, log)
 	log.Info("Server exiting")
 }
+
+// loadKeyRings собирает связки ключей access и refresh токенов.
+// Временный ключ access-токенов генерируется только при ENV=development.
+func loadKeyRings(cfg *config.Config, isDev bool, log *zap.Logger) (*jwt.KeyRing, *jwt.KeyRing, error) {
+	accessKeys, generated, err := jwt.NewAccessRing(cfg.JWT.AccessKeyFile, cfg.JWT.AccessKeysDir, isDev)
+	if errors.Is(err, jwt.ErrNoAccessKeys) {
+		return nil, nil, fmt.Errorf("%w: set ACCESS_PRIVATE_KEY_FILE or ACCESS_KEYS_DIR (a temporary key is generated only with ENV=development)", err)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if generated {
+		log.Warn("Ключи access-токенов не заданы: сгенерирован временный ключ, выданные access-токены станут недействительны после перезапуска")
+	}
+	refreshKeys, err := jwt.NewRefreshRing(cfg.JWT.Refresh, cfg.JWT.RefreshSecrets)
+	if err != nil {
+		return nil, nil, err
+	}
+	return accessKeys, refreshKeys, nil
+}
+
+// relyingParty возвращает nil, если WebAuthn не настроен
+func relyingParty(cfg *config.Config, log *zap.Logger) *webauthn.RelyingParty {
+	if cfg.WebAuthn.RPID == "" {
+		log.Info("WEBAUTHN_RP_ID не задан: вход по ключам доступа выключен")
+		return nil
+	}
+	origins := cfg.WebAuthn.Origins
+	if len(origins) == 0 {
+		origins = []string{"https://" + cfg.WebAuthn.RPID}
+	}
+	return &webauthn.RelyingParty{ID: cfg.WebAuthn.RPID, Name: cfg.WebAuthn.RPName, Origins: origins}
+}
+
+// oauthProviders создаёт клиентов внешних провайдеров входа
+func oauthProviders(cfg *config.Config) ([]*oauthclient.Provider, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	providers := make([]*oauthclient.Provider, 0, len(cfg.OAuth.Providers))
+	for _, pc := range cfg.OAuth.Providers {
+		p, err := oauthclient.NewProvider(oauthclient.Config{
+			Name:         pc.Name,
+			Kind:         pc.Kind,
+			ClientID:     pc.ClientID,
+			ClientSecret: pc.ClientSecret,
+			RedirectURL:  pc.RedirectURL,
+			Issuer:       pc.Issuer,
+			Scopes:       pc.Scopes,
+		}, client)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+	return providers, nil
+}
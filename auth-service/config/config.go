@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,15 +15,120 @@ type Config struct {
 	JWT  JWTConfig
 	DB   DBConfig
 
+	// HTTP порт для /.well-known/jwks.json
+	JWKSPort string
+	// Адреса и подсети шлюзов, которым доверяется X-Forwarded-For (gRPC и HTTP)
+	TrustedProxies []string
+
 	KafkaBrokers []string
 	KafkaTopic   string
+
+	Outbox    OutboxConfig
+	MFA       MFAConfig
+	WebAuthn  WebAuthnConfig
+	MagicLink MagicLinkConfig
+	OAuth     OAuthConfig
+	OIDC      OIDCConfig
+	Login     LoginGuardConfig
+}
+
+// LoginGuardConfig — защита входа по паролю от подбора
+type LoginGuardConfig struct {
+	// После скольких неудач подряд по адресу начинаются задержки (1s, 2s, 4s... до DelayMax)
+	DelayAfter int
+	DelayMax   time.Duration
+	// Неудач по адресу до блокировки
+	MaxFailures int
+	// Неудач с одного IP до блокировки
+	IPMaxFailures int
+	// Срок блокировки
+	Lockout time.Duration
+	// Неудачи старше окна не учитываются
+	FailureWindow time.Duration
+}
+
+// OIDCConfig — LinkVault как OpenID-провайдер для других приложений
+type OIDCConfig struct {
+	// Идентификатор провайдера (iss) и базовый адрес HTTP-эндпоинтов; без него провайдер выключен
+	Issuer string
+	// Страница входа LinkVault, которая завершает запрос авторизации
+	LoginURL string
+	// Сколько действует код авторизации
+	CodeTTL time.Duration
+}
+
+type OAuthConfig struct {
+	Providers []OAuthProviderConfig
+	// Сколько ждать возврата пользователя от провайдера
+	StateTTL time.Duration
+}
+
+// OAuthProviderConfig — внешний провайдер входа; переменные OAUTH_<NAME>_*
+type OAuthProviderConfig struct {
+	Name string
+	// oidc или github
+	Kind         string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Issuer       string
+	Scopes       []string
+}
+
+type MagicLinkConfig struct {
+	// Сколько действует ссылка для входа
+	TTL time.Duration
+	// Ссылок на один адрес в час
+	MaxPerHour int
+}
+
+type WebAuthnConfig struct {
+	// Домен relying party (например linkvault.app); без него вход по ключам доступа выключен
+	RPID   string
+	RPName string
+	// Origin страниц, с которых разрешены церемонии; по умолчанию https://<RPID>
+	Origins []string
+	// Сколько действует выданный challenge
+	Timeout time.Duration
+}
+
+type MFAConfig struct {
+	// Ключ шифрования секретов TOTP: 32 байта в base64; без него двухфакторная аутентификация недоступна
+	EncryptionKey string
+	// Имя сервиса в приложении-аутентификаторе
+	Issuer string
+	// Сколько действует вызов второго фактора после проверки пароля
+	ChallengeTTL time.Duration
+	// Неверных кодов на один вызов
+	MaxAttempts int
+}
+
+type OutboxConfig struct {
+	RelayInterval time.Duration
+	BatchSize     int
+	// После стольких неудачных попыток сообщение больше не отправляется
+	MaxAttempts int
+	// Сколько хранить отправленные сообщения
+	Retention time.Duration
 }
 
 type JWTConfig struct {
-	Access     string
-	AccessExp  time.Duration
-	Refresh    string
-	RefreshExp time.Duration
+	// PEM-файл закрытого ключа Ed25519 для access-токенов
+	AccessKeyFile string
+	// Каталог с ключами access-токенов (*.pem, kid — имя файла); без ключей ключ генерируется при старте
+	AccessKeysDir string
+	AccessExp     time.Duration
+	// Секрет refresh-токенов с kid "default"
+	Refresh string
+	// Дополнительные секреты refresh-токенов: "kid:secret,kid:secret"
+	RefreshSecrets string
+	RefreshExp     time.Duration
+	// Как часто перечитывать активные ключи из БД
+	KeyReload time.Duration
+	// Сообщать пользователю письмом о повторном использовании refresh-токена
+	NotifyReuse bool
+	// Токен доступа к списку отозванных access-токенов (GET /internal/revocations); пусто — список не публикуется
+	RevocationFeedToken string
 }
 
 type DBConfig struct {
@@ -46,15 +152,102 @@ func Load(log *zap.Logger) *Config {
 			SSLMode:  getEnv("DB_SSLMODE", log),
 		},
 		JWT: JWTConfig{
-			Access:     getEnv("ACCESS_SECRET", log),
-			AccessExp:  parseDurationWithDays(getEnv("ACCESS_EXP", log)),
-			Refresh:    getEnv("REFRESH_SECRET", log),
-			RefreshExp: parseDurationWithDays(getEnv("REFRESH_EXP", log)),
+			AccessKeyFile:  os.Getenv("ACCESS_PRIVATE_KEY_FILE"),
+			AccessKeysDir:  os.Getenv("ACCESS_KEYS_DIR"),
+			AccessExp:      parseDurationWithDays(getEnv("ACCESS_EXP", log)),
+			Refresh:        os.Getenv("REFRESH_SECRET"),
+			RefreshSecrets: os.Getenv("REFRESH_SECRETS"),
+			RefreshExp:     parseDurationWithDays(getEnv("REFRESH_EXP", log)),
+			KeyReload:      parseDurationWithDays(getEnvDefault("KEY_RELOAD_INTERVAL", "1m")),
+			NotifyReuse:    getEnvBool("REFRESH_REUSE_NOTIFY", true, log),
+
+			RevocationFeedToken: os.Getenv("REVOCATION_FEED_TOKEN"),
 		},
+		JWKSPort:       getEnvDefault("JWKS_HTTP_PORT", ":8091"),
+		TrustedProxies: splitAndTrim(os.Getenv("TRUSTED_PROXIES")),
 
 		KafkaBrokers: splitAndTrim(os.Getenv("KAFKA_BROKERS")),
 		KafkaTopic:   getEnv("KAFKA_TOPIC_EMAIL", log),
+
+		Outbox: OutboxConfig{
+			RelayInterval: parseDurationWithDays(getEnvDefault("OUTBOX_RELAY_INTERVAL", "1s")),
+			BatchSize:     getEnvInt("OUTBOX_BATCH_SIZE", 100, log),
+			MaxAttempts:   getEnvInt("OUTBOX_MAX_ATTEMPTS", 10, log),
+			Retention:     parseDurationWithDays(getEnvDefault("OUTBOX_RETENTION", "7d")),
+		},
+
+		MFA: MFAConfig{
+			EncryptionKey: os.Getenv("MFA_ENCRYPTION_KEY"),
+			Issuer:        getEnvDefault("MFA_ISSUER", "LinkVault"),
+			ChallengeTTL:  parseDurationWithDays(getEnvDefault("MFA_CHALLENGE_TTL", "5m")),
+			MaxAttempts:   getEnvInt("MFA_MAX_ATTEMPTS", 5, log),
+		},
+
+		WebAuthn: WebAuthnConfig{
+			RPID:    os.Getenv("WEBAUTHN_RP_ID"),
+			RPName:  getEnvDefault("WEBAUTHN_RP_NAME", "LinkVault"),
+			Origins: splitAndTrim(os.Getenv("WEBAUTHN_ORIGINS")),
+			Timeout: parseDurationWithDays(getEnvDefault("WEBAUTHN_TIMEOUT", "5m")),
+		},
+
+		MagicLink: MagicLinkConfig{
+			TTL:        parseDurationWithDays(getEnvDefault("MAGIC_LINK_TTL", "15m")),
+			MaxPerHour: getEnvInt("MAGIC_LINK_MAX_PER_HOUR", 3, log),
+		},
+
+		OAuth: OAuthConfig{
+			Providers: loadOAuthProviders(log),
+			StateTTL:  parseDurationWithDays(getEnvDefault("OAUTH_STATE_TTL", "10m")),
+		},
+
+		OIDC: loadOIDC(log),
+
+		Login: LoginGuardConfig{
+			DelayAfter:    getEnvInt("LOGIN_DELAY_AFTER", 3, log),
+			DelayMax:      parseDurationWithDays(getEnvDefault("LOGIN_DELAY_MAX", "30s")),
+			MaxFailures:   getEnvInt("LOGIN_MAX_FAILURES", 10, log),
+			IPMaxFailures: getEnvInt("LOGIN_IP_MAX_FAILURES", 100, log),
+			Lockout:       parseDurationWithDays(getEnvDefault("LOGIN_LOCKOUT", "15m")),
+			FailureWindow: parseDurationWithDays(getEnvDefault("LOGIN_FAILURE_WINDOW", "1h")),
+		},
+	}
+}
+
+func loadOIDC(log *zap.Logger) OIDCConfig {
+	cfg := OIDCConfig{
+		Issuer:  strings.TrimSuffix(os.Getenv("OIDC_ISSUER"), "/"),
+		CodeTTL: parseDurationWithDays(getEnvDefault("OIDC_CODE_TTL", "1m")),
 	}
+	if cfg.Issuer != "" {
+		cfg.LoginURL = getEnv("OIDC_LOGIN_URL", log)
+	}
+	return cfg
+}
+
+// loadOAuthProviders читает провайдеров из OAUTH_PROVIDERS (например "google,github,corp").
+// google и github известны заранее, остальные считаются OIDC и требуют OAUTH_<NAME>_ISSUER.
+func loadOAuthProviders(log *zap.Logger) []OAuthProviderConfig {
+	var providers []OAuthProviderConfig
+	for _, name := range splitAndTrim(os.Getenv("OAUTH_PROVIDERS")) {
+		prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+		p := OAuthProviderConfig{
+			Name:         name,
+			Kind:         getEnvDefault(prefix+"KIND", "oidc"),
+			ClientID:     getEnv(prefix+"CLIENT_ID", log),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", log),
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		switch name {
+		case "google":
+			p.Issuer = getEnvDefault(prefix+"ISSUER", "https://accounts.google.com")
+		case "github":
+			p.Kind = getEnvDefault(prefix+"KIND", "github")
+		}
+		providers = append(providers, p)
+	}
+	return providers
 }
 
 func getEnv(key string, log *zap.Logger) string {
@@ -65,6 +258,39 @@ func getEnv(key string, log *zap.Logger) string {
 	panic("missing required environment variable: " + key)
 }
 
+func getEnvDefault(key, def string) string {
+	if val, exists := os.LookupEnv(key); exists && val != "" {
+		return val
+	}
+	return def
+}
+
+func getEnvInt(key string, def int, log *zap.Logger) int {
+	val, exists := os.LookupEnv(key)
+	if !exists || val == "" {
+		return def
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		log.Warn("Некорректное число в переменной окружения, используется значение по умолчанию", zap.String("key", key), zap.String("value", val))
+		return def
+	}
+	return n
+}
+
+func getEnvBool(key string, def bool, log *zap.Logger) bool {
+	val, exists := os.LookupEnv(key)
+	if !exists || val == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		log.Warn("Некорректное булево значение в переменной окружения, используется значение по умолчанию", zap.String("key", key), zap.String("value", val))
+		return def
+	}
+	return b
+}
+
 func parseDurationWithDays(s string) time.Duration {
 	if strings.HasSuffix(s, "d") {
 		daysStr := strings.TrimSuffix(s, "d")
@@ -0,0 +1,38 @@
+package clientip
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestResolve(t *testing.T) {
+	r := NewResolver([]string{"10.0.0.0/8", "127.0.0.1", "not-an-ip"}, zap.NewNop())
+
+	cases := []struct {
+		name, remote, forwarded, want string
+	}{
+		{"direct client", "203.0.113.7:5000", "", "203.0.113.7"},
+		{"spoofed header from client", "203.0.113.7:5000", "198.51.100.1", "203.0.113.7"},
+		{"trusted subnet", "10.1.2.3:443", "198.51.100.1", "198.51.100.1"},
+		{"trusted address", "127.0.0.1:443", "198.51.100.1", "198.51.100.1"},
+		{"client-supplied entries before proxy", "10.1.2.3:443", "192.0.2.66, 198.51.100.1", "198.51.100.1"},
+		{"chain of trusted proxies", "10.1.2.3:443", "192.0.2.66, 198.51.100.1, 10.4.5.6", "198.51.100.1"},
+		{"only trusted hops", "10.1.2.3:443", "10.4.5.6, 10.7.8.9", "10.4.5.6"},
+		{"garbage hop", "10.1.2.3:443", "198.51.100.1, not-an-ip", "10.1.2.3"},
+		{"garbage behind trusted hop", "10.1.2.3:443", "not-an-ip, 10.4.5.6", "10.4.5.6"},
+		{"trusted proxy without header", "10.1.2.3:443", "", "10.1.2.3"},
+		{"ipv4-mapped proxy", "[::ffff:10.1.2.3]:443", "198.51.100.1", "198.51.100.1"},
+		{"address without port", "203.0.113.7", "198.51.100.1", "203.0.113.7"},
+	}
+	for _, tc := range cases {
+		if got := r.Resolve(tc.remote, tc.forwarded); got != tc.want {
+			t.Errorf("%s: Resolve(%q, %q) = %q, want %q", tc.name, tc.remote, tc.forwarded, got, tc.want)
+		}
+	}
+
+	var none *Resolver
+	if got := none.Resolve("203.0.113.7:5000", "198.51.100.1"); got != "203.0.113.7" {
+		t.Errorf("nil resolver trusted the header: %q", got)
+	}
+}
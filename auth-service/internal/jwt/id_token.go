@@ -0,0 +1,22 @@
+package jwt
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// IDTokenClaims — ID token OpenID Connect. iss, sub, aud и сроки задаёт вызывающий.
+type IDTokenClaims struct {
+	Nonce         string `json:"nonce,omitempty"`
+	Name          string `json:"name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// GenerateIDToken подписывает ID token активным ключом access-связки; проверяется по тому же JWKS
+func GenerateIDToken(claims IDTokenClaims, keys *KeyRing) (string, error) {
+	key := keys.Active()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Sign)
+}
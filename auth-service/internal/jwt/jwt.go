@@ -12,69 +12,102 @@ import (
 type Claims struct {
 	UserID string `json:"user_id"`
 	Type   string `json:"type"`
+	// Сессия (семейство refresh-токенов), в которой выдан access-токен
+	SessionID string `json:"sid,omitempty"`
+	// OAuth-клиент, которому выдан токен (тип client_access), и разрешённые ему scope
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateAccessToken(userID string, cfg *config.JWTConfig) (string, *Claims, error) {
-	claims := Claims{
-		UserID: userID,
-		Type:   "access",
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(cfg.AccessExp)),
-			ID:        uuid.NewString(),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+// GenerateAccessToken подписывает access-токен активным ключом связки; kid ключа пишется в заголовок
+func GenerateAccessToken(userID, sessionID string, keys *KeyRing, cfg *config.JWTConfig) (string, *Claims, error) {
+	return generate(userID, "access", sessionID, cfg.AccessExp, keys)
+}
+
+// GenerateClientAccessToken подписывает access-токен OAuth-клиента. У него свой тип client_access,
+// поэтому API LinkVault (ParseAccessToken) такой токен не принимает.
+func GenerateClientAccessToken(userID, sessionID, clientID, scope string, keys *KeyRing, cfg *config.JWTConfig) (string, *Claims, error) {
+	return sign(Claims{
+		UserID:    userID,
+		Type:      "client_access",
+		SessionID: sessionID,
+		ClientID:  clientID,
+		Scope:     scope,
+	}, cfg.AccessExp, keys)
+}
+
+func GenerateRefreshToken(userID string, keys *KeyRing, cfg *config.JWTConfig) (string, *Claims, error) {
+	return generate(userID, "refresh", "", cfg.RefreshExp, keys)
+}
+
+func generate(userID, tokenType, sessionID string, ttl time.Duration, keys *KeyRing) (string, *Claims, error) {
+	return sign(Claims{UserID: userID, Type: tokenType, SessionID: sessionID}, ttl, keys)
+}
+
+func sign(claims Claims, ttl time.Duration, keys *KeyRing) (string, *Claims, error) {
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		ID:        uuid.NewString(),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := token.SignedString([]byte(cfg.Access))
+	key := keys.Active()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString(key.Sign)
 	if err != nil {
 		return "", nil, err
 	}
 	return signed, &claims, nil
 }
 
-func GenerateRefreshToken(userID string, cfg *config.JWTConfig) (string, *Claims, error) {
-	claims := Claims{
-		UserID: userID,
-		Type:   "refresh",
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(cfg.RefreshExp)),
-			ID:        uuid.NewString(),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := token.SignedString([]byte(cfg.Refresh))
-	if err != nil {
-		return "", nil, err
+func ParseAccessToken(tokenStr string, keys *KeyRing) (*Claims, error) {
+	claims, err := parse(tokenStr, keys, "")
+	if err != nil || claims.Type != "access" {
+		return nil, errors.New("invalid access token")
 	}
-	return signed, &claims, nil
+	return claims, nil
 }
 
-func ParseAccessToken(tokenStr string, secret string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secret), nil
-	})
-	if err != nil {
-		return nil, err
+func ParseClientAccessToken(tokenStr string, keys *KeyRing) (*Claims, error) {
+	claims, err := parse(tokenStr, keys, "")
+	if err != nil || claims.Type != "client_access" {
+		return nil, errors.New("invalid client access token")
 	}
-	claims, ok := token.Claims.(*Claims)
-	if !ok || !token.Valid || claims.Type != "access" {
-		return nil, errors.New("invalid access token")
+	return claims, nil
+}
+
+// ParseRefreshToken принимает и токены без kid, выданные до введения связки ключей, — их проверяет ключ "default"
+func ParseRefreshToken(tokenStr string, keys *KeyRing) (*Claims, error) {
+	claims, err := parse(tokenStr, keys, LegacyRefreshKeyID)
+	if err != nil || claims.Type != "refresh" {
+		return nil, errors.New("invalid refresh token")
 	}
 	return claims, nil
 }
 
-func ParseRefreshToken(tokenStr string, secret string) (*Claims, error) {
+func parse(tokenStr string, keys *KeyRing, defaultKid string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secret), nil
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			kid = defaultKid
+		}
+		key, err := keys.VerifyKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		// Алгоритм задаёт ключ, а не заголовок токена
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, errors.New("unexpected signing method")
+		}
+		return key.Verify, nil
 	})
 	if err != nil {
 		return nil, err
 	}
 	claims, ok := token.Claims.(*Claims)
-	if !ok || !token.Valid || claims.Type != "refresh" {
-		return nil, errors.New("invalid refresh token")
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
 	}
 	return claims, nil
 }
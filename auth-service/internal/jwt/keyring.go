@@ -0,0 +1,241 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrUnknownKey = errors.New("unknown key id")
+
+// ErrNoAccessKeys — не задан ни один ключ access-токенов, а генерировать временный запрещено
+var ErrNoAccessKeys = errors.New("no access signing keys configured")
+
+// Key — ключ связки: закрытая часть для подписи и открытая (или тот же секрет для HMAC) для проверки
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	Sign   interface{}
+	Verify interface{}
+}
+
+// KeyRing — связка ключей с одним активным ключом подписи.
+// Остальные ключи только проверяют подписи; выведенный из оборота ключ проверяет до своего retireAt.
+type KeyRing struct {
+	name string
+
+	mu       sync.RWMutex
+	keys     map[string]*Key
+	order    []string
+	active   string
+	retireAt map[string]time.Time
+}
+
+func newKeyRing(name string) *KeyRing {
+	return &KeyRing{name: name, keys: map[string]*Key{}, retireAt: map[string]time.Time{}}
+}
+
+func (r *KeyRing) Name() string {
+	return r.name
+}
+
+func (r *KeyRing) add(k *Key) error {
+	if _, ok := r.keys[k.ID]; ok {
+		return fmt.Errorf("%w: duplicate key id %q in %s ring", ErrInvalidKey, k.ID, r.name)
+	}
+	r.keys[k.ID] = k
+	r.order = append(r.order, k.ID)
+	return nil
+}
+
+// DefaultActive — ключ, активный до первого переключения: последний загруженный
+func (r *KeyRing) DefaultActive() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.order[len(r.order)-1]
+}
+
+func (r *KeyRing) Has(kid string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.keys[kid]
+	return ok
+}
+
+// IDs — идентификаторы ключей в порядке загрузки
+func (r *KeyRing) IDs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string(nil), r.order...)
+}
+
+// Apply задаёт активный ключ и сроки вывода из оборота прежних ключей
+func (r *KeyRing) Apply(active string, retireAt map[string]time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.keys[active]; !ok {
+		return fmt.Errorf("%w: %q in %s ring", ErrUnknownKey, active, r.name)
+	}
+	r.active = active
+	r.retireAt = retireAt
+	return nil
+}
+
+// Active — текущий ключ подписи
+func (r *KeyRing) Active() *Key {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.keys[r.active]
+}
+
+// ActiveID — kid текущего ключа подписи
+func (r *KeyRing) ActiveID() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active
+}
+
+// VerifyKey — ключ проверки по kid; выведенные из оборота после retireAt не принимаются
+func (r *KeyRing) VerifyKey(kid string) (*Key, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	k, ok := r.keys[kid]
+	if !ok || !r.usable(kid, time.Now()) {
+		return nil, ErrUnknownKey
+	}
+	return k, nil
+}
+
+func (r *KeyRing) usable(kid string, now time.Time) bool {
+	if kid == r.active {
+		return true
+	}
+	at, retired := r.retireAt[kid]
+	return !retired || now.Before(at)
+}
+
+// JWKS — документ /.well-known/jwks.json с открытыми Ed25519 ключами, пригодными для проверки
+func (r *KeyRing) JWKS() ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	doc := struct {
+		Keys []JWK `json:"keys"`
+	}{Keys: []JWK{}}
+	now := time.Now()
+	for _, kid := range r.order {
+		pub, ok := r.keys[kid].Verify.(ed25519.PublicKey)
+		if !ok || !r.usable(kid, now) {
+			continue
+		}
+		doc.Keys = append(doc.Keys, JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+			Kid: kid,
+			Alg: "EdDSA",
+			Use: "sig",
+		})
+	}
+	return json.Marshal(doc)
+}
+
+// JWK — открытый ключ в формате RFC 8037 (OKP / Ed25519)
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+}
+
+func ed25519Key(k *SigningKey) *Key {
+	return &Key{ID: k.ID, Method: jwt.SigningMethodEdDSA, Sign: k.Private, Verify: k.Public()}
+}
+
+// NewAccessRing собирает связку ключей access-токенов: ключ из keyFile (kid — отпечаток)
+// и все *.pem из keysDir (kid — имя файла без расширения). Без ключей генерирует временный,
+// если это разрешено allowGenerate (только для разработки), иначе возвращает ErrNoAccessKeys.
+func NewAccessRing(keyFile, keysDir string, allowGenerate bool) (ring *KeyRing, generated bool, err error) {
+	ring = newKeyRing("access")
+	if keyFile != "" {
+		k, err := LoadSigningKey(keyFile)
+		if err != nil {
+			return nil, false, err
+		}
+		if err := ring.add(ed25519Key(k)); err != nil {
+			return nil, false, err
+		}
+	}
+	if keysDir != "" {
+		files, err := filepath.Glob(filepath.Join(keysDir, "*.pem"))
+		if err != nil {
+			return nil, false, err
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			k, err := LoadSigningKey(file)
+			if err != nil {
+				return nil, false, fmt.Errorf("%s: %w", file, err)
+			}
+			k.ID = strings.TrimSuffix(filepath.Base(file), ".pem")
+			if err := ring.add(ed25519Key(k)); err != nil {
+				return nil, false, err
+			}
+		}
+	}
+	if len(ring.order) == 0 {
+		if !allowGenerate {
+			return nil, false, ErrNoAccessKeys
+		}
+		k, err := GenerateSigningKey()
+		if err != nil {
+			return nil, false, err
+		}
+		_ = ring.add(ed25519Key(k))
+		generated = true
+	}
+	return ring, generated, ring.Apply(ring.order[len(ring.order)-1], map[string]time.Time{})
+}
+
+// LegacyRefreshKeyID — kid секрета REFRESH_SECRET; им же проверяются токены без kid
+const LegacyRefreshKeyID = "default"
+
+// NewRefreshRing собирает связку HMAC-секретов refresh-токенов: legacySecret (kid "default")
+// и список "kid:secret" через запятую
+func NewRefreshRing(legacySecret, secrets string) (*KeyRing, error) {
+	ring := newKeyRing("refresh")
+	if legacySecret != "" {
+		_ = ring.add(hmacKey(LegacyRefreshKeyID, legacySecret))
+	}
+	for _, item := range strings.Split(secrets, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kid, secret, ok := strings.Cut(item, ":")
+		if !ok || kid == "" || secret == "" {
+			return nil, fmt.Errorf("%w: refresh secret must be kid:secret", ErrInvalidKey)
+		}
+		if err := ring.add(hmacKey(kid, secret)); err != nil {
+			return nil, err
+		}
+	}
+	if len(ring.order) == 0 {
+		return nil, fmt.Errorf("%w: no refresh secrets configured", ErrInvalidKey)
+	}
+	return ring, ring.Apply(ring.order[len(ring.order)-1], map[string]time.Time{})
+}
+
+func hmacKey(kid, secret string) *Key {
+	return &Key{ID: kid, Method: jwt.SigningMethodHS256, Sign: []byte(secret), Verify: []byte(secret)}
+}
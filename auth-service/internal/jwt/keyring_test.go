@@ -0,0 +1,52 @@
+package jwt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewAccessRingGeneratesOnlyWhenAllowed(t *testing.T) {
+	k, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pem, err := MarshalSigningKey(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "access.pem")
+	if err := os.WriteFile(keyFile, pem, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name          string
+		keyFile       string
+		allowGenerate bool
+		wantGenerated bool
+		wantErr       error
+	}{
+		{"no keys in production", "", false, false, ErrNoAccessKeys},
+		{"no keys in development", "", true, true, nil},
+		{"key file in production", keyFile, false, false, nil},
+		{"key file in development", keyFile, true, false, nil},
+	}
+	for _, tc := range cases {
+		ring, generated, err := NewAccessRing(tc.keyFile, "", tc.allowGenerate)
+		if !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if generated != tc.wantGenerated {
+			t.Errorf("%s: generated = %v, want %v", tc.name, generated, tc.wantGenerated)
+		}
+		if tc.keyFile != "" && ring.ActiveID() != k.ID {
+			t.Errorf("%s: active kid = %q, want %q", tc.name, ring.ActiveID(), k.ID)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var ErrInvalidKey = errors.New("invalid signing key")
+
+// SigningKey — ключ подписи access-токенов (Ed25519) с идентификатором kid
+type SigningKey struct {
+	ID      string
+	Private ed25519.PrivateKey
+}
+
+func (k *SigningKey) Public() ed25519.PublicKey {
+	return k.Private.Public().(ed25519.PublicKey)
+}
+
+// keyID — отпечаток открытого ключа: первые 16 символов base64url(SHA-256)
+func keyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return base64.RawURLEncoding.EncodeToString(sum[:])[:16]
+}
+
+func newSigningKey(priv ed25519.PrivateKey) *SigningKey {
+	k := &SigningKey{Private: priv}
+	k.ID = keyID(k.Public())
+	return k
+}
+
+// LoadSigningKey читает закрытый ключ Ed25519 из PEM (PKCS#8)
+func LoadSigningKey(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSigningKey(data)
+}
+
+func ParseSigningKey(data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM block", ErrInvalidKey)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	priv, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: not an Ed25519 key", ErrInvalidKey)
+	}
+	return newSigningKey(priv), nil
+}
+
+// GenerateSigningKey создаёт новый ключ Ed25519
+func GenerateSigningKey() (*SigningKey, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return newSigningKey(priv), nil
+}
+
+// MarshalSigningKey кодирует закрытый ключ в PEM (PKCS#8)
+func MarshalSigningKey(k *SigningKey) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(k.Private)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
@@ -16,12 +16,22 @@ type Scheduler struct {
 	rtRepo            *repository.RefreshTokenRepository
 	emailTokenRepo    *repository.EmailVerificationTokenRepository
 	passwordResetRepo *repository.PasswordResetTokenRepository
+	magicLinkRepo     *repository.MagicLinkTokenRepository
+	outboxRepo        *repository.OutboxRepository
+	revocationRepo    *repository.AccessRevocationRepository
+	mfaRepo           *repository.MFARepository
+	webauthnRepo      *repository.WebAuthnRepository
+	oauthRepo         *repository.OAuthRepository
+	oidcRepo          *repository.OIDCRepository
+	loginThrottleRepo *repository.LoginThrottleRepository
+	outboxRetention   time.Duration
+	failureWindow     time.Duration
 }
 
-func NewScheduler(log *zap.Logger, rtRepo *repository.RefreshTokenRepository, emailTokenRepo *repository.EmailVerificationTokenRepository, passwordResetRepo *repository.PasswordResetTokenRepository) *Scheduler {
+func NewScheduler(log *zap.Logger, rtRepo *repository.RefreshTokenRepository, emailTokenRepo *repository.EmailVerificationTokenRepository, passwordResetRepo *repository.PasswordResetTokenRepository, magicLinkRepo *repository.MagicLinkTokenRepository, outboxRepo *repository.OutboxRepository, revocationRepo *repository.AccessRevocationRepository, mfaRepo *repository.MFARepository, webauthnRepo *repository.WebAuthnRepository, oauthRepo *repository.OAuthRepository, oidcRepo *repository.OIDCRepository, loginThrottleRepo *repository.LoginThrottleRepository, outboxRetention, failureWindow time.Duration) *Scheduler {
 	// Используем cron с секундами отключёнными (стандартный 5-полюсный синтаксис) и локацией из системы.
 	c := cron.New(cron.WithParser(cron.NewParser(cron.Minute|cron.Hour|cron.Dom|cron.Month|cron.Dow)), cron.WithChain())
-	return &Scheduler{c: c, log: log, rtRepo: rtRepo, emailTokenRepo: emailTokenRepo, passwordResetRepo: passwordResetRepo}
+	return &Scheduler{c: c, log: log, rtRepo: rtRepo, emailTokenRepo: emailTokenRepo, passwordResetRepo: passwordResetRepo, magicLinkRepo: magicLinkRepo, outboxRepo: outboxRepo, revocationRepo: revocationRepo, mfaRepo: mfaRepo, webauthnRepo: webauthnRepo, oauthRepo: oauthRepo, oidcRepo: oidcRepo, loginThrottleRepo: loginThrottleRepo, outboxRetention: outboxRetention, failureWindow: failureWindow}
 }
 
 func (s *Scheduler) Start(ctx context.Context) error {
@@ -56,6 +66,60 @@ func (s *Scheduler) cleanupExpired() {
 			s.log.Info("Удалены просроченные refresh токены", zap.Int64("count", deleted))
 		}
 	}
+	// Очистка записей об отзыве уже истёкших access токенов
+	if s.revocationRepo != nil {
+		deleted, err := s.revocationRepo.DeleteExpired(now)
+		if err != nil {
+			s.log.Error("Ошибка очистки отозванных access токенов", zap.Error(err))
+		} else if deleted > 0 {
+			s.log.Info("Удалены записи об отзыве истёкших access токенов", zap.Int64("count", deleted))
+		}
+	}
+	// Очистка истёкших вызовов второго фактора
+	if s.mfaRepo != nil {
+		deleted, err := s.mfaRepo.DeleteExpiredChallenges(now)
+		if err != nil {
+			s.log.Error("Ошибка очистки вызовов MFA", zap.Error(err))
+		} else if deleted > 0 {
+			s.log.Info("Удалены истёкшие вызовы MFA", zap.Int64("count", deleted))
+		}
+	}
+	// Очистка незавершённых церемоний WebAuthn
+	if s.webauthnRepo != nil {
+		deleted, err := s.webauthnRepo.DeleteExpiredCeremonies(now)
+		if err != nil {
+			s.log.Error("Ошибка очистки церемоний WebAuthn", zap.Error(err))
+		} else if deleted > 0 {
+			s.log.Info("Удалены истёкшие церемонии WebAuthn", zap.Int64("count", deleted))
+		}
+	}
+	// Очистка незавершённых входов через внешних провайдеров
+	if s.oauthRepo != nil {
+		deleted, err := s.oauthRepo.DeleteExpiredStates(now)
+		if err != nil {
+			s.log.Error("Ошибка очистки состояний OAuth", zap.Error(err))
+		} else if deleted > 0 {
+			s.log.Info("Удалены истёкшие состояния OAuth", zap.Int64("count", deleted))
+		}
+	}
+	// Очистка необменянных кодов авторизации OIDC
+	if s.oidcRepo != nil {
+		deleted, err := s.oidcRepo.DeleteExpiredCodes(now)
+		if err != nil {
+			s.log.Error("Ошибка очистки кодов авторизации OIDC", zap.Error(err))
+		} else if deleted > 0 {
+			s.log.Info("Удалены истёкшие коды авторизации OIDC", zap.Int64("count", deleted))
+		}
+	}
+	// Очистка счётчиков неудачных входов без недавних неудач и действующей блокировки
+	if s.loginThrottleRepo != nil {
+		deleted, err := s.loginThrottleRepo.DeleteStale(now, now.Add(-s.failureWindow))
+		if err != nil {
+			s.log.Error("Ошибка очистки счётчиков неудачных входов", zap.Error(err))
+		} else if deleted > 0 {
+			s.log.Info("Удалены устаревшие счётчики неудачных входов", zap.Int64("count", deleted))
+		}
+	}
 	// Очистка email verification токенов
 	if s.emailTokenRepo != nil {
 		deleted, err := s.emailTokenRepo.DeleteExpired(now)
@@ -74,4 +138,22 @@ func (s *Scheduler) cleanupExpired() {
 			s.log.Info("Удалены просроченные password reset токены", zap.Int64("count", deleted))
 		}
 	}
+	// Очистка ссылок для входа
+	if s.magicLinkRepo != nil {
+		deleted, err := s.magicLinkRepo.DeleteExpired(now)
+		if err != nil {
+			s.log.Error("Ошибка очистки ссылок для входа", zap.Error(err))
+		} else if deleted > 0 {
+			s.log.Info("Удалены использованные и просроченные ссылки для входа", zap.Int64("count", deleted))
+		}
+	}
+	// Очистка отправленных сообщений outbox
+	if s.outboxRepo != nil && s.outboxRetention > 0 {
+		deleted, err := s.outboxRepo.DeleteDeliveredBefore(now.Add(-s.outboxRetention))
+		if err != nil {
+			s.log.Error("Ошибка очистки отправленных сообщений outbox", zap.Error(err))
+		} else if deleted > 0 {
+			s.log.Info("Удалены отправленные сообщения outbox", zap.Int64("count", deleted))
+		}
+	}
 }
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RevokedAccessToken — отозванный access-токен; запись нужна только до истечения токена
+type RevokedAccessToken struct {
+	JTI       string    `gorm:"type:text;primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;index;not null"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// RevokedSession — завершённая сессия: её access-токены (claim sid) недействительны.
+// ExpiresAt — момент, когда истечёт последний такой токен и запись можно удалить.
+type RevokedSession struct {
+	SessionID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;index;not null"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// AccessTokenCutoff — все access-токены пользователя, выданные раньше RevokedBefore, недействительны.
+// ExpiresAt — момент, когда истечёт последний такой токен и запись можно удалить.
+type AccessTokenCutoff struct {
+	UserID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	RevokedBefore time.Time `gorm:"not null"`
+	ExpiresAt     time.Time `gorm:"index;not null"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LoginThrottle — неудачные попытки входа по адресу (kind "email") или с IP (kind "ip")
+type LoginThrottle struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Kind          string    `gorm:"size:16;not null;uniqueIndex:idx_login_throttle"`
+	Key           string    `gorm:"size:320;not null;uniqueIndex:idx_login_throttle"`
+	Failures      int       `gorm:"not null;default:0"`
+	LastFailureAt time.Time `gorm:"index;not null"`
+	LockedUntil   *time.Time
+	// SHA-256 токена из письма о блокировке, снимающего её досрочно
+	UnlockTokenHash *string `gorm:"size:64;uniqueIndex"`
+}
+
+func (m *LoginThrottle) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MagicLinkToken — одноразовая ссылка для входа без пароля
+type MagicLinkToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;index;not null"`
+	Token     string    `gorm:"type:varchar(255);unique;not null"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	Used      bool      `gorm:"default:false;not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (m *MagicLinkToken) BeforeCreate(tx *gorm.DB) (err error) {
+	m.ID = uuid.New()
+	return
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserTOTP — TOTP второго фактора. Пока ConfirmedAt пуст, фактор не включён: пользователь ещё не подтвердил код.
+type UserTOTP struct {
+	UserID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	// Секрет, зашифрованный AES-GCM ключом MFA_ENCRYPTION_KEY
+	Secret      []byte `gorm:"type:bytea;not null"`
+	ConfirmedAt *time.Time
+	// Последний принятый временной шаг: код одного шага принимается только один раз
+	LastStep  int64     `gorm:"not null;default:0"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// RecoveryCode — одноразовый код восстановления; хранится только SHA-256
+type RecoveryCode struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;index;not null"`
+	CodeHash  string    `gorm:"type:text;not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (m *RecoveryCode) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return
+}
+
+// MFAChallenge — промежуточный результат входа: пароль проверен, ожидается второй фактор
+type MFAChallenge struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	TokenHash string    `gorm:"type:text;uniqueIndex;not null"`
+	UserID    uuid.UUID `gorm:"type:uuid;index;not null"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	Attempts  int       `gorm:"not null;default:0"`
+	UsedAt    *time.Time
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (m *MFAChallenge) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return
+}
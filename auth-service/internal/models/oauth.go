@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OAuthState — незавершённый вход через внешнего провайдера. State связывает ответ провайдера
+// с запросом, а CodeVerifier и Nonce никогда не покидают сервис.
+type OAuthState struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
+	State        string    `gorm:"type:varchar(255);uniqueIndex;not null"`
+	Provider     string    `gorm:"size:64;not null"`
+	CodeVerifier string    `gorm:"type:text;not null"`
+	Nonce        string    `gorm:"type:text"`
+	ExpiresAt    time.Time `gorm:"index;not null"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+}
+
+func (m *OAuthState) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return
+}
+
+// ExternalIdentity — учётная запись у внешнего провайдера, привязанная к пользователю
+type ExternalIdentity struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID      uuid.UUID `gorm:"type:uuid;index;not null"`
+	Provider    string    `gorm:"size:64;not null;uniqueIndex:idx_external_identity"`
+	Subject     string    `gorm:"size:255;not null;uniqueIndex:idx_external_identity"`
+	Email       string    `gorm:"type:text"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	LastLoginAt *time.Time
+}
+
+func (m *ExternalIdentity) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return
+}
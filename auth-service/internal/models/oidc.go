@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OIDCClient — приложение, которое входит через LinkVault как через OpenID-провайдера
+type OIDCClient struct {
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ClientID string    `gorm:"size:64;uniqueIndex;not null"`
+	// SHA-256 секрета; пусто у публичных клиентов (SPA, мобильные), их защищает только PKCE
+	SecretHash   string    `gorm:"size:64"`
+	Name         string    `gorm:"size:128;not null"`
+	RedirectURIs []string  `gorm:"serializer:json;type:text;not null"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+}
+
+func (m *OIDCClient) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return
+}
+
+// OIDCAuthCode — одноразовый код авторизации; хранится SHA-256 кода
+type OIDCAuthCode struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey"`
+	CodeHash      string    `gorm:"size:64;uniqueIndex;not null"`
+	ClientID      string    `gorm:"size:64;not null"`
+	UserID        uuid.UUID `gorm:"type:uuid;not null"`
+	RedirectURI   string    `gorm:"type:text;not null"`
+	Scope         string    `gorm:"size:512"`
+	Nonce         string    `gorm:"type:text"`
+	CodeChallenge string    `gorm:"size:128;not null"`
+	ExpiresAt     time.Time `gorm:"index;not null"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+}
+
+func (m *OIDCAuthCode) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return
+}
@@ -14,6 +14,25 @@ type RefreshToken struct {
 	ExpiresAt time.Time `gorm:"index;not null"`
 	Revoked   bool      `gorm:"default:false;not null"`
 	CreatedAt time.Time `gorm:"autoCreateTime"`
+
+	// Семейство — цепочка токенов от одного входа; ParentJTI — токен, обменянный на этот
+	FamilyID  uuid.UUID `gorm:"type:uuid;index"`
+	ParentJTI *string   `gorm:"type:uuid"`
+	// JTI токена, выданного взамен при ротации; повторное предъявление такого токена — признак кражи
+	ReplacedBy *string `gorm:"type:uuid"`
+
+	// Устройство, с которого выдан токен; семейство токенов — это сессия
+	UserAgent  string `gorm:"size:512"`
+	IP         string `gorm:"size:64"`
+	ClientName string `gorm:"size:128"`
+	// Время входа, открывшего сессию; переносится в каждый следующий токен семейства
+	SessionStartedAt *time.Time
+	// Когда токен предъявили для обмена
+	LastUsedAt *time.Time
+
+	// OAuth-клиент, которому выдана сессия через OIDC, и его scope; пусто для приложений LinkVault
+	ClientID string `gorm:"size:64;index"`
+	Scope    string `gorm:"size:512"`
 }
 
 func (m *RefreshToken) BeforeCreate(tx *gorm.DB) (err error) {
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// SigningKeyState — состояние ключа связки (access / refresh), общее для всех экземпляров сервиса.
+// Ключи без записи проверяют подписи, но не подписывают.
+type SigningKeyState struct {
+	Ring   string `gorm:"type:text;primaryKey"`
+	KeyID  string `gorm:"type:text;primaryKey"`
+	Active bool   `gorm:"not null;default:false"`
+	// С этого момента выведенный ключ больше не принимается
+	RetireAt  *time.Time
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
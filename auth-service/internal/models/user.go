@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,5 +19,12 @@ type User struct {
 
 func (m *User) BeforeCreate(tx *gorm.DB) (err error) {
 	m.ID = uuid.New()
+	m.Email = NormalizeEmail(m.Email)
 	return
 }
+
+// NormalizeEmail приводит адрес к виду, в котором он хранится и ищется: без пробелов по краям, в нижнем регистре.
+// Единственное место нормализации: его используют сохранение пользователя, поиск по email и счётчики LoginGuard.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
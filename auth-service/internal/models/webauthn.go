@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebAuthnCredential — ключ доступа (passkey / аппаратный ключ) пользователя
+type WebAuthnCredential struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID       uuid.UUID `gorm:"type:uuid;index;not null"`
+	CredentialID []byte    `gorm:"type:bytea;uniqueIndex;not null"`
+	// Открытый ключ в PKIX DER и его алгоритм COSE
+	PublicKey []byte `gorm:"type:bytea;not null"`
+	Algorithm int64  `gorm:"not null"`
+	// Счётчик подписей аутентификатора; уменьшение означает копию ключа
+	SignCount  int64     `gorm:"not null;default:0"`
+	AAGUID     []byte    `gorm:"type:bytea"`
+	Name       string    `gorm:"size:128"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+	LastUsedAt *time.Time
+}
+
+func (m *WebAuthnCredential) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return
+}
+
+const (
+	WebAuthnRegistration = "registration"
+	WebAuthnLogin        = "login"
+)
+
+// WebAuthnCeremony — выданный challenge незавершённой регистрации или входа; используется один раз
+type WebAuthnCeremony struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Challenge []byte    `gorm:"type:bytea;uniqueIndex;not null"`
+	Kind      string    `gorm:"size:16;not null"`
+	// Пусто для входа без указания пользователя (discoverable credentials)
+	UserID    *uuid.UUID `gorm:"type:uuid"`
+	ExpiresAt time.Time  `gorm:"index;not null"`
+	CreatedAt time.Time  `gorm:"autoCreateTime"`
+}
+
+func (m *WebAuthnCeremony) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return
+}
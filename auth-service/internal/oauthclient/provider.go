@@ -0,0 +1,312 @@
+// Package oauthclient — вход через внешних провайдеров: OAuth 2.0 authorization code с PKCE
+// и проверка ID-токенов OpenID Connect по JWKS провайдера. GitHub не поддерживает OIDC,
+// для него профиль и подтверждённый email берутся из REST API.
+package oauthclient
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	KindOIDC   = "oidc"
+	KindGitHub = "github"
+)
+
+var (
+	ErrExchange = errors.New("code exchange failed")
+	ErrIDToken  = errors.New("invalid id token")
+)
+
+// Config — параметры провайдера. Для OIDC адреса можно не задавать: они берутся из discovery по Issuer.
+type Config struct {
+	Name         string
+	Kind         string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Issuer       string
+	Scopes       []string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	JWKSURL      string
+}
+
+// Identity — пользователь у провайдера
+type Identity struct {
+	// Неизменный идентификатор у провайдера (sub)
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu   sync.Mutex
+	keys *keySet
+	// Discovery выполнен
+	ready bool
+}
+
+// NewProvider проверяет конфигурацию и подставляет адреса GitHub; client задаёт таймауты запросов к провайдеру
+func NewProvider(cfg Config, client *http.Client) (*Provider, error) {
+	if cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("oauth provider %s: client id and redirect url are required", cfg.Name)
+	}
+	switch cfg.Kind {
+	case KindGitHub:
+		cfg.AuthURL = defaultString(cfg.AuthURL, "https://github.com/login/oauth/authorize")
+		cfg.TokenURL = defaultString(cfg.TokenURL, "https://github.com/login/oauth/access_token")
+		cfg.UserInfoURL = defaultString(cfg.UserInfoURL, "https://api.github.com")
+		if len(cfg.Scopes) == 0 {
+			cfg.Scopes = []string{"read:user", "user:email"}
+		}
+	case KindOIDC:
+		if cfg.Issuer == "" {
+			return nil, fmt.Errorf("oauth provider %s: issuer is required", cfg.Name)
+		}
+		cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+		if len(cfg.Scopes) == 0 {
+			cfg.Scopes = []string{"openid", "email", "profile"}
+		}
+	default:
+		return nil, fmt.Errorf("oauth provider %s: unknown kind %q", cfg.Name, cfg.Kind)
+	}
+	return &Provider{cfg: cfg, client: client}, nil
+}
+
+func (p *Provider) Name() string { return p.cfg.Name }
+
+// OIDC сообщает, выдаёт ли провайдер ID-токен (и, значит, нужен nonce)
+func (p *Provider) OIDC() bool { return p.cfg.Kind == KindOIDC }
+
+// AuthCodeURL возвращает адрес страницы входа провайдера
+func (p *Provider) AuthCodeURL(ctx context.Context, state, codeChallenge, nonce string) (string, error) {
+	if err := p.discover(ctx); err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", p.cfg.ClientID)
+	q.Set("redirect_uri", p.cfg.RedirectURL)
+	q.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	q.Set("state", state)
+	q.Set("code_challenge", codeChallenge)
+	q.Set("code_challenge_method", "S256")
+	if nonce != "" {
+		q.Set("nonce", nonce)
+	}
+	sep := "?"
+	if strings.Contains(p.cfg.AuthURL, "?") {
+		sep = "&"
+	}
+	return p.cfg.AuthURL + sep + q.Encode(), nil
+}
+
+// Exchange обменивает code на токены провайдера и возвращает проверенного пользователя
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error) {
+	if err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+	tokens, err := p.exchange(ctx, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	if p.cfg.Kind == KindGitHub {
+		return p.githubIdentity(ctx, tokens.AccessToken)
+	}
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id_token in response", ErrIDToken)
+	}
+	return p.verifyIDToken(ctx, tokens.IDToken, nonce)
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (p *Provider) exchange(ctx context.Context, code, codeVerifier string) (*tokenResponse, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("client_secret", p.cfg.ClientSecret)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExchange, err)
+	}
+	defer resp.Body.Close()
+
+	var tokens tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("%w: status %d", ErrExchange, resp.StatusCode)
+	}
+	// GitHub сообщает об ошибке со статусом 200
+	if resp.StatusCode != http.StatusOK || tokens.Error != "" || tokens.AccessToken == "" {
+		return nil, fmt.Errorf("%w: %s %s", ErrExchange, tokens.Error, tokens.ErrorDescription)
+	}
+	return &tokens, nil
+}
+
+type idClaims struct {
+	Email string `json:"email"`
+	// Часть провайдеров передаёт строку "true"
+	EmailVerified any    `json:"email_verified"`
+	Name          string `json:"name"`
+	Nonce         string `json:"nonce"`
+	jwt.RegisteredClaims
+}
+
+func (p *Provider) verifyIDToken(ctx context.Context, raw, nonce string) (*Identity, error) {
+	claims := &idClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.keys.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "ES256", "EdDSA"}),
+		jwt.WithIssuer(p.cfg.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrIDToken, err)
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrIDToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: empty subject", ErrIDToken)
+	}
+	verified := false
+	switch v := claims.EmailVerified.(type) {
+	case bool:
+		verified = v
+	case string:
+		verified, _ = strconv.ParseBool(v)
+	}
+	return &Identity{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: verified,
+		Name:          claims.Name,
+	}, nil
+}
+
+func (p *Provider) githubIdentity(ctx context.Context, accessToken string) (*Identity, error) {
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := getJSON(ctx, p.client, p.cfg.UserInfoURL+"/user", accessToken, &user); err != nil {
+		return nil, err
+	}
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, p.client, p.cfg.UserInfoURL+"/user/emails", accessToken, &emails); err != nil {
+		return nil, err
+	}
+	id := &Identity{Subject: strconv.FormatInt(user.ID, 10), Name: defaultString(user.Name, user.Login)}
+	for _, e := range emails {
+		if e.Primary {
+			id.Email = e.Email
+			id.EmailVerified = e.Verified
+		}
+	}
+	return id, nil
+}
+
+// discover один раз загружает адреса из /.well-known/openid-configuration
+func (p *Provider) discover(ctx context.Context) error {
+	if p.cfg.Kind != KindOIDC {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.ready {
+		return nil
+	}
+	if p.cfg.AuthURL == "" || p.cfg.TokenURL == "" || p.cfg.JWKSURL == "" {
+		var doc struct {
+			Issuer                string `json:"issuer"`
+			AuthorizationEndpoint string `json:"authorization_endpoint"`
+			TokenEndpoint         string `json:"token_endpoint"`
+			UserInfoEndpoint      string `json:"userinfo_endpoint"`
+			JWKSURI               string `json:"jwks_uri"`
+		}
+		if err := getJSON(ctx, p.client, p.cfg.Issuer+"/.well-known/openid-configuration", "", &doc); err != nil {
+			return fmt.Errorf("oidc discovery: %w", err)
+		}
+		if strings.TrimSuffix(doc.Issuer, "/") != p.cfg.Issuer {
+			return fmt.Errorf("oidc discovery: issuer mismatch %q", doc.Issuer)
+		}
+		p.cfg.AuthURL = defaultString(p.cfg.AuthURL, doc.AuthorizationEndpoint)
+		p.cfg.TokenURL = defaultString(p.cfg.TokenURL, doc.TokenEndpoint)
+		p.cfg.UserInfoURL = defaultString(p.cfg.UserInfoURL, doc.UserInfoEndpoint)
+		p.cfg.JWKSURL = defaultString(p.cfg.JWKSURL, doc.JWKSURI)
+	}
+	p.keys = newKeySet(p.cfg.JWKSURL, p.client)
+	p.ready = true
+	return nil
+}
+
+// NewPKCE возвращает code_verifier и code_challenge (S256, RFC 7636)
+func NewPKCE() (verifier, challenge string, err error) {
+	verifier, err = RandomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// RandomString возвращает n случайных байт в base64url — для state, nonce и verifier
+func RandomString(n int) (string, error) {
+	raw := make([]byte, n)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func defaultString(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,249 @@
+package oauthclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockIssuer — OIDC-провайдер на httptest: discovery, JWKS и token endpoint.
+// Код авторизации выдаётся через authorize, ID-токен подписывается ES256.
+type mockIssuer struct {
+	*httptest.Server
+	key *ecdsa.PrivateKey
+
+	mu sync.Mutex
+	// code → параметры запроса авторизации
+	codes map[string]url.Values
+	// claims переопределяет поля ID-токена
+	claims func(c jwt.MapClaims)
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockIssuer{key: key, codes: map[string]url.Values{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.URL,
+			"authorization_endpoint": m.URL + "/authorize",
+			"token_endpoint":         m.URL + "/token",
+			"jwks_uri":               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		enc := base64.RawURLEncoding
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "EC", "crv": "P-256", "kid": "k1", "use": "sig",
+			"x": enc.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+			"y": enc.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+		}}})
+	})
+	mux.HandleFunc("/token", m.token)
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+// authorize имитирует вход пользователя на странице провайдера и возвращает code
+func (m *mockIssuer) authorize(t *testing.T, authURL string) string {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.Scheme + "://" + u.Host + u.Path; got != m.URL+"/authorize" {
+		t.Fatalf("auth url = %s, want the discovered authorization endpoint", got)
+	}
+	code, _ := RandomString(16)
+	m.mu.Lock()
+	m.codes[code] = u.Query()
+	m.mu.Unlock()
+	return code
+}
+
+func (m *mockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	m.mu.Lock()
+	auth, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	claimsFn := m.claims
+	m.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("client_id") != auth.Get("client_id") ||
+		r.PostForm.Get("redirect_uri") != auth.Get("redirect_uri") ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != auth.Get("code_challenge") {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":            m.URL,
+		"sub":            "user-42",
+		"aud":            auth.Get("client_id"),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          auth.Get("nonce"),
+		"email":          "alice@example.com",
+		"email_verified": true,
+		"name":           "Alice",
+	}
+	if claimsFn != nil {
+		claimsFn(claims)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = "k1"
+	signed, err := token.SignedString(m.key)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "at", "token_type": "Bearer", "id_token": signed})
+}
+
+func newTestProvider(t *testing.T, m *mockIssuer) *Provider {
+	p, err := NewProvider(Config{
+		Name:         "mock",
+		Kind:         KindOIDC,
+		ClientID:     "linkvault",
+		ClientSecret: "secret",
+		RedirectURL:  "https://linkvault.test/oauth/mock/callback",
+		Issuer:       m.URL + "/",
+	}, m.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// login проходит весь поток: адрес авторизации, «вход» у провайдера и обмен кода
+func login(t *testing.T, m *mockIssuer, p *Provider, nonce string) (*Identity, error) {
+	verifier, challenge, err := NewPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := p.AuthCodeURL(context.Background(), "state-1", challenge, nonce)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	code := m.authorize(t, authURL)
+	return p.Exchange(context.Background(), code, verifier, nonce)
+}
+
+func TestOIDCLogin(t *testing.T) {
+	m := newMockIssuer(t)
+	p := newTestProvider(t, m)
+
+	id, err := login(t, m, p, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	want := Identity{Subject: "user-42", Email: "alice@example.com", EmailVerified: true, Name: "Alice"}
+	if *id != want {
+		t.Fatalf("identity = %+v, want %+v", *id, want)
+	}
+}
+
+func TestOIDCEmailVerifiedAsString(t *testing.T) {
+	m := newMockIssuer(t)
+	p := newTestProvider(t, m)
+	m.claims = func(c jwt.MapClaims) { c["email_verified"] = "true" }
+
+	id, err := login(t, m, p, "nonce-1")
+	if err != nil || !id.EmailVerified {
+		t.Fatalf("identity = %+v, %v; want verified email", id, err)
+	}
+}
+
+func TestOIDCRejectsInvalidIDToken(t *testing.T) {
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]func(c jwt.MapClaims){
+		"other issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.test" },
+		"other audience": func(c jwt.MapClaims) { c["aud"] = "another-client" },
+		"expired":        func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"no expiry":      func(c jwt.MapClaims) { delete(c, "exp") },
+		"nonce mismatch": func(c jwt.MapClaims) { c["nonce"] = "replayed" },
+		"empty subject":  func(c jwt.MapClaims) { c["sub"] = "" },
+	}
+	for name, modify := range cases {
+		t.Run(name, func(t *testing.T) {
+			m := newMockIssuer(t)
+			p := newTestProvider(t, m)
+			m.claims = modify
+			if _, err := login(t, m, p, "nonce-1"); !errors.Is(err, ErrIDToken) {
+				t.Fatalf("err = %v, want ErrIDToken", err)
+			}
+		})
+	}
+
+	t.Run("signed by another key", func(t *testing.T) {
+		m := newMockIssuer(t)
+		p := newTestProvider(t, m)
+		m.key = otherKey
+		if _, err := login(t, m, p, "nonce-1"); !errors.Is(err, ErrIDToken) {
+			t.Fatalf("err = %v, want ErrIDToken", err)
+		}
+	})
+}
+
+func TestOIDCRejectsWrongVerifier(t *testing.T) {
+	m := newMockIssuer(t)
+	p := newTestProvider(t, m)
+
+	_, challenge, _ := NewPKCE()
+	authURL, err := p.AuthCodeURL(context.Background(), "state-1", challenge, "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := m.authorize(t, authURL)
+	otherVerifier, _, _ := NewPKCE()
+	if _, err := p.Exchange(context.Background(), code, otherVerifier, "nonce-1"); !errors.Is(err, ErrExchange) {
+		t.Fatalf("err = %v, want ErrExchange", err)
+	}
+}
+
+func TestOIDCDiscoveryIssuerMismatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 "https://evil.test",
+			"authorization_endpoint": "https://evil.test/authorize",
+			"token_endpoint":         "https://evil.test/token",
+			"jwks_uri":               "https://evil.test/jwks",
+		})
+	}))
+	defer srv.Close()
+	p, err := NewProvider(Config{
+		Name:        "mock",
+		Kind:        KindOIDC,
+		ClientID:    "linkvault",
+		RedirectURL: "https://linkvault.test/oauth/mock/callback",
+		Issuer:      srv.URL,
+	}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.AuthCodeURL(context.Background(), "state", "challenge", "nonce"); err == nil {
+		t.Fatal("AuthCodeURL accepted a discovery document for another issuer")
+	}
+}
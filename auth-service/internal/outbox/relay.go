@@ -0,0 +1,147 @@
+// Package outbox отправляет в брокер сообщения, записанные в таблицу outbox_messages.
+//
+// Такой же пакет есть в link-service: сервисы — отдельные Go-модули без общей
+// библиотеки, у каждого своя таблица и свой ключ advisory-блокировки релея.
+// Изменения релея нужно переносить в обе копии.
+package outbox
+
+import (
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Sender доставляет пачку сообщений во внешний брокер одним запросом.
+// Если часть сообщений не принята, возвращает BatchError.
+type Sender interface {
+	Send(ctx context.Context, msgs []models.OutboxMessage) error
+}
+
+// BatchError — ошибки отдельных сообщений пачки по порядку; nil — сообщение доставлено
+type BatchError []error
+
+func (e BatchError) Error() string {
+	failed := 0
+	for _, err := range e {
+		if err != nil {
+			failed++
+		}
+	}
+	return fmt.Sprintf("outbox: %d of %d messages failed", failed, len(e))
+}
+
+type Config struct {
+	Interval  time.Duration
+	BatchSize int
+	// После стольких неудачных попыток сообщение считается недоставляемым и больше не отправляется
+	MaxAttempts int
+}
+
+// Relay периодически переносит неотправленные сообщения из outbox в брокер.
+// Доставка — at-least-once: потребители должны быть готовы к повторам (заголовок outbox-id).
+type Relay struct {
+	tx     *repository.Transactor
+	repo   *repository.OutboxRepository
+	sender Sender
+	cfg    Config
+	log    *zap.Logger
+}
+
+func NewRelay(tx *repository.Transactor, repo *repository.OutboxRepository, sender Sender, cfg Config, log *zap.Logger) *Relay {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 10
+	}
+	return &Relay{tx: tx, repo: repo, sender: sender, cfg: cfg, log: log}
+}
+
+// Run работает до отмены ctx
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.flush(ctx); err != nil && ctx.Err() == nil {
+				r.log.Error("Ошибка отправки сообщений outbox", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (r *Relay) flush(ctx context.Context) error {
+	return r.tx.Do(func(tx *gorm.DB) error {
+		repo := r.repo.WithTx(tx)
+		locked, err := repo.TryLockRelay()
+		if err != nil || !locked {
+			return err
+		}
+		msgs, err := repo.FetchPending(time.Now(), r.cfg.BatchSize)
+		if err != nil || len(msgs) == 0 {
+			return err
+		}
+
+		// Сообщения одного ключа попадают в одну партицию и принимаются или отклоняются вместе,
+		// поэтому пачка не нарушает порядок по ключу
+		errs := make([]error, len(msgs))
+		if err := r.sender.Send(ctx, msgs); err != nil {
+			if ctx.Err() != nil {
+				// Остановка сервиса — не повод тратить попытку
+				return ctx.Err()
+			}
+			var batchErr BatchError
+			if errors.As(err, &batchErr) && len(batchErr) == len(msgs) {
+				copy(errs, batchErr)
+			} else {
+				for i := range errs {
+					errs[i] = err
+				}
+			}
+		}
+
+		now := time.Now()
+		delivered := make([]uint64, 0, len(msgs))
+		for i := range msgs {
+			if errs[i] == nil {
+				delivered = append(delivered, msgs[i].ID)
+				continue
+			}
+			if err := r.markFailed(repo, &msgs[i], errs[i], now); err != nil {
+				return err
+			}
+		}
+		return repo.MarkDelivered(delivered, now)
+	})
+}
+
+// markFailed откладывает повтор сообщения, а после MaxAttempts попыток убирает его из очереди,
+// чтобы оно не задерживало следующие сообщения своего ключа
+func (r *Relay) markFailed(repo *repository.OutboxRepository, msg *models.OutboxMessage, sendErr error, now time.Time) error {
+	attempts := msg.Attempts + 1
+	fields := []zap.Field{zap.Uint64("id", msg.ID), zap.String("topic", msg.Topic), zap.Int("attempts", attempts), zap.Error(sendErr)}
+	if attempts >= r.cfg.MaxAttempts {
+		r.log.Error("Сообщение outbox не доставлено, попытки исчерпаны", fields...)
+		return repo.MarkDead(msg.ID, attempts, sendErr.Error(), now)
+	}
+	r.log.Warn("Не удалось отправить сообщение outbox", fields...)
+	return repo.MarkFailed(msg.ID, attempts, sendErr.Error(), now.Add(backoff(attempts)))
+}
+
+// backoff — экспоненциальная задержка повтора, не больше 10 минут
+func backoff(attempts int) time.Duration {
+	d := time.Second << min(attempts, 10)
+	return min(d, 10*time.Minute)
+}
@@ -1,24 +1,29 @@
 package producer
 
 import (
+	"auth-service/internal/models"
+	"auth-service/internal/outbox"
 	"context"
-	"encoding/json"
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/segmentio/kafka-go"
 )
 
-type EmailProducer struct {
+// KafkaProducer отправляет сообщения outbox в Kafka; топик берётся из сообщения
+type KafkaProducer struct {
 	writer *kafka.Writer
 }
 
-func NewEmailProducer(brokers []string, topic string) *EmailProducer {
-	return &EmailProducer{
+func NewKafkaProducer(brokers []string) *KafkaProducer {
+	return &KafkaProducer{
 		writer: &kafka.Writer{
-			Addr:         kafka.TCP(brokers...),
-			Topic:        topic,
-			Balancer:     &kafka.LeastBytes{},
+			Addr: kafka.TCP(brokers...),
+			// Hash по ключу сохраняет порядок сообщений одного ключа внутри партиции
+			Balancer:     &kafka.Hash{},
 			RequiredAcks: kafka.RequireAll,
+			BatchTimeout: 10 * time.Millisecond,
 		},
 	}
 }
@@ -30,19 +35,33 @@ type EmailMessage struct {
 	Data     map[string]any `json:"data"`
 }
 
-func (p *EmailProducer) SendEmail(key string, msg EmailMessage) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// Send отправляет пачку сообщений outbox одним вызовом WriteMessages
+func (p *KafkaProducer) Send(ctx context.Context, msgs []models.OutboxMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
-	value, err := json.Marshal(msg)
-	if err != nil {
-		return err
+	batch := make([]kafka.Message, 0, len(msgs))
+	for i := range msgs {
+		msg := &msgs[i]
+		// outbox-id позволяет потребителям отбрасывать повторы
+		headers := []kafka.Header{{Key: "outbox-id", Value: []byte(strconv.FormatUint(msg.ID, 10))}}
+		for k, v := range msg.HeaderMap() {
+			headers = append(headers, kafka.Header{Key: k, Value: []byte(v)})
+		}
+		batch = append(batch, kafka.Message{
+			Topic:   msg.Topic,
+			Key:     []byte(msg.Key),
+			Value:   msg.Payload,
+			Headers: headers,
+		})
 	}
-	return p.writer.WriteMessages(ctx, kafka.Message{
-		Key:   []byte(key),
-		Value: value,
-	})
+	err := p.writer.WriteMessages(ctx, batch...)
+	var writeErrs kafka.WriteErrors
+	if errors.As(err, &writeErrs) {
+		return outbox.BatchError(writeErrs)
+	}
+	return err
 }
 
-func (p *EmailProducer) Close() error {
+func (p *KafkaProducer) Close() error {
 	return p.writer.Close()
 }
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"time"
+
+	"auth-service/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AccessRevocationRepository struct {
+	db *gorm.DB
+}
+
+func NewAccessRevocationRepository(db *gorm.DB) *AccessRevocationRepository {
+	return &AccessRevocationRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий в транзакции tx
+func (r *AccessRevocationRepository) WithTx(tx *gorm.DB) *AccessRevocationRepository {
+	return &AccessRevocationRepository{db: tx}
+}
+
+func (r *AccessRevocationRepository) RevokeJTI(jti string, userID uuid.UUID, expiresAt time.Time) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RevokedAccessToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+// RevokeUserBefore отзывает все access-токены пользователя, выданные раньше before
+func (r *AccessRevocationRepository) RevokeUserBefore(userID uuid.UUID, before, expiresAt time.Time) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revoked_before", "expires_at"}),
+	}).Create(&models.AccessTokenCutoff{
+		UserID:        userID,
+		RevokedBefore: before,
+		ExpiresAt:     expiresAt,
+	}).Error
+}
+
+// RevokeSessions отзывает все access-токены сессий пользователя
+func (r *AccessRevocationRepository) RevokeSessions(userID uuid.UUID, sessionIDs []uuid.UUID, expiresAt time.Time) error {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+	sessions := make([]models.RevokedSession, len(sessionIDs))
+	for i, id := range sessionIDs {
+		sessions[i] = models.RevokedSession{SessionID: id, UserID: userID, ExpiresAt: expiresAt}
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "session_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"expires_at"}),
+	}).Create(&sessions).Error
+}
+
+// IsRevoked проверяет токен по списку отозванных JTI, завершённым сессиям и отсечке пользователя
+func (r *AccessRevocationRepository) IsRevoked(jti string, userID, sessionID uuid.UUID, issuedAt time.Time) (bool, error) {
+	var revoked bool
+	err := r.db.Raw(`SELECT EXISTS (SELECT 1 FROM revoked_access_tokens WHERE jti = ?)
+		OR EXISTS (SELECT 1 FROM revoked_sessions WHERE session_id = ?)
+		OR EXISTS (SELECT 1 FROM access_token_cutoffs WHERE user_id = ? AND revoked_before > ?)`,
+		jti, sessionID, userID, issuedAt).Scan(&revoked).Error
+	return revoked, err
+}
+
+// ListActive — записи об отзыве ещё не истёкших токенов (для локальной проверки в других сервисах)
+func (r *AccessRevocationRepository) ListActive(now time.Time) ([]models.RevokedAccessToken, []models.RevokedSession, []models.AccessTokenCutoff, error) {
+	var tokens []models.RevokedAccessToken
+	if err := r.db.Where("expires_at >= ?", now).Find(&tokens).Error; err != nil {
+		return nil, nil, nil, err
+	}
+	var sessions []models.RevokedSession
+	if err := r.db.Where("expires_at >= ?", now).Find(&sessions).Error; err != nil {
+		return nil, nil, nil, err
+	}
+	var cutoffs []models.AccessTokenCutoff
+	if err := r.db.Where("expires_at >= ?", now).Find(&cutoffs).Error; err != nil {
+		return nil, nil, nil, err
+	}
+	return tokens, sessions, cutoffs, nil
+}
+
+// DeleteExpired удаляет записи об уже истёкших токенах и возвращает число удалённых строк
+func (r *AccessRevocationRepository) DeleteExpired(now time.Time) (int64, error) {
+	res := r.db.Where("expires_at < ?", now).Delete(&models.RevokedAccessToken{})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	deleted := res.RowsAffected
+	res = r.db.Where("expires_at < ?", now).Delete(&models.RevokedSession{})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	deleted += res.RowsAffected
+	res = r.db.Where("expires_at < ?", now).Delete(&models.AccessTokenCutoff{})
+	return deleted + res.RowsAffected, res.Error
+}
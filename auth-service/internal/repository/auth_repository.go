@@ -17,9 +17,14 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	}
 }
 
+// WithTx возвращает репозиторий, работающий в транзакции tx
+func (r *UserRepository) WithTx(tx *gorm.DB) *UserRepository {
+	return &UserRepository{db: tx}
+}
+
 func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
 	var user models.User
-	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+	if err := r.db.Where("email = ?", models.NormalizeEmail(email)).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
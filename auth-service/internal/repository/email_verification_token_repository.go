@@ -16,6 +16,11 @@ func NewEmailVerificationTokenRepository(db *gorm.DB) *EmailVerificationTokenRep
 	return &EmailVerificationTokenRepository{db: db}
 }
 
+// WithTx возвращает репозиторий, работающий в транзакции tx
+func (r *EmailVerificationTokenRepository) WithTx(tx *gorm.DB) *EmailVerificationTokenRepository {
+	return &EmailVerificationTokenRepository{db: tx}
+}
+
 func (r *EmailVerificationTokenRepository) Create(token *models.EmailVerificationToken) error {
 	return r.db.Create(token).Error
 }
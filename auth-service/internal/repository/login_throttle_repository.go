@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"time"
+
+	"auth-service/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LoginThrottleRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginThrottleRepository(db *gorm.DB) *LoginThrottleRepository {
+	return &LoginThrottleRepository{db: db}
+}
+
+func (r *LoginThrottleRepository) Find(kind, key string) (*models.LoginThrottle, error) {
+	var t models.LoginThrottle
+	if err := r.db.Where("kind = ? AND key = ?", kind, key).First(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// RegisterFailure атомарно увеличивает счётчик неудач и возвращает его. Счётчик начинается
+// заново, если предыдущая неудача была раньше windowStart.
+func (r *LoginThrottleRepository) RegisterFailure(kind, key string, now, windowStart time.Time) (*models.LoginThrottle, error) {
+	t := models.LoginThrottle{Kind: kind, Key: key, Failures: 1, LastFailureAt: now}
+	err := r.db.Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "kind"}, {Name: "key"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"failures":        gorm.Expr("CASE WHEN login_throttles.last_failure_at > ? THEN login_throttles.failures + 1 ELSE 1 END", windowStart),
+				"last_failure_at": now,
+			}),
+		},
+		clause.Returning{},
+	).Create(&t).Error
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Lock блокирует вход до until и обнуляет счётчик: после блокировки задержки начинаются сначала
+func (r *LoginThrottleRepository) Lock(kind, key string, until time.Time, unlockTokenHash *string) error {
+	return r.db.Model(&models.LoginThrottle{}).Where("kind = ? AND key = ?", kind, key).
+		Updates(map[string]interface{}{"failures": 0, "locked_until": until, "unlock_token_hash": unlockTokenHash}).Error
+}
+
+// Reset удаляет счётчик после успешного входа
+func (r *LoginThrottleRepository) Reset(kind, key string) error {
+	return r.db.Where("kind = ? AND key = ?", kind, key).Delete(&models.LoginThrottle{}).Error
+}
+
+// Unlock снимает действующую блокировку по токену из письма; false — токен неизвестен или блокировка уже истекла
+func (r *LoginThrottleRepository) Unlock(unlockTokenHash string, now time.Time) (bool, error) {
+	res := r.db.Where("unlock_token_hash = ? AND locked_until > ?", unlockTokenHash, now).Delete(&models.LoginThrottle{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// DeleteStale удаляет счётчики без недавних неудач и действующей блокировки
+func (r *LoginThrottleRepository) DeleteStale(now, windowStart time.Time) (int64, error) {
+	res := r.db.Where("last_failure_at <= ? AND (locked_until IS NULL OR locked_until <= ?)", windowStart, now).
+		Delete(&models.LoginThrottle{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MagicLinkTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewMagicLinkTokenRepository(db *gorm.DB) *MagicLinkTokenRepository {
+	return &MagicLinkTokenRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий в транзакции tx
+func (r *MagicLinkTokenRepository) WithTx(tx *gorm.DB) *MagicLinkTokenRepository {
+	return &MagicLinkTokenRepository{db: tx}
+}
+
+func (r *MagicLinkTokenRepository) Create(token *models.MagicLinkToken) error {
+	return r.db.Create(token).Error
+}
+
+// Consume гасит действующий токен и возвращает его; одновременный повторный вызов получит ErrRecordNotFound
+func (r *MagicLinkTokenRepository) Consume(token string) (*models.MagicLinkToken, error) {
+	var mlt models.MagicLinkToken
+	res := r.db.Model(&mlt).Clauses(clause.Returning{}).
+		Where("token = ? AND used = false AND expires_at > ?", token, time.Now()).
+		Update("used", true)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &mlt, nil
+}
+
+// CountSince возвращает число ссылок, запрошенных пользователем начиная с since
+func (r *MagicLinkTokenRepository) CountSince(userID uuid.UUID, since time.Time) (int64, error) {
+	var n int64
+	err := r.db.Model(&models.MagicLinkToken{}).Where("user_id = ? AND created_at > ?", userID, since).Count(&n).Error
+	return n, err
+}
+
+// Использованные и истёкшие ссылки хранятся сутки: по ним считается частота запросов
+const magicLinkRetention = 24 * time.Hour
+
+// DeleteExpired удаляет использованные и истёкшие ссылки старше суток и возвращает число удалённых строк
+func (r *MagicLinkTokenRepository) DeleteExpired(now time.Time) (int64, error) {
+	res := r.db.Where("created_at <= ? AND (expires_at <= ? OR used = true)", now.Add(-magicLinkRetention), now).
+		Delete(&models.MagicLinkToken{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"time"
+
+	"auth-service/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MFARepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) *MFARepository {
+	return &MFARepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий в транзакции tx
+func (r *MFARepository) WithTx(tx *gorm.DB) *MFARepository {
+	return &MFARepository{db: tx}
+}
+
+func (r *MFARepository) FindTOTP(userID uuid.UUID) (*models.UserTOTP, error) {
+	var t models.UserTOTP
+	if err := r.db.Where("user_id = ?", userID).First(&t).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// SaveTOTP записывает новый неподтверждённый секрет, заменяя прежний
+func (r *MFARepository) SaveTOTP(t *models.UserTOTP) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "confirmed_at", "last_step", "created_at"}),
+	}).Create(t).Error
+}
+
+// ConfirmTOTP включает фактор; step — шаг кода, которым подтвердили
+func (r *MFARepository) ConfirmTOTP(userID uuid.UUID, step int64, at time.Time) error {
+	return r.db.Model(&models.UserTOTP{}).Where("user_id = ?", userID).
+		Updates(map[string]interface{}{"confirmed_at": at, "last_step": step}).Error
+}
+
+// UseStep запоминает принятый шаг; false — код этого или более позднего шага уже использован
+func (r *MFARepository) UseStep(userID uuid.UUID, step int64) (bool, error) {
+	res := r.db.Model(&models.UserTOTP{}).
+		Where("user_id = ? AND last_step < ?", userID, step).
+		Update("last_step", step)
+	return res.RowsAffected == 1, res.Error
+}
+
+// DeleteTOTP отключает фактор и удаляет коды восстановления
+func (r *MFARepository) DeleteTOTP(userID uuid.UUID) error {
+	if err := r.db.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	return r.db.Where("user_id = ?", userID).Delete(&models.UserTOTP{}).Error
+}
+
+// ReplaceRecoveryCodes удаляет прежние коды восстановления и сохраняет новые
+func (r *MFARepository) ReplaceRecoveryCodes(userID uuid.UUID, hashes []string) error {
+	if err := r.db.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	codes := make([]models.RecoveryCode, 0, len(hashes))
+	for _, h := range hashes {
+		codes = append(codes, models.RecoveryCode{UserID: userID, CodeHash: h})
+	}
+	return r.db.Create(&codes).Error
+}
+
+// UseRecoveryCode гасит код восстановления; false — кода нет или он уже использован
+func (r *MFARepository) UseRecoveryCode(userID uuid.UUID, hash string) (bool, error) {
+	res := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *MFARepository) CountRecoveryCodes(userID uuid.UUID) (int64, error) {
+	var n int64
+	err := r.db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&n).Error
+	return n, err
+}
+
+func (r *MFARepository) CreateChallenge(c *models.MFAChallenge) error {
+	return r.db.Create(c).Error
+}
+
+// FindChallenge возвращает неиспользованный и не истёкший вызов
+func (r *MFARepository) FindChallenge(tokenHash string) (*models.MFAChallenge, error) {
+	var c models.MFAChallenge
+	err := r.db.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, time.Now()).First(&c).Error
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// TakeChallengeAttempt засчитывает попытку ввода кода; false — попытки исчерпаны
+func (r *MFARepository) TakeChallengeAttempt(id uuid.UUID, max int) (bool, error) {
+	res := r.db.Model(&models.MFAChallenge{}).
+		Where("id = ? AND attempts < ?", id, max).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	return res.RowsAffected == 1, res.Error
+}
+
+// UseChallenge гасит вызов; false — его уже обменяли
+func (r *MFARepository) UseChallenge(id uuid.UUID) (bool, error) {
+	res := r.db.Model(&models.MFAChallenge{}).Where("id = ? AND used_at IS NULL", id).Update("used_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
+
+// DeleteExpiredChallenges удаляет истёкшие вызовы и возвращает число удалённых строк
+func (r *MFARepository) DeleteExpiredChallenges(now time.Time) (int64, error) {
+	res := r.db.Where("expires_at <= ?", now).Delete(&models.MFAChallenge{})
+	return res.RowsAffected, res.Error
+}
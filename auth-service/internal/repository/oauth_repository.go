@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"time"
+
+	"auth-service/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OAuthRepository struct {
+	db *gorm.DB
+}
+
+func NewOAuthRepository(db *gorm.DB) *OAuthRepository {
+	return &OAuthRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий в транзакции tx
+func (r *OAuthRepository) WithTx(tx *gorm.DB) *OAuthRepository {
+	return &OAuthRepository{db: tx}
+}
+
+func (r *OAuthRepository) CreateState(s *models.OAuthState) error {
+	return r.db.Create(s).Error
+}
+
+// TakeState удаляет и возвращает не истёкший state провайдера — повторно его не использовать
+func (r *OAuthRepository) TakeState(state, provider string) (*models.OAuthState, error) {
+	var s models.OAuthState
+	res := r.db.Clauses(clause.Returning{}).
+		Where("state = ? AND provider = ? AND expires_at > ?", state, provider, time.Now()).
+		Delete(&s)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &s, nil
+}
+
+func (r *OAuthRepository) FindIdentity(provider, subject string) (*models.ExternalIdentity, error) {
+	var id models.ExternalIdentity
+	if err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&id).Error; err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (r *OAuthRepository) CreateIdentity(id *models.ExternalIdentity) error {
+	return r.db.Create(id).Error
+}
+
+func (r *OAuthRepository) ListIdentities(userID uuid.UUID) ([]models.ExternalIdentity, error) {
+	var ids []models.ExternalIdentity
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&ids).Error
+	return ids, err
+}
+
+// TouchIdentity запоминает время входа и актуальный email у провайдера
+func (r *OAuthRepository) TouchIdentity(id uuid.UUID, email string) error {
+	return r.db.Model(&models.ExternalIdentity{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"last_login_at": time.Now(), "email": email}).Error
+}
+
+func (r *OAuthRepository) DeleteIdentity(userID, id uuid.UUID) (int64, error) {
+	res := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.ExternalIdentity{})
+	return res.RowsAffected, res.Error
+}
+
+// DeleteExpiredStates удаляет незавершённые входы и возвращает число удалённых строк
+func (r *OAuthRepository) DeleteExpiredStates(now time.Time) (int64, error) {
+	res := r.db.Where("expires_at <= ?", now).Delete(&models.OAuthState{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"time"
+
+	"auth-service/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OIDCRepository struct {
+	db *gorm.DB
+}
+
+func NewOIDCRepository(db *gorm.DB) *OIDCRepository {
+	return &OIDCRepository{db: db}
+}
+
+func (r *OIDCRepository) CreateClient(c *models.OIDCClient) error {
+	return r.db.Create(c).Error
+}
+
+func (r *OIDCRepository) FindClient(clientID string) (*models.OIDCClient, error) {
+	var c models.OIDCClient
+	if err := r.db.Where("client_id = ?", clientID).First(&c).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *OIDCRepository) ListClients() ([]models.OIDCClient, error) {
+	var clients []models.OIDCClient
+	err := r.db.Order("created_at").Find(&clients).Error
+	return clients, err
+}
+
+func (r *OIDCRepository) DeleteClient(clientID string) (int64, error) {
+	res := r.db.Where("client_id = ?", clientID).Delete(&models.OIDCClient{})
+	return res.RowsAffected, res.Error
+}
+
+func (r *OIDCRepository) CreateCode(c *models.OIDCAuthCode) error {
+	return r.db.Create(c).Error
+}
+
+// TakeCode удаляет и возвращает не истёкший код: обменять его можно только один раз
+func (r *OIDCRepository) TakeCode(codeHash string) (*models.OIDCAuthCode, error) {
+	var c models.OIDCAuthCode
+	res := r.db.Clauses(clause.Returning{}).
+		Where("code_hash = ? AND expires_at > ?", codeHash, time.Now()).
+		Delete(&c)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &c, nil
+}
+
+// DeleteExpiredCodes удаляет необменянные коды и возвращает число удалённых строк
+func (r *OIDCRepository) DeleteExpiredCodes(now time.Time) (int64, error) {
+	res := r.db.Where("expires_at <= ?", now).Delete(&models.OIDCAuthCode{})
+	return res.RowsAffected, res.Error
+}
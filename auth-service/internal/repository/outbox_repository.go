@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"auth-service/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Ключ advisory-блокировки релея: одновременно сообщения отправляет только один экземпляр сервиса
+const outboxRelayLockID = 7_310_001
+
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий в транзакции tx
+func (r *OutboxRepository) WithTx(tx *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{db: tx}
+}
+
+func (r *OutboxRepository) Create(msg *models.OutboxMessage) error {
+	return r.db.Create(msg).Error
+}
+
+// TryLockRelay захватывает блокировку до конца текущей транзакции
+func (r *OutboxRepository) TryLockRelay() (bool, error) {
+	var locked bool
+	err := r.db.Raw("SELECT pg_try_advisory_xact_lock(?)", outboxRelayLockID).Scan(&locked).Error
+	return locked, err
+}
+
+// FetchPending — сообщения, которым пора уходить, в порядке записи. Сообщение ждёт, пока более
+// раннее сообщение того же ключа стоит в очереди на повтор: иначе нарушился бы порядок.
+func (r *OutboxRepository) FetchPending(now time.Time, limit int) ([]models.OutboxMessage, error) {
+	var msgs []models.OutboxMessage
+	err := r.db.
+		Where("delivered_at IS NULL AND dead_at IS NULL AND next_attempt_at <= ?", now).
+		Where(`NOT EXISTS (SELECT 1 FROM outbox_messages p
+			WHERE p.topic = outbox_messages.topic AND p.key = outbox_messages.key AND p.id < outbox_messages.id
+			AND p.delivered_at IS NULL AND p.dead_at IS NULL AND p.next_attempt_at > ?)`, now).
+		Order("id").Limit(limit).Find(&msgs).Error
+	return msgs, err
+}
+
+func (r *OutboxRepository) MarkDelivered(ids []uint64, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.OutboxMessage{}).Where("id IN ?", ids).Update("delivered_at", at).Error
+}
+
+func (r *OutboxRepository) MarkFailed(id uint64, attempts int, lastError string, nextAttemptAt time.Time) error {
+	return r.db.Model(&models.OutboxMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"attempts": attempts, "last_error": lastError, "next_attempt_at": nextAttemptAt}).Error
+}
+
+// MarkDead убирает сообщение из очереди после исчерпания попыток; строка остаётся для разбора
+func (r *OutboxRepository) MarkDead(id uint64, attempts int, lastError string, at time.Time) error {
+	return r.db.Model(&models.OutboxMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"attempts": attempts, "last_error": lastError, "dead_at": at}).Error
+}
+
+func (r *OutboxRepository) DeleteDeliveredBefore(t time.Time) (int64, error) {
+	res := r.db.Where("delivered_at IS NOT NULL AND delivered_at < ?", t).Delete(&models.OutboxMessage{})
+	return res.RowsAffected, res.Error
+}
@@ -16,6 +16,11 @@ func NewPasswordResetTokenRepository(db *gorm.DB) *PasswordResetTokenRepository
 	return &PasswordResetTokenRepository{db: db}
 }
 
+// WithTx возвращает репозиторий, работающий в транзакции tx
+func (r *PasswordResetTokenRepository) WithTx(tx *gorm.DB) *PasswordResetTokenRepository {
+	return &PasswordResetTokenRepository{db: tx}
+}
+
 func (r *PasswordResetTokenRepository) Create(token *models.PasswordResetToken) error {
 	return r.db.Create(token).Error
 }
@@ -17,6 +17,11 @@ func NewRefreshTokenRepository(db *gorm.DB) *RefreshTokenRepository {
 	return &RefreshTokenRepository{db: db}
 }
 
+// WithTx возвращает репозиторий, работающий в транзакции tx
+func (r *RefreshTokenRepository) WithTx(tx *gorm.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: tx}
+}
+
 func (r *RefreshTokenRepository) Create(rt *models.RefreshToken) error {
 	return r.db.Create(rt).Error
 }
@@ -29,6 +34,71 @@ func (r *RefreshTokenRepository) FindValid(jti string) (*models.RefreshToken, er
 	return &rt, nil
 }
 
+// FindByJTI возвращает токен в любом состоянии
+func (r *RefreshTokenRepository) FindByJTI(jti string) (*models.RefreshToken, error) {
+	var rt models.RefreshToken
+	if err := r.db.Where("jti = ?", jti).First(&rt).Error; err != nil {
+		return nil, err
+	}
+	return &rt, nil
+}
+
+// Rotate отзывает действующий токен, помечая его заменённым; false — токен уже был отозван или истёк
+func (r *RefreshTokenRepository) Rotate(jti, replacedBy string) (bool, error) {
+	now := time.Now()
+	res := r.db.Model(&models.RefreshToken{}).
+		Where("jti = ? AND revoked = false AND expires_at > ?", jti, now).
+		Updates(map[string]interface{}{"revoked": true, "replaced_by": replacedBy, "last_used_at": now})
+	return res.RowsAffected == 1, res.Error
+}
+
+// ListActiveByUser возвращает действующие токены пользователя — по одному на сессию, новые первыми
+func (r *RefreshTokenRepository) ListActiveByUser(userID uuid.UUID) ([]models.RefreshToken, error) {
+	var tokens []models.RefreshToken
+	err := r.db.Where("user_id = ? AND revoked = false AND expires_at > ?", userID, time.Now()).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
+
+// Сессия токена, выданного до появления семейств, — его собственный id
+const sessionExpr = "COALESCE(family_id, id)"
+
+// RevokeSession отзывает токены одной сессии пользователя
+func (r *RefreshTokenRepository) RevokeSession(userID, sessionID uuid.UUID) (int64, error) {
+	res := r.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked = false AND "+sessionExpr+" = ?", userID, sessionID).
+		Update("revoked", true)
+	return res.RowsAffected, res.Error
+}
+
+// RevokeOtherSessions отзывает токены всех сессий пользователя, кроме keepID, и возвращает отозванные сессии
+func (r *RefreshTokenRepository) RevokeOtherSessions(userID, keepID uuid.UUID) ([]uuid.UUID, error) {
+	var revoked []uuid.UUID
+	err := r.db.Raw(`UPDATE refresh_tokens SET revoked = true
+		WHERE user_id = ? AND revoked = false AND `+sessionExpr+` <> ?
+		RETURNING `+sessionExpr, userID, keepID).Scan(&revoked).Error
+	if err != nil {
+		return nil, err
+	}
+	// В сессии может оказаться несколько действующих токенов
+	sessions := make([]uuid.UUID, 0, len(revoked))
+	seen := make(map[uuid.UUID]bool, len(revoked))
+	for _, id := range revoked {
+		if !seen[id] {
+			seen[id] = true
+			sessions = append(sessions, id)
+		}
+	}
+	return sessions, nil
+}
+
+// RevokeFamily отзывает все токены семейства
+func (r *RefreshTokenRepository) RevokeFamily(familyID uuid.UUID) (int64, error) {
+	res := r.db.Model(&models.RefreshToken{}).Where("family_id = ? AND revoked = false", familyID).Update("revoked", true)
+	return res.RowsAffected, res.Error
+}
+
 func (r *RefreshTokenRepository) RevokeByJTI(jti string) error {
 	return r.db.Model(&models.RefreshToken{}).Where("jti = ?", jti).Update("revoked", true).Error
 }
@@ -38,7 +108,8 @@ func (r *RefreshTokenRepository) RevokeAllForUser(userID uuid.UUID) error {
 }
 
 // DeleteExpired удаляет все просроченные refresh токены (независимо от revoked) и возвращает число удалённых строк.
+// Отозванные, но не истёкшие токены остаются: по ним распознаётся повторное использование.
 func (r *RefreshTokenRepository) DeleteExpired(now time.Time) (int64, error) {
-	res := r.db.Where("expires_at <= ? OR revoked = true", now).Delete(&models.RefreshToken{})
+	res := r.db.Where("expires_at <= ?", now).Delete(&models.RefreshToken{})
 	return res.RowsAffected, res.Error
 }
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"time"
+
+	"auth-service/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SigningKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewSigningKeyRepository(db *gorm.DB) *SigningKeyRepository {
+	return &SigningKeyRepository{db: db}
+}
+
+func (r *SigningKeyRepository) List() ([]models.SigningKeyState, error) {
+	var states []models.SigningKeyState
+	err := r.db.Order("ring, key_id").Find(&states).Error
+	return states, err
+}
+
+// Promote делает keyID активным ключом связки ring; прежний активный ключ проверяет подписи до retireAt
+func (r *SigningKeyRepository) Promote(ring, keyID, previousID string, retireAt time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.SigningKeyState{}).
+			Where("ring = ? AND active = true", ring).
+			Updates(map[string]interface{}{"active": false, "retire_at": retireAt}).Error; err != nil {
+			return err
+		}
+		// Ключ, активный по умолчанию, мог ещё не иметь записи
+		if previousID != "" && previousID != keyID {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&models.SigningKeyState{Ring: ring, KeyID: previousID, RetireAt: &retireAt}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "ring"}, {Name: "key_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"active": true, "retire_at": nil, "updated_at": time.Now()}),
+		}).Create(&models.SigningKeyState{Ring: ring, KeyID: keyID, Active: true}).Error
+	})
+}
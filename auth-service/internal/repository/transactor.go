@@ -0,0 +1,17 @@
+package repository
+
+import "gorm.io/gorm"
+
+// Transactor выполняет несколько операций репозиториев в одной транзакции
+type Transactor struct {
+	db *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+// Do откатывает транзакцию, если fn вернула ошибку
+func (t *Transactor) Do(fn func(tx *gorm.DB) error) error {
+	return t.db.Transaction(fn)
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"time"
+
+	"auth-service/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebAuthnRepository struct {
+	db *gorm.DB
+}
+
+func NewWebAuthnRepository(db *gorm.DB) *WebAuthnRepository {
+	return &WebAuthnRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий в транзакции tx
+func (r *WebAuthnRepository) WithTx(tx *gorm.DB) *WebAuthnRepository {
+	return &WebAuthnRepository{db: tx}
+}
+
+func (r *WebAuthnRepository) CreateCeremony(c *models.WebAuthnCeremony) error {
+	return r.db.Create(c).Error
+}
+
+// TakeCeremony удаляет и возвращает не истёкшую церемонию с этим challenge — повторно её не использовать
+func (r *WebAuthnRepository) TakeCeremony(challenge []byte, kind string) (*models.WebAuthnCeremony, error) {
+	var c models.WebAuthnCeremony
+	res := r.db.Clauses(clause.Returning{}).
+		Where("challenge = ? AND kind = ? AND expires_at > ?", challenge, kind, time.Now()).
+		Delete(&c)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &c, nil
+}
+
+func (r *WebAuthnRepository) CreateCredential(c *models.WebAuthnCredential) error {
+	return r.db.Create(c).Error
+}
+
+func (r *WebAuthnRepository) ListCredentials(userID uuid.UUID) ([]models.WebAuthnCredential, error) {
+	var creds []models.WebAuthnCredential
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&creds).Error
+	return creds, err
+}
+
+func (r *WebAuthnRepository) FindCredential(credentialID []byte) (*models.WebAuthnCredential, error) {
+	var c models.WebAuthnCredential
+	if err := r.db.Where("credential_id = ?", credentialID).First(&c).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// UpdateSignCount сохраняет счётчик, если его не изменил параллельный вход; false — изменил
+func (r *WebAuthnRepository) UpdateSignCount(id uuid.UUID, old, next int64) (bool, error) {
+	res := r.db.Model(&models.WebAuthnCredential{}).
+		Where("id = ? AND sign_count = ?", id, old).
+		Updates(map[string]interface{}{"sign_count": next, "last_used_at": time.Now()})
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *WebAuthnRepository) DeleteCredential(userID, id uuid.UUID) (int64, error) {
+	res := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.WebAuthnCredential{})
+	return res.RowsAffected, res.Error
+}
+
+// DeleteExpiredCeremonies удаляет незавершённые церемонии и возвращает число удалённых строк
+func (r *WebAuthnRepository) DeleteExpiredCeremonies(now time.Time) (int64, error) {
+	res := r.db.Where("expires_at <= ?", now).Delete(&models.WebAuthnCeremony{})
+	return res.RowsAffected, res.Error
+}
@@ -7,11 +7,14 @@ import (
 	"auth-service/internal/producer"
 	"auth-service/internal/repository"
 	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 var ErrUserExists = errors.New("user already exists")
@@ -21,8 +24,15 @@ type UserService struct {
 	rtRepo            *repository.RefreshTokenRepository
 	emailTokenRepo    *repository.EmailVerificationTokenRepository
 	passwordResetRepo *repository.PasswordResetTokenRepository
+	magicLinkRepo     *repository.MagicLinkTokenRepository
+	outboxRepo        *repository.OutboxRepository
+	revocationRepo    *repository.AccessRevocationRepository
+	mfa               *MFAService
+	guard             *LoginGuard
+	tx                *repository.Transactor
+	AccessKeys        *jwt.KeyRing
+	RefreshKeys       *jwt.KeyRing
 	Cfg               *config.Config
-	emailProducer     *producer.EmailProducer
 	Log               *zap.Logger
 }
 
@@ -31,8 +41,15 @@ func NewUserService(
 	rtRepo *repository.RefreshTokenRepository,
 	emailTokenRepo *repository.EmailVerificationTokenRepository,
 	passwordResetRepo *repository.PasswordResetTokenRepository,
+	magicLinkRepo *repository.MagicLinkTokenRepository,
+	outboxRepo *repository.OutboxRepository,
+	revocationRepo *repository.AccessRevocationRepository,
+	mfa *MFAService,
+	guard *LoginGuard,
+	tx *repository.Transactor,
+	accessKeys *jwt.KeyRing,
+	refreshKeys *jwt.KeyRing,
 	cfg *config.Config,
-	emailProducer *producer.EmailProducer,
 	log *zap.Logger,
 ) *UserService {
 	return &UserService{
@@ -40,8 +57,15 @@ func NewUserService(
 		rtRepo:            rtRepo,
 		emailTokenRepo:    emailTokenRepo,
 		passwordResetRepo: passwordResetRepo,
+		magicLinkRepo:     magicLinkRepo,
+		outboxRepo:        outboxRepo,
+		revocationRepo:    revocationRepo,
+		mfa:               mfa,
+		guard:             guard,
+		tx:                tx,
+		AccessKeys:        accessKeys,
+		RefreshKeys:       refreshKeys,
 		Cfg:               cfg,
-		emailProducer:     emailProducer,
 		Log:               log,
 	}
 }
@@ -62,21 +86,30 @@ func (s *UserService) Register(name, email, password string) (*models.User, erro
 		PasswordHash: hashedPassword,
 	}
 
-	if err := s.repo.Create(user); err != nil {
+	// Пользователь, токен и письмо сохраняются атомарно: письмо отправит релей outbox
+	err = s.tx.Do(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Create(user); err != nil {
+			return err
+		}
+		return s.createVerificationEmail(tx, user)
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	return user, nil
+}
+
+func (s *UserService) createVerificationEmail(tx *gorm.DB, user *models.User) error {
 	emailVerToken := &models.EmailVerificationToken{
 		UserID:    user.ID,
 		Token:     uuid.New().String(),
 		ExpiresAt: time.Now().Add(24 * time.Hour),
 	}
-
-	if err := s.emailTokenRepo.Create(emailVerToken); err != nil {
-		return nil, err
+	if err := s.emailTokenRepo.WithTx(tx).Create(emailVerToken); err != nil {
+		return err
 	}
-
-	err = s.emailProducer.SendEmail(user.Email, producer.EmailMessage{
+	return s.enqueueEmail(tx, producer.EmailMessage{
 		To:       user.Email,
 		Subject:  "Подтвердите email",
 		Template: "verify_email",
@@ -85,11 +118,15 @@ func (s *UserService) Register(name, email, password string) (*models.User, erro
 			"ConfirmURL": "https://app/confirm?token=" + emailVerToken.Token,
 		},
 	})
+}
+
+// enqueueEmail кладёт письмо в outbox; ключ — адрес получателя
+func (s *UserService) enqueueEmail(tx *gorm.DB, email producer.EmailMessage) error {
+	msg, err := models.NewOutboxMessage(s.Cfg.KafkaTopic, email.To, email, nil)
 	if err != nil {
-		s.Log.Warn("Не удалось отправить email через Kafka", zap.Error(err))
+		return err
 	}
-
-	return user, nil
+	return s.outboxRepo.WithTx(tx).Create(msg)
 }
 
 func hashedPassword(password string) (string, error) {
@@ -101,76 +138,300 @@ func hashedPassword(password string) (string, error) {
 }
 
 var ErrUserNotFound = errors.New("user not found")
-var ErrInvalidPassword = errors.New("invalid password")
 
-func (s *UserService) Login(email, password string) (access, refresh string, err error) {
+// dummyPasswordHash сравнивается с паролем, когда сравнивать не с чем: время ответа
+// для неизвестного адреса не должно отличаться от неверного пароля
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("linkvault-dummy-password"), bcrypt.DefaultCost)
+	return hash
+})
+
+// Login проверяет пароль. Неизвестный адрес и неверный пароль дают одну ошибку ErrInvalidCredentials;
+// при подборе вход замедляется и блокируется (LoginThrottledError), см. LoginGuard.
+func (s *UserService) Login(email, password string, client ClientInfo) (access, refresh string, err error) {
+	if err := s.guard.Check(email, client.IP); err != nil {
+		return "", "", err
+	}
+
 	user, err := s.repo.FindByEmail(email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", "", err
+	}
+	hash := dummyPasswordHash()
+	// Без пароля — аккаунты, созданные входом через провайдера
+	if user != nil && user.PasswordHash != "" {
+		hash = []byte(user.PasswordHash)
+	}
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil || user == nil || user.PasswordHash == "" {
+		s.loginFailed(email, user, client)
+		return "", "", ErrInvalidCredentials
+	}
+
+	access, refresh, err = s.completeLogin(user.ID, client)
 	if err != nil {
-		return "", "", ErrUserNotFound
+		// В том числе MFARequiredError: с включённым вторым фактором счётчик сбрасывает только VerifyMFA
+		return "", "", err
+	}
+	s.loginSucceeded(email)
+	return access, refresh, nil
+}
+
+// loginSucceeded сбрасывает счётчик неудачных входов адреса после пройденной проверки всех факторов
+func (s *UserService) loginSucceeded(email string) {
+	if err := s.guard.Succeed(email); err != nil {
+		s.Log.Error("Не удалось сбросить счётчик неудачных входов", zap.Error(err))
 	}
+}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return "", "", ErrInvalidPassword
+// loginFailed учитывает неудачную попытку, а при блокировке аккаунта отправляет владельцу
+// письмо со ссылкой для досрочной разблокировки. Ошибки только логируются.
+func (s *UserService) loginFailed(email string, user *models.User, client ClientInfo) {
+	unlockToken, err := s.guard.Fail(email, client.IP)
+	if err != nil {
+		s.Log.Error("Не удалось учесть неудачный вход", zap.Error(err))
+		return
+	}
+	if unlockToken == "" || user == nil {
+		return
+	}
+	err = s.tx.Do(func(tx *gorm.DB) error {
+		return s.enqueueEmail(tx, producer.EmailMessage{
+			To:       user.Email,
+			Subject:  "Вход в аккаунт временно заблокирован",
+			Template: "account_locked",
+			Data: map[string]any{
+				"UserName":    user.Name,
+				"IP":          client.IP,
+				"LockMinutes": fmt.Sprint(int(s.Cfg.Login.Lockout.Minutes())),
+				"UnlockURL":   "https://app/unlock?token=" + unlockToken,
+			},
+		})
+	})
+	if err != nil {
+		s.Log.Error("Не удалось отправить письмо о блокировке входа", zap.String("user_id", user.ID.String()), zap.Error(err))
 	}
+}
 
-	access, accessClaims, err := jwt.GenerateAccessToken(user.ID.String(), &s.Cfg.JWT)
+// UnlockAccount снимает блокировку входа по ссылке из письма о блокировке
+func (s *UserService) UnlockAccount(token string) error {
+	return s.guard.Unlock(token)
+}
+
+// completeLogin выдаёт токены после проверки первого фактора либо требует второй, если он включён
+func (s *UserService) completeLogin(userID uuid.UUID, client ClientInfo) (access, refresh string, err error) {
+	enabled, err := s.mfa.Enabled(userID)
 	if err != nil {
 		return "", "", err
 	}
+	if enabled {
+		challenge, err := s.mfa.createChallenge(userID)
+		if err != nil {
+			return "", "", err
+		}
+		return "", "", &MFARequiredError{ChallengeToken: challenge}
+	}
+	return s.issueSession(userID, client)
+}
 
-	refresh, refreshClaims, err := jwt.GenerateRefreshToken(user.ID.String(), &s.Cfg.JWT)
+// VerifyMFA завершает вход с вторым фактором: обменивает вызов из Login и код TOTP
+// (или код восстановления) на пару токенов. Неверный код — такая же неудачная попытка входа
+// для LoginGuard, как неверный пароль, а при паузе или блокировке второй шаг отклоняется
+// с LoginThrottledError, не проверяя код.
+func (s *UserService) VerifyMFA(challengeToken, code string, client ClientInfo) (access, refresh string, err error) {
+	challenge, err := s.mfa.findChallenge(challengeToken)
+	if err != nil {
+		return "", "", err
+	}
+	user, err := s.repo.FindByID(challenge.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", "", ErrInvalidToken
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.guard.Check(user.Email, client.IP); err != nil {
+		return "", "", err
+	}
+	if err := s.mfa.consumeChallenge(challenge, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			s.loginFailed(user.Email, user, client)
+		}
+		return "", "", err
+	}
+	s.loginSucceeded(user.Email)
+	return s.issueSession(user.ID, client)
+}
+
+// issueSession открывает новую сессию пользователя и выдаёт пару токенов
+func (s *UserService) issueSession(userID uuid.UUID, client ClientInfo) (access, refresh string, err error) {
+	// Каждый вход открывает новое семейство refresh-токенов — новую сессию
+	sessionID := uuid.New()
+	access, err = s.accessToken(userID, sessionID, client.ClientID, client.Scope)
+	if err != nil {
+		return "", "", err
+	}
+
+	refresh, refreshClaims, err := jwt.GenerateRefreshToken(userID.String(), s.RefreshKeys, &s.Cfg.JWT)
 	if err != nil {
 		return "", "", err
 	}
 
+	now := time.Now()
 	if err := s.rtRepo.Create(&models.RefreshToken{
-		JTI:       refreshClaims.ID,
-		UserID:    user.ID,
-		ExpiresAt: refreshClaims.ExpiresAt.Time,
-		Revoked:   false,
+		JTI:              refreshClaims.ID,
+		UserID:           userID,
+		ExpiresAt:        refreshClaims.ExpiresAt.Time,
+		Revoked:          false,
+		FamilyID:         sessionID,
+		UserAgent:        client.UserAgent,
+		IP:               client.IP,
+		ClientName:       client.ClientName,
+		SessionStartedAt: &now,
+		ClientID:         client.ClientID,
+		Scope:            client.Scope,
 	}); err != nil {
 		return "", "", err
 	}
 
-	_ = accessClaims
 	return access, refresh, nil
 }
 
+// accessToken выдаёт access-токен сессии; OAuth-клиенты получают токен типа client_access
+func (s *UserService) accessToken(userID, sessionID uuid.UUID, clientID, scope string) (string, error) {
+	if clientID == "" {
+		token, _, err := jwt.GenerateAccessToken(userID.String(), sessionID.String(), s.AccessKeys, &s.Cfg.JWT)
+		return token, err
+	}
+	token, _, err := jwt.GenerateClientAccessToken(userID.String(), sessionID.String(), clientID, scope, s.AccessKeys, &s.Cfg.JWT)
+	return token, err
+}
+
 var ErrInvalidToken = errors.New("invalid token")
 
-func (s *UserService) Refresh(refreshToken string) (access, refresh string, err error) {
-	claims, err := jwt.ParseRefreshToken(refreshToken, s.Cfg.JWT.Refresh)
+// ErrRefreshTokenReused — предъявлен уже обменянный refresh-токен; для клиента это тот же ErrInvalidToken
+var ErrRefreshTokenReused = fmt.Errorf("%w: refresh token reused", ErrInvalidToken)
+
+// Refresh обменивает refresh-токен на новую пару. Старый токен помечается заменённым;
+// повторное его предъявление означает утечку, и всё семейство отзывается (OAuth 2.0 Security BCP).
+func (s *UserService) Refresh(refreshToken string, client ClientInfo) (access, refresh string, err error) {
+	claims, err := jwt.ParseRefreshToken(refreshToken, s.RefreshKeys)
 	if err != nil {
 		return "", "", ErrInvalidToken
 	}
 
-	rt, err := s.rtRepo.FindValid(claims.ID)
+	rt, err := s.rtRepo.FindByJTI(claims.ID)
 	if err != nil {
 		return "", "", ErrInvalidToken
 	}
+	if rt.ReplacedBy != nil {
+		s.handleRefreshReuse(rt)
+		return "", "", ErrRefreshTokenReused
+	}
+	// Токен OAuth-клиента обменивается только им самим и только через token endpoint
+	if rt.Revoked || !rt.ExpiresAt.After(time.Now()) || rt.ClientID != client.ClientID {
+		return "", "", ErrInvalidToken
+	}
 
-	_ = s.rtRepo.RevokeByJTI(rt.JTI) // игнорируем ошибку
-
-	access, accessClaims, err := jwt.GenerateAccessToken(claims.UserID, &s.Cfg.JWT)
+	sessionID := tokenFamily(rt)
+	access, err = s.accessToken(rt.UserID, sessionID, rt.ClientID, rt.Scope)
 	if err != nil {
 		return "", "", err
 	}
-	refresh, refreshClaims, err := jwt.GenerateRefreshToken(claims.UserID, &s.Cfg.JWT)
+	refresh, refreshClaims, err := jwt.GenerateRefreshToken(rt.UserID.String(), s.RefreshKeys, &s.Cfg.JWT)
 	if err != nil {
 		return "", "", err
 	}
-	if err := s.rtRepo.Create(&models.RefreshToken{
-		JTI:       refreshClaims.ID,
-		UserID:    uuid.MustParse(claims.UserID),
-		ExpiresAt: refreshClaims.ExpiresAt.Time,
-		Revoked:   false,
-	}); err != nil {
+
+	err = s.tx.Do(func(tx *gorm.DB) error {
+		repo := s.rtRepo.WithTx(tx)
+		rotated, err := repo.Rotate(rt.JTI, refreshClaims.ID)
+		if err != nil {
+			return err
+		}
+		// Параллельный запрос успел обменять этот же токен — считаем это повторным использованием
+		if !rotated {
+			return ErrRefreshTokenReused
+		}
+		next := &models.RefreshToken{
+			JTI:              refreshClaims.ID,
+			UserID:           rt.UserID,
+			ExpiresAt:        refreshClaims.ExpiresAt.Time,
+			Revoked:          false,
+			FamilyID:         sessionID,
+			ParentJTI:        &rt.JTI,
+			UserAgent:        client.UserAgent,
+			IP:               client.IP,
+			ClientName:       client.ClientName,
+			SessionStartedAt: rt.SessionStartedAt,
+			ClientID:         rt.ClientID,
+			Scope:            rt.Scope,
+		}
+		// Клиент мог не передать имя при обновлении — сохраняем указанное при входе
+		if next.ClientName == "" {
+			next.ClientName = rt.ClientName
+		}
+		if next.SessionStartedAt == nil {
+			next.SessionStartedAt = &rt.CreatedAt
+		}
+		return repo.Create(next)
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		s.handleRefreshReuse(rt)
+		return "", "", err
+	}
+	if err != nil {
 		return "", "", err
 	}
-	_ = accessClaims
 	return access, refresh, nil
 }
 
+// tokenFamily возвращает семейство токена; токены, выданные до появления семейств, образуют своё
+func tokenFamily(rt *models.RefreshToken) uuid.UUID {
+	if rt.FamilyID == uuid.Nil {
+		return rt.ID
+	}
+	return rt.FamilyID
+}
+
+// handleRefreshReuse отзывает семейство скомпрометированного токена и уведомляет пользователя.
+// Ошибки только логируются: клиент в любом случае получает отказ.
+func (s *UserService) handleRefreshReuse(rt *models.RefreshToken) {
+	family := tokenFamily(rt)
+	s.Log.Warn("Обнаружено повторное использование refresh-токена, семейство отозвано",
+		zap.String("user_id", rt.UserID.String()),
+		zap.String("family_id", family.String()),
+		zap.String("jti", rt.JTI),
+	)
+
+	err := s.tx.Do(func(tx *gorm.DB) error {
+		if _, err := s.rtRepo.WithTx(tx).RevokeFamily(family); err != nil {
+			return err
+		}
+		if err := s.revokeSessionAccess(tx, rt.UserID, []uuid.UUID{family}); err != nil {
+			return err
+		}
+		if !s.Cfg.JWT.NotifyReuse {
+			return nil
+		}
+		user, err := s.repo.WithTx(tx).FindByID(rt.UserID)
+		if err != nil {
+			return err
+		}
+		return s.enqueueEmail(tx, producer.EmailMessage{
+			To:       user.Email,
+			Subject:  "Подозрительная активность в аккаунте",
+			Template: "security_alert",
+			Data: map[string]any{
+				"UserName":   user.Name,
+				"DetectedAt": time.Now().UTC().Format("02.01.2006 15:04 UTC"),
+			},
+		})
+	})
+	if err != nil {
+		s.Log.Error("Не удалось отозвать семейство refresh-токенов", zap.String("family_id", family.String()), zap.Error(err))
+	}
+}
+
 func (s *UserService) Profile(userID uuid.UUID) (*models.User, error) {
 	user, err := s.repo.FindByID(userID)
 	if err != nil {
@@ -180,11 +441,74 @@ func (s *UserService) Profile(userID uuid.UUID) (*models.User, error) {
 	return user, nil
 }
 
+// Logout завершает все сессии пользователя: отзывает refresh-токены и уже выданные access-токены
 func (s *UserService) Logout(userID uuid.UUID) error {
 	if err := s.rtRepo.RevokeAllForUser(userID); err != nil {
 		return err
 	}
-	return nil
+	return s.revokeAccessTokens(userID)
+}
+
+var ErrTokenRevoked = errors.New("token revoked")
+
+// ValidateAccessToken проверяет подпись, срок и тип access-токена, а также что он не отозван
+func (s *UserService) ValidateAccessToken(token string) (*jwt.Claims, error) {
+	claims, err := jwt.ParseAccessToken(token, s.AccessKeys)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return s.checkRevoked(claims)
+}
+
+// ValidateClientAccessToken проверяет access-токен, выданный OAuth-клиенту через OIDC
+func (s *UserService) ValidateClientAccessToken(token string) (*jwt.Claims, error) {
+	claims, err := jwt.ParseClientAccessToken(token, s.AccessKeys)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return s.checkRevoked(claims)
+}
+
+func (s *UserService) checkRevoked(claims *jwt.Claims) (*jwt.Claims, error) {
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	// Токены без sid (выданные до появления сессий) завершением сессии не отзываются
+	sessionID, _ := uuid.Parse(claims.SessionID)
+	revoked, err := s.revocationRepo.IsRevoked(claims.ID, userID, sessionID, issuedAt)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+// RevokeAccessToken отзывает один access-токен до истечения его срока
+func (s *UserService) RevokeAccessToken(claims *jwt.Claims) error {
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	return s.revocationRepo.RevokeJTI(claims.ID, userID, claims.ExpiresAt.Time)
+}
+
+// ActiveRevocations — действующие записи об отзыве access-токенов для сервисов, проверяющих токены по JWKS
+func (s *UserService) ActiveRevocations() ([]models.RevokedAccessToken, []models.RevokedSession, []models.AccessTokenCutoff, error) {
+	return s.revocationRepo.ListActive(time.Now())
+}
+
+// revokeAccessTokens отзывает все access-токены пользователя, выданные до текущего момента.
+// Токены с iat в ту же секунду тоже считаются отозванными: iat хранится с точностью до секунды.
+func (s *UserService) revokeAccessTokens(userID uuid.UUID) error {
+	now := time.Now()
+	return s.revocationRepo.RevokeUserBefore(userID, now, now.Add(s.Cfg.JWT.AccessExp))
 }
 
 func (s *UserService) VerifyEmail(token string) error {
@@ -216,28 +540,9 @@ func (s *UserService) ResendVerificationEmail(userID uuid.UUID) error {
 		return ErrEmailAlready
 	}
 
-	emailVerToken := &models.EmailVerificationToken{
-		UserID:    user.ID,
-		Token:     uuid.New().String(),
-		ExpiresAt: time.Now().Add(24 * time.Hour),
-	}
-
-	if err := s.emailTokenRepo.Create(emailVerToken); err != nil {
-		return err
-	}
-
-	if err := s.emailProducer.SendEmail(user.Email, producer.EmailMessage{
-		To:       user.Email,
-		Subject:  "Подтвердите email",
-		Template: "verify_email",
-		Data: map[string]any{
-			"UserName":   user.Name,
-			"ConfirmURL": "https://app/confirm?token=" + emailVerToken.Token,
-		},
-	}); err != nil {
-		return err
-	}
-	return nil
+	return s.tx.Do(func(tx *gorm.DB) error {
+		return s.createVerificationEmail(tx, user)
+	})
 }
 
 func (s *UserService) RequestPasswordReset(email string) error {
@@ -257,23 +562,21 @@ func (s *UserService) RequestPasswordReset(email string) error {
 		ExpiresAt: time.Now().Add(30 * time.Minute),
 	}
 
-	if err := s.passwordResetRepo.Create(passwordResetToken); err != nil {
-		return err
-	}
-	if err := s.emailProducer.SendEmail(user.Email, producer.EmailMessage{
-		To:       user.Email,
-		Subject:  "Сброс пароля",
-		Template: "reset_password",
-		Data: map[string]any{
-			"UserName":      user.Name,
-			"ResetURL":      "https://app/confirm?token=" + passwordResetToken.Token,
-			"ExpireMinutes": "30",
-		},
-	}); err != nil {
-		return err
-	}
-
-	return nil
+	return s.tx.Do(func(tx *gorm.DB) error {
+		if err := s.passwordResetRepo.WithTx(tx).Create(passwordResetToken); err != nil {
+			return err
+		}
+		return s.enqueueEmail(tx, producer.EmailMessage{
+			To:       user.Email,
+			Subject:  "Сброс пароля",
+			Template: "reset_password",
+			Data: map[string]any{
+				"UserName":      user.Name,
+				"ResetURL":      "https://app/confirm?token=" + passwordResetToken.Token,
+				"ExpireMinutes": "30",
+			},
+		})
+	})
 }
 
 func (s *UserService) ConfirmPasswordReset(token, newPassword string) error {
@@ -296,5 +599,9 @@ func (s *UserService) ConfirmPasswordReset(token, newPassword string) error {
 	if err := s.passwordResetRepo.MarkUsed(prt.ID); err != nil {
 		return err
 	}
-	return nil
+	// После смены пароля все прежние сессии недействительны
+	if err := s.rtRepo.RevokeAllForUser(user.ID); err != nil {
+		return err
+	}
+	return s.revokeAccessTokens(user.ID)
 }
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"auth-service/internal/jwt"
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// loginTokens входит паролем пользователя, созданного registerUser
+func loginTokens(t *testing.T, env *testEnv, email string) (access, refresh string) {
+	t.Helper()
+	access, refresh, err := env.users.Login(email, testPassword, testClient)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	return access, refresh
+}
+
+// outboxCount — число писем в outbox для адреса
+func outboxCount(t *testing.T, env *testEnv, email string) int64 {
+	t.Helper()
+	var n int64
+	if err := env.db.Model(&models.OutboxMessage{}).Where("key = ?", email).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestRefreshRotation(t *testing.T) {
+	env := newTestEnv(t)
+	const email = "refresh-rotate@example.com"
+	registerUser(t, env, email)
+	_, refresh := loginTokens(t, env, email)
+
+	for i := 0; i < 3; i++ {
+		access, next, err := env.users.Refresh(refresh, testClient)
+		if err != nil {
+			t.Fatalf("Refresh %d: %v", i+1, err)
+		}
+		if _, err := env.users.ValidateAccessToken(access); err != nil {
+			t.Fatalf("ValidateAccessToken after refresh %d: %v", i+1, err)
+		}
+		if next == refresh {
+			t.Fatalf("Refresh %d returned the same refresh token", i+1)
+		}
+		refresh = next
+	}
+}
+
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	cases := []struct {
+		name       string
+		notify     bool
+		wantEmails int64
+	}{
+		{"with notification", true, 1},
+		{"without notification", false, 0},
+	}
+	for _, tc := range cases {
+		env := newTestEnv(t)
+		env.cfg.JWT.NotifyReuse = tc.notify
+		const email = "refresh-reuse@example.com"
+		registerUser(t, env, email)
+		_, stolen := loginTokens(t, env, email)
+		_, otherSession := loginTokens(t, env, email)
+
+		currentAccess, current, err := env.users.Refresh(stolen, testClient)
+		if err != nil {
+			t.Fatalf("%s: Refresh: %v", tc.name, err)
+		}
+		emails := outboxCount(t, env, email)
+
+		_, _, err = env.users.Refresh(stolen, testClient)
+		if !errors.Is(err, ErrRefreshTokenReused) || !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("%s: Refresh with replaced token = %v, want ErrRefreshTokenReused", tc.name, err)
+		}
+		// Владелец семейства тоже теряет сессию: неизвестно, кто из двоих злоумышленник
+		if _, _, err := env.users.Refresh(current, testClient); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: Refresh with current token of revoked family = %v, want ErrInvalidToken", tc.name, err)
+		}
+		if _, err := env.users.ValidateAccessToken(currentAccess); !errors.Is(err, ErrTokenRevoked) {
+			t.Errorf("%s: access token of revoked family = %v, want ErrTokenRevoked", tc.name, err)
+		}
+		if _, _, err := env.users.Refresh(otherSession, testClient); err != nil {
+			t.Errorf("%s: Refresh in other session = %v, want it untouched", tc.name, err)
+		}
+		if got := outboxCount(t, env, email) - emails; got != tc.wantEmails {
+			t.Errorf("%s: security alerts = %d, want %d", tc.name, got, tc.wantEmails)
+		}
+	}
+}
+
+// Два параллельных обмена одного токена: выигрывает один, а проигравший отзывает семейство
+func TestRefreshConcurrentReuse(t *testing.T) {
+	env := newTestEnv(t)
+	const email = "refresh-race@example.com"
+	registerUser(t, env, email)
+	_, refresh := loginTokens(t, env, email)
+
+	type result struct {
+		refresh string
+		err     error
+	}
+	results := make([]result, 2)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, next, err := env.users.Refresh(refresh, testClient)
+			results[i] = result{next, err}
+		}(i)
+	}
+	wg.Wait()
+
+	var winner string
+	reused := 0
+	for _, r := range results {
+		switch {
+		case r.err == nil:
+			winner = r.refresh
+		case errors.Is(r.err, ErrRefreshTokenReused):
+			reused++
+		default:
+			t.Fatalf("Refresh: %v", r.err)
+		}
+	}
+	if winner == "" || reused != 1 {
+		t.Fatalf("results = %+v, want one success and one reuse", results)
+	}
+	if _, _, err := env.users.Refresh(winner, testClient); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Refresh with winner's token = %v, want family revoked", err)
+	}
+}
+
+func TestRefreshRejectsInvalidTokens(t *testing.T) {
+	env := newTestEnv(t)
+	const email = "refresh-invalid@example.com"
+	user := registerUser(t, env, email)
+	access, _ := loginTokens(t, env, email)
+	rtRepo := repository.NewRefreshTokenRepository(env.db)
+
+	_, expired := loginTokens(t, env, email)
+	claims, err := jwt.ParseRefreshToken(expired, env.users.RefreshKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := env.db.Model(&models.RefreshToken{}).Where("jti = ?", claims.ID).
+		Update("expires_at", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatal(err)
+	}
+	_, revoked := loginTokens(t, env, email)
+	claims, err = jwt.ParseRefreshToken(revoked, env.users.RefreshKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt, err := rtRepo.FindByJTI(claims.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rtRepo.RevokeFamily(rt.FamilyID); err != nil {
+		t.Fatal(err)
+	}
+	unknown, _, err := jwt.GenerateRefreshToken(user.ID.String(), env.users.RefreshKeys, &env.cfg.JWT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientID, _ := registerOIDCClient(t, env, true)
+	code, verifier := authorize(t, env, access, clientID)
+	clientTokens, err := env.oidc.ExchangeCode(clientID, "", code, testRedirectURI, verifier, testClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name, token string
+	}{
+		{"garbage", "not-a-token"},
+		{"access token", access},
+		{"expired", expired},
+		{"revoked family", revoked},
+		{"not issued by service", unknown},
+		{"client token outside token endpoint", clientTokens.RefreshToken},
+	}
+	for _, tc := range cases {
+		if _, _, err := env.users.Refresh(tc.token, testClient); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: Refresh = %v, want ErrInvalidToken", tc.name, err)
+		}
+	}
+}
+
+func TestLogoutRevokesAllSessions(t *testing.T) {
+	env := newTestEnv(t)
+	const email = "logout@example.com"
+	user := registerUser(t, env, email)
+	access1, refresh1 := loginTokens(t, env, email)
+	access2, refresh2 := loginTokens(t, env, email)
+
+	other := registerUser(t, env, "logout-other@example.com")
+	otherAccess, otherRefresh := loginTokens(t, env, other.Email)
+
+	if err := env.users.Logout(user.ID); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	for i, access := range []string{access1, access2} {
+		if _, err := env.users.ValidateAccessToken(access); !errors.Is(err, ErrTokenRevoked) {
+			t.Errorf("access token %d after Logout = %v, want ErrTokenRevoked", i+1, err)
+		}
+	}
+	for i, refresh := range []string{refresh1, refresh2} {
+		if _, _, err := env.users.Refresh(refresh, testClient); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("refresh token %d after Logout = %v, want ErrInvalidToken", i+1, err)
+		}
+	}
+	if _, err := env.users.ValidateAccessToken(otherAccess); err != nil {
+		t.Errorf("other user's access token = %v", err)
+	}
+	if _, _, err := env.users.Refresh(otherRefresh, testClient); err != nil {
+		t.Errorf("other user's refresh token = %v", err)
+	}
+
+	_, _, cutoffs, err := env.users.ActiveRevocations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cutoffs) != 1 || cutoffs[0].UserID != user.ID {
+		t.Errorf("revocation feed cutoffs = %+v, want one for %s", cutoffs, user.ID)
+	}
+}
+
+func TestCheckRevoked(t *testing.T) {
+	env := newTestEnv(t)
+	revocations := repository.NewAccessRevocationRepository(env.db)
+
+	cases := []struct {
+		name string
+		// Отсечка относительно iat токена; nil — отсечки нет
+		cutoff        *time.Duration
+		cutoffUser    bool // отсечка другого пользователя
+		revokeJTI     bool
+		revokeSession bool
+		noSession     bool // токен без sid
+		want          error
+	}{
+		{name: "not revoked"},
+		{name: "jti revoked", revokeJTI: true, want: ErrTokenRevoked},
+		{name: "session revoked", revokeSession: true, want: ErrTokenRevoked},
+		{name: "without sid, other session revoked", revokeSession: true, noSession: true},
+		{name: "issued before cutoff", cutoff: durationPtr(time.Second), want: ErrTokenRevoked},
+		{name: "issued at cutoff", cutoff: durationPtr(0)},
+		{name: "issued after cutoff", cutoff: durationPtr(-time.Second)},
+		{name: "cutoff of other user", cutoff: durationPtr(time.Second), cutoffUser: true},
+	}
+	for _, tc := range cases {
+		userID, sessionID := uuid.New(), uuid.New()
+		sid := sessionID.String()
+		if tc.noSession {
+			sid = ""
+		}
+		token, claims, err := jwt.GenerateAccessToken(userID.String(), sid, env.users.AccessKeys, &env.cfg.JWT)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.revokeSession {
+			if err := revocations.RevokeSessions(userID, []uuid.UUID{sessionID}, claims.ExpiresAt.Time); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if tc.revokeJTI {
+			if err := env.users.RevokeAccessToken(claims); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if tc.cutoff != nil {
+			owner := userID
+			if tc.cutoffUser {
+				owner = uuid.New()
+			}
+			if err := revocations.RevokeUserBefore(owner, claims.IssuedAt.Add(*tc.cutoff), claims.ExpiresAt.Time); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := env.users.ValidateAccessToken(token); !errors.Is(err, tc.want) {
+			t.Errorf("%s: ValidateAccessToken = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
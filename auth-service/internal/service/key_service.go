@@ -0,0 +1,114 @@
+package service
+
+import (
+	"auth-service/internal/jwt"
+	"auth-service/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var ErrUnknownKeyRing = errors.New("unknown key ring")
+var ErrKeyAlreadyActive = errors.New("key is already active")
+
+// Запас к сроку жизни токенов, в течение которого прежний ключ ещё принимается
+const keyRetireLeeway = time.Minute
+
+// KeyService переключает ключи подписи и синхронизирует связки с состоянием в БД
+type KeyService struct {
+	repo  *repository.SigningKeyRepository
+	rings map[string]*jwt.KeyRing
+	ttls  map[string]time.Duration
+	log   *zap.Logger
+}
+
+func NewKeyService(repo *repository.SigningKeyRepository, access, refresh *jwt.KeyRing, accessTTL, refreshTTL time.Duration, log *zap.Logger) *KeyService {
+	return &KeyService{
+		repo:  repo,
+		rings: map[string]*jwt.KeyRing{access.Name(): access, refresh.Name(): refresh},
+		ttls:  map[string]time.Duration{access.Name(): accessTTL, refresh.Name(): refreshTTL},
+		log:   log,
+	}
+}
+
+// Reload применяет к связкам состояние из БД; без записи об активном ключе активен ключ по умолчанию
+func (s *KeyService) Reload() error {
+	states, err := s.repo.List()
+	if err != nil {
+		return err
+	}
+	for name, ring := range s.rings {
+		active := ""
+		retireAt := map[string]time.Time{}
+		for _, st := range states {
+			if st.Ring != name {
+				continue
+			}
+			if st.Active {
+				if ring.Has(st.KeyID) {
+					active = st.KeyID
+				} else {
+					s.log.Warn("Active signing key is not loaded on this instance", zap.String("ring", name), zap.String("kid", st.KeyID))
+				}
+			}
+			if st.RetireAt != nil {
+				retireAt[st.KeyID] = *st.RetireAt
+			}
+		}
+		if active == "" {
+			active = ring.DefaultActive()
+		}
+		if active != ring.ActiveID() {
+			s.log.Info("Switching signing key", zap.String("ring", name), zap.String("kid", active))
+		}
+		if err := ring.Apply(active, retireAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Promote делает ключ kid активным; токены, подписанные прежним ключом, принимаются до истечения их срока
+func (s *KeyService) Promote(ringName, kid string) error {
+	ring, ok := s.rings[ringName]
+	if !ok {
+		return ErrUnknownKeyRing
+	}
+	if !ring.Has(kid) {
+		return fmt.Errorf("%w: %s", jwt.ErrUnknownKey, kid)
+	}
+	previous := ring.ActiveID()
+	if previous == kid {
+		return ErrKeyAlreadyActive
+	}
+	retireAt := time.Now().Add(s.ttls[ringName] + keyRetireLeeway)
+	if err := s.repo.Promote(ringName, kid, previous, retireAt); err != nil {
+		return err
+	}
+	s.log.Info("Signing key promoted", zap.String("ring", ringName), zap.String("kid", kid), zap.String("previous", previous), zap.Time("previousRetireAt", retireAt))
+	return s.Reload()
+}
+
+// Rings — связки ключей по имени
+func (s *KeyService) Rings() map[string]*jwt.KeyRing {
+	return s.rings
+}
+
+// Run периодически перечитывает состояние, чтобы переключение на одном экземпляре подхватили остальные
+func (s *KeyService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Reload(); err != nil {
+				s.log.Warn("Failed to reload signing keys", zap.Error(err))
+			}
+		}
+	}
+}
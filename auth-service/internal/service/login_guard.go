@@ -0,0 +1,164 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"auth-service/config"
+	"auth-service/internal/models"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCredentials — неизвестный email и неверный пароль неразличимы для клиента
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+var ErrLoginThrottled = errors.New("too many failed login attempts")
+
+// LoginThrottledError — вход временно запрещён; повторить можно через RetryAfter
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("%s: retry after %s", ErrLoginThrottled, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	return ErrLoginThrottled
+}
+
+const (
+	throttleEmail = "email"
+	throttleIP    = "ip"
+	// Первая задержка после LoginGuard.DelayAfter неудач; дальше удваивается до DelayMax
+	loginDelayBase = time.Second
+)
+
+// LoginGuard ограничивает подбор пароля: после DelayAfter неудач подряд каждая следующая попытка
+// по адресу возможна только после растущей задержки, после MaxFailures вход блокируется на Lockout.
+// С одного IP блокировка наступает после IPMaxFailures неудач по любым адресам.
+// Счётчики ведутся и для несуществующих адресов — иначе блокировка выдавала бы зарегистрированные.
+type LoginGuard struct {
+	repo LoginThrottleStore
+	cfg  config.LoginGuardConfig
+	log  *zap.Logger
+}
+
+// LoginThrottleStore — хранилище счётчиков неудачных входов (repository.LoginThrottleRepository)
+type LoginThrottleStore interface {
+	Find(kind, key string) (*models.LoginThrottle, error)
+	RegisterFailure(kind, key string, now, windowStart time.Time) (*models.LoginThrottle, error)
+	Lock(kind, key string, until time.Time, unlockTokenHash *string) error
+	Reset(kind, key string) error
+	Unlock(unlockTokenHash string, now time.Time) (bool, error)
+}
+
+func NewLoginGuard(repo LoginThrottleStore, cfg config.LoginGuardConfig, log *zap.Logger) *LoginGuard {
+	return &LoginGuard{repo: repo, cfg: cfg, log: log}
+}
+
+type throttleKey struct {
+	kind string
+	key  string
+}
+
+func (g *LoginGuard) keys(email, ip string) []throttleKey {
+	keys := []throttleKey{{throttleEmail, models.NormalizeEmail(email)}}
+	if ip != "" {
+		keys = append(keys, throttleKey{throttleIP, ip})
+	}
+	return keys
+}
+
+// Check возвращает LoginThrottledError, если вход по адресу или с IP сейчас запрещён.
+// Такая попытка не проверяет пароль и не учитывается как неудачная.
+func (g *LoginGuard) Check(email, ip string) error {
+	now := time.Now()
+	for _, k := range g.keys(email, ip) {
+		t, err := g.repo.Find(k.kind, k.key)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if t.LockedUntil != nil && t.LockedUntil.After(now) {
+			return &LoginThrottledError{RetryAfter: t.LockedUntil.Sub(now)}
+		}
+		if k.kind != throttleEmail {
+			continue
+		}
+		if wait := t.LastFailureAt.Add(g.delay(t.Failures)).Sub(now); wait > 0 {
+			return &LoginThrottledError{RetryAfter: wait}
+		}
+	}
+	return nil
+}
+
+// delay — пауза после failures неудач подряд по одному адресу. С IP задержек нет, только
+// блокировка с большим порогом: за одним IP (NAT, офис) может быть много пользователей.
+func (g *LoginGuard) delay(failures int) time.Duration {
+	over := failures - g.cfg.DelayAfter
+	if over < 0 {
+		return 0
+	}
+	if over > 16 {
+		return g.cfg.DelayMax
+	}
+	return min(loginDelayBase<<over, g.cfg.DelayMax)
+}
+
+// Fail учитывает неудачную попытку. Если адрес только что заблокирован, возвращается
+// токен для письма, который снимает блокировку досрочно.
+func (g *LoginGuard) Fail(email, ip string) (unlockToken string, err error) {
+	now := time.Now()
+	for _, k := range g.keys(email, ip) {
+		t, err := g.repo.RegisterFailure(k.kind, k.key, now, now.Add(-g.cfg.FailureWindow))
+		if err != nil {
+			return "", err
+		}
+		limit := g.cfg.MaxFailures
+		if k.kind == throttleIP {
+			limit = g.cfg.IPMaxFailures
+		}
+		if t.Failures < limit {
+			continue
+		}
+
+		var tokenHash *string
+		if k.kind == throttleEmail {
+			if unlockToken, err = randomToken(32); err != nil {
+				return "", err
+			}
+			hash := hashSecret(unlockToken)
+			tokenHash = &hash
+		}
+		if err := g.repo.Lock(k.kind, k.key, now.Add(g.cfg.Lockout), tokenHash); err != nil {
+			return "", err
+		}
+		g.log.Warn("Вход временно заблокирован после неудачных попыток",
+			zap.String("kind", k.kind), zap.String("key", k.key), zap.Int("failures", t.Failures))
+	}
+	return unlockToken, nil
+}
+
+// Succeed сбрасывает счётчик адреса. Счётчик IP не сбрасывается: иначе вход в свой аккаунт
+// обнулял бы его при переборе чужих.
+func (g *LoginGuard) Succeed(email string) error {
+	return g.repo.Reset(throttleEmail, models.NormalizeEmail(email))
+}
+
+// Unlock снимает блокировку адреса по токену из письма
+func (g *LoginGuard) Unlock(token string) error {
+	ok, err := g.repo.Unlock(hashSecret(token), time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidToken
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"auth-service/config"
+	"auth-service/internal/models"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// memoryThrottles — LoginThrottleStore в памяти с той же семантикой, что у LoginThrottleRepository
+type memoryThrottles struct {
+	mu   sync.Mutex
+	rows map[throttleKey]*models.LoginThrottle
+}
+
+func newMemoryThrottles() *memoryThrottles {
+	return &memoryThrottles{rows: map[throttleKey]*models.LoginThrottle{}}
+}
+
+func (s *memoryThrottles) Find(kind, key string) (*models.LoginThrottle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.rows[throttleKey{kind, key}]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *t
+	return &copied, nil
+}
+
+func (s *memoryThrottles) RegisterFailure(kind, key string, now, windowStart time.Time) (*models.LoginThrottle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.rows[throttleKey{kind, key}]
+	switch {
+	case !ok:
+		t = &models.LoginThrottle{Kind: kind, Key: key, Failures: 1}
+		s.rows[throttleKey{kind, key}] = t
+	case t.LastFailureAt.After(windowStart):
+		t.Failures++
+	default:
+		t.Failures = 1
+	}
+	t.LastFailureAt = now
+	copied := *t
+	return &copied, nil
+}
+
+func (s *memoryThrottles) Lock(kind, key string, until time.Time, unlockTokenHash *string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.rows[throttleKey{kind, key}]; ok {
+		t.Failures, t.LockedUntil, t.UnlockTokenHash = 0, &until, unlockTokenHash
+	}
+	return nil
+}
+
+func (s *memoryThrottles) Reset(kind, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.rows, throttleKey{kind, key})
+	return nil
+}
+
+func (s *memoryThrottles) Unlock(unlockTokenHash string, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, t := range s.rows {
+		if t.UnlockTokenHash != nil && *t.UnlockTokenHash == unlockTokenHash && t.LockedUntil != nil && t.LockedUntil.After(now) {
+			delete(s.rows, k)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// rewind сдвигает последнюю неудачу в прошлое — вместо ожидания паузы
+func (s *memoryThrottles) rewind(kind, key string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.rows[throttleKey{kind, key}]; ok {
+		t.LastFailureAt = t.LastFailureAt.Add(-d)
+	}
+}
+
+var testGuardConfig = config.LoginGuardConfig{
+	DelayAfter:    2,
+	DelayMax:      4 * time.Second,
+	MaxFailures:   5,
+	IPMaxFailures: 8,
+	Lockout:       15 * time.Minute,
+	FailureWindow: time.Hour,
+}
+
+func newTestGuard() (*LoginGuard, *memoryThrottles) {
+	store := newMemoryThrottles()
+	return NewLoginGuard(store, testGuardConfig, zap.NewNop()), store
+}
+
+// retryAfter возвращает паузу из LoginThrottledError или 0, если вход разрешён
+func retryAfter(t *testing.T, g *LoginGuard, email, ip string) time.Duration {
+	t.Helper()
+	err := g.Check(email, ip)
+	if err == nil {
+		return 0
+	}
+	var throttled *LoginThrottledError
+	if !errors.As(err, &throttled) {
+		t.Fatalf("Check: %v", err)
+	}
+	return throttled.RetryAfter
+}
+
+func fail(t *testing.T, g *LoginGuard, email, ip string) string {
+	t.Helper()
+	token, err := g.Fail(email, ip)
+	if err != nil {
+		t.Fatalf("Fail: %v", err)
+	}
+	return token
+}
+
+func TestLoginGuardDelaysGrowAfterThreshold(t *testing.T) {
+	g, store := newTestGuard()
+	const email = "alice@example.com"
+
+	fail(t, g, email, "10.0.0.1")
+	if wait := retryAfter(t, g, email, "10.0.0.1"); wait != 0 {
+		t.Fatalf("retry after first failure = %v, want no delay", wait)
+	}
+
+	// Пауза после 2, 3 и 4 неудач: 1s, 2s, 4s (DelayMax)
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		store.rewind(throttleEmail, email, time.Minute)
+		fail(t, g, email, "10.0.0.1")
+		wait := retryAfter(t, g, email, "10.0.0.1")
+		if wait <= want-time.Second/2 || wait > want {
+			t.Fatalf("retry after = %v, want about %v", wait, want)
+		}
+		// После паузы вход снова разрешён
+		store.rewind(throttleEmail, email, want)
+		if wait := retryAfter(t, g, email, "10.0.0.1"); wait != 0 {
+			t.Fatalf("retry after pause = %v, want none", wait)
+		}
+	}
+}
+
+func TestLoginGuardLocksAndUnlocksByEmailToken(t *testing.T) {
+	g, store := newTestGuard()
+	const email = "alice@example.com"
+
+	var token string
+	for i := 0; i < testGuardConfig.MaxFailures; i++ {
+		store.rewind(throttleEmail, email, time.Minute)
+		token = fail(t, g, email, "10.0.0.1")
+		if i < testGuardConfig.MaxFailures-1 && token != "" {
+			t.Fatalf("unlock token issued after %d failures", i+1)
+		}
+	}
+	if token == "" {
+		t.Fatal("no unlock token after lockout")
+	}
+	if wait := retryAfter(t, g, email, "10.0.0.1"); wait < testGuardConfig.Lockout-time.Minute {
+		t.Fatalf("retry after lockout = %v, want about %v", wait, testGuardConfig.Lockout)
+	}
+	// Давняя последняя неудача не снимает блокировку
+	store.rewind(throttleEmail, email, time.Hour)
+	if wait := retryAfter(t, g, email, "10.0.0.1"); wait == 0 {
+		t.Fatal("lockout lifted by an old last failure")
+	}
+
+	if err := g.Unlock("wrong-token"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Unlock(wrong) = %v, want ErrInvalidToken", err)
+	}
+	if err := g.Unlock(token); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if wait := retryAfter(t, g, email, "10.0.0.1"); wait != 0 {
+		t.Fatalf("retry after unlock = %v, want none", wait)
+	}
+	if err := g.Unlock(token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("second Unlock = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestLoginGuardNormalizesEmail(t *testing.T) {
+	g, _ := newTestGuard()
+
+	fail(t, g, " Alice@Example.COM ", "")
+	fail(t, g, "alice@example.com", "")
+	if wait := retryAfter(t, g, "ALICE@example.com", ""); wait == 0 {
+		t.Fatal("failures under different spellings of one address were counted separately")
+	}
+	if err := g.Succeed("Alice@example.com "); err != nil {
+		t.Fatal(err)
+	}
+	if wait := retryAfter(t, g, "alice@example.com", ""); wait != 0 {
+		t.Fatalf("retry after success = %v, want the counter reset", wait)
+	}
+}
+
+func TestLoginGuardLocksIPAcrossAddresses(t *testing.T) {
+	g, _ := newTestGuard()
+	const ip = "203.0.113.7"
+
+	for i := 0; i < testGuardConfig.IPMaxFailures; i++ {
+		// Каждый раз новый адрес: пауз по адресу нет, и письма о блокировке тоже
+		if token := fail(t, g, fmt.Sprintf("user%d@example.com", i), ip); token != "" {
+			t.Fatal("unlock token issued for an IP lockout")
+		}
+	}
+	if wait := retryAfter(t, g, "fresh@example.com", ip); wait < testGuardConfig.Lockout-time.Minute {
+		t.Fatalf("retry after IP lockout = %v, want about %v", wait, testGuardConfig.Lockout)
+	}
+	if wait := retryAfter(t, g, "fresh@example.com", "203.0.113.8"); wait != 0 {
+		t.Fatalf("other IP throttled for %v", wait)
+	}
+}
+
+func TestLoginGuardSucceedKeepsIPCounter(t *testing.T) {
+	g, store := newTestGuard()
+	const ip = "203.0.113.7"
+
+	for i := 0; i < testGuardConfig.IPMaxFailures-1; i++ {
+		store.rewind(throttleEmail, "alice@example.com", time.Minute)
+		fail(t, g, "alice@example.com", ip)
+		if err := g.Succeed("alice@example.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fail(t, g, "bob@example.com", ip)
+	if wait := retryAfter(t, g, "alice@example.com", ip); wait == 0 {
+		t.Fatal("successful logins reset the IP counter")
+	}
+}
+
+func TestLoginGuardForgetsOldFailures(t *testing.T) {
+	g, store := newTestGuard()
+	const email = "alice@example.com"
+
+	for i := 0; i < testGuardConfig.MaxFailures-1; i++ {
+		store.rewind(throttleEmail, email, time.Minute)
+		fail(t, g, email, "")
+	}
+	store.rewind(throttleEmail, email, testGuardConfig.FailureWindow)
+	if token := fail(t, g, email, ""); token != "" {
+		t.Fatal("failures older than FailureWindow led to a lockout")
+	}
+	if wait := retryAfter(t, g, email, ""); wait != 0 {
+		t.Fatalf("retry after = %v, want the counter restarted", wait)
+	}
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"auth-service/internal/models"
+	"auth-service/internal/producer"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Окно, в котором действует MagicLink.MaxPerHour
+const magicLinkWindow = time.Hour
+
+// RequestMagicLink отправляет одноразовую ссылку для входа без пароля.
+// На один адрес — не больше MagicLink.MaxPerHour ссылок в час. Неизвестный адрес и
+// превышение лимита не считаются ошибкой: по ответу нельзя узнать, зарегистрирован ли адрес.
+func (s *UserService) RequestMagicLink(email string) error {
+	user, err := s.repo.FindByEmail(email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	recent, err := s.magicLinkRepo.CountSince(user.ID, time.Now().Add(-magicLinkWindow))
+	if err != nil {
+		return err
+	}
+	if recent >= int64(s.Cfg.MagicLink.MaxPerHour) {
+		s.Log.Info("Лимит ссылок для входа исчерпан", zap.String("user_id", user.ID.String()))
+		return nil
+	}
+
+	token := &models.MagicLinkToken{
+		UserID:    user.ID,
+		Token:     uuid.New().String(),
+		ExpiresAt: time.Now().Add(s.Cfg.MagicLink.TTL),
+	}
+	return s.tx.Do(func(tx *gorm.DB) error {
+		if err := s.magicLinkRepo.WithTx(tx).Create(token); err != nil {
+			return err
+		}
+		return s.enqueueEmail(tx, producer.EmailMessage{
+			To:       user.Email,
+			Subject:  "Вход в LinkVault",
+			Template: "magic_link",
+			Data: map[string]any{
+				"UserName":      user.Name,
+				"LoginURL":      "https://app/login?token=" + token.Token,
+				"ExpireMinutes": fmt.Sprint(int(s.Cfg.MagicLink.TTL.Minutes())),
+			},
+		})
+	})
+}
+
+// ConsumeMagicLink обменивает ссылку на пару токенов. Ссылка подтверждает владение адресом,
+// поэтому email отмечается подтверждённым. Включённый второй фактор по-прежнему запрашивается.
+func (s *UserService) ConsumeMagicLink(token string, client ClientInfo) (access, refresh string, err error) {
+	mlt, err := s.magicLinkRepo.Consume(token)
+	if err != nil {
+		return "", "", ErrInvalidToken
+	}
+	user, err := s.repo.FindByID(mlt.UserID)
+	if err != nil {
+		return "", "", ErrUserNotFound
+	}
+	if !user.EmailVerified {
+		if err := s.repo.MarkEmailVerified(user.ID); err != nil {
+			return "", "", err
+		}
+	}
+	return s.completeLogin(user.ID, client)
+}
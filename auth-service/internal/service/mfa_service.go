@@ -0,0 +1,314 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"auth-service/config"
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"auth-service/internal/totp"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMFAUnavailable    = errors.New("mfa is not configured")
+	ErrMFAAlreadyEnabled = errors.New("mfa already enabled")
+	ErrMFANotEnabled     = errors.New("mfa not enabled")
+	ErrInvalidMFACode    = errors.New("invalid mfa code")
+	ErrMFARequired       = errors.New("mfa required")
+)
+
+// MFARequiredError — пароль верен, но токены выдаются только после второго фактора.
+// ChallengeToken обменивается вместе с кодом через UserService.VerifyMFA.
+type MFARequiredError struct {
+	ChallengeToken string
+}
+
+func (e *MFARequiredError) Error() string { return ErrMFARequired.Error() }
+func (e *MFARequiredError) Unwrap() error { return ErrMFARequired }
+
+// TOTPEnrollment — данные для добавления аккаунта в приложение-аутентификатор
+type TOTPEnrollment struct {
+	// Секрет в base32 для ручного ввода
+	Secret string
+	// otpauth:// URI для QR-кода
+	URI string
+}
+
+const (
+	recoveryCodeCount = 10
+	// Допустимое расхождение часов клиента — один шаг в каждую сторону
+	totpSkew = 1
+)
+
+// MFAService управляет вторым фактором: TOTP, кодами восстановления и вызовами при входе
+type MFAService struct {
+	repo     *repository.MFARepository
+	userRepo *repository.UserRepository
+	tx       *repository.Transactor
+	// nil, если MFA_ENCRYPTION_KEY не задан
+	cipher *totp.Cipher
+	cfg    config.MFAConfig
+	log    *zap.Logger
+}
+
+func NewMFAService(repo *repository.MFARepository, userRepo *repository.UserRepository, tx *repository.Transactor, cipher *totp.Cipher, cfg config.MFAConfig, log *zap.Logger) *MFAService {
+	return &MFAService{repo: repo, userRepo: userRepo, tx: tx, cipher: cipher, cfg: cfg, log: log}
+}
+
+// EnrollTOTP создаёт новый секрет. Фактор включается только после ConfirmTOTP.
+func (s *MFAService) EnrollTOTP(userID uuid.UUID) (*TOTPEnrollment, error) {
+	if s.cipher == nil {
+		return nil, ErrMFAUnavailable
+	}
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if existing, err := s.repo.FindTOTP(userID); err == nil && existing.ConfirmedAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.cipher.Seal(secret, userID[:])
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveTOTP(&models.UserTOTP{UserID: userID, Secret: sealed}); err != nil {
+		return nil, err
+	}
+	return &TOTPEnrollment{
+		Secret: totp.EncodeSecret(secret),
+		URI:    totp.ProvisioningURI(s.cfg.Issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP включает фактор по первому коду из приложения и возвращает коды восстановления.
+// Коды показываются пользователю один раз: сервис хранит только их хэши.
+func (s *MFAService) ConfirmTOTP(userID uuid.UUID, code string) ([]string, error) {
+	t, err := s.repo.FindTOTP(userID)
+	if err != nil {
+		return nil, ErrMFANotEnabled
+	}
+	if t.ConfirmedAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	secret, err := s.openSecret(t)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := totp.Verify(secret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	err = s.tx.Do(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.ConfirmTOTP(userID, step, time.Now()); err != nil {
+			return err
+		}
+		return repo.ReplaceRecoveryCodes(userID, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTOTP отключает второй фактор; нужен действующий код или код восстановления
+func (s *MFAService) DisableTOTP(userID uuid.UUID, code string) error {
+	if err := s.verifyCode(userID, code); err != nil {
+		return err
+	}
+	return s.tx.Do(func(tx *gorm.DB) error {
+		return s.repo.WithTx(tx).DeleteTOTP(userID)
+	})
+}
+
+// RegenerateRecoveryCodes заменяет коды восстановления новыми; прежние перестают действовать
+func (s *MFAService) RegenerateRecoveryCodes(userID uuid.UUID, code string) ([]string, error) {
+	if err := s.verifyCode(userID, code); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	err = s.tx.Do(func(tx *gorm.DB) error {
+		return s.repo.WithTx(tx).ReplaceRecoveryCodes(userID, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// RecoveryCodesLeft возвращает число неиспользованных кодов восстановления
+func (s *MFAService) RecoveryCodesLeft(userID uuid.UUID) (int64, error) {
+	return s.repo.CountRecoveryCodes(userID)
+}
+
+// Enabled сообщает, требуется ли пользователю второй фактор при входе
+func (s *MFAService) Enabled(userID uuid.UUID) (bool, error) {
+	t, err := s.repo.FindTOTP(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return t.ConfirmedAt != nil, nil
+}
+
+// createChallenge выдаёт одноразовый токен вызова; в БД хранится только его хэш
+func (s *MFAService) createChallenge(userID uuid.UUID) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	err := s.repo.CreateChallenge(&models.MFAChallenge{
+		TokenHash: hashSecret(token),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(s.cfg.ChallengeTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// findChallenge возвращает действующий вызов по токену из MFARequiredError
+func (s *MFAService) findChallenge(token string) (*models.MFAChallenge, error) {
+	c, err := s.repo.FindChallenge(hashSecret(token))
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return c, nil
+}
+
+// consumeChallenge проверяет код по вызову и гасит вызов. Каждая попытка, в том числе
+// удачная, расходует одну из MaxAttempts — подбор кода в рамках одного вызова невозможен.
+func (s *MFAService) consumeChallenge(c *models.MFAChallenge, code string) error {
+	ok, err := s.repo.TakeChallengeAttempt(c.ID, s.cfg.MaxAttempts)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidToken
+	}
+	if err := s.verifyCode(c.UserID, code); err != nil {
+		return err
+	}
+	used, err := s.repo.UseChallenge(c.ID)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// verifyCode принимает код TOTP (6 цифр) или код восстановления; любой код срабатывает один раз
+func (s *MFAService) verifyCode(userID uuid.UUID, code string) error {
+	t, err := s.repo.FindTOTP(userID)
+	if err != nil || t.ConfirmedAt == nil {
+		return ErrMFANotEnabled
+	}
+	code = normalizeCode(code)
+
+	if isDigits(code) && len(code) == totp.Digits {
+		secret, err := s.openSecret(t)
+		if err != nil {
+			return err
+		}
+		step, ok := totp.Verify(secret, code, time.Now(), totpSkew)
+		if !ok {
+			return ErrInvalidMFACode
+		}
+		fresh, err := s.repo.UseStep(userID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	used, err := s.repo.UseRecoveryCode(userID, hashSecret(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+	s.log.Info("Использован код восстановления", zap.String("user_id", userID.String()))
+	return nil
+}
+
+func (s *MFAService) openSecret(t *models.UserTOTP) ([]byte, error) {
+	if s.cipher == nil {
+		return nil, ErrMFAUnavailable
+	}
+	return s.cipher.Open(t.Secret, t.UserID[:])
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCodes возвращает коды для показа (вида xxxxx-xxxxx, 50 бит) и их хэши для хранения
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		code := recoveryEncoding.EncodeToString(raw)[:10]
+		codes = append(codes, strings.ToLower(code[:5]+"-"+code[5:]))
+		hashes = append(hashes, hashSecret(code))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeCode убирает пробелы и дефисы и приводит к верхнему регистру, как при генерации
+func normalizeCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, code)
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func hashSecret(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"auth-service/internal/totp"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	testPassword = "correct horse battery staple"
+	// Формат кода восстановления, которого у пользователя точно нет
+	wrongMFACode = "aaaaa-bbbbb"
+)
+
+var testClient = ClientInfo{UserAgent: "test", IP: "203.0.113.7"}
+
+// registerUser создаёт пользователя с паролем testPassword
+func registerUser(t *testing.T, env *testEnv, email string) *models.User {
+	t.Helper()
+	user, err := env.users.Register("Test", email, testPassword)
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	return user
+}
+
+// enableTOTP включает второй фактор и возвращает секрет; код текущего шага уже израсходован
+func enableTOTP(t *testing.T, env *testEnv, userID uuid.UUID) []byte {
+	t.Helper()
+	enrollment, err := env.mfa.EnrollTOTP(userID)
+	if err != nil {
+		t.Fatalf("EnrollTOTP: %v", err)
+	}
+	secret, err := recoveryEncoding.DecodeString(enrollment.Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.mfa.ConfirmTOTP(userID, totp.Code(secret, totp.Step(time.Now()))); err != nil {
+		t.Fatalf("ConfirmTOTP: %v", err)
+	}
+	return secret
+}
+
+// nextTOTPCode — код следующего шага: текущий уже принят при включении, а следующий ещё в окне допуска
+func nextTOTPCode(secret []byte) string {
+	return totp.Code(secret, totp.Step(time.Now())+1)
+}
+
+// loginChallenge проходит пароль и возвращает вызов второго фактора
+func loginChallenge(t *testing.T, env *testEnv, email string) string {
+	t.Helper()
+	_, _, err := env.users.Login(email, testPassword, testClient)
+	var mfaErr *MFARequiredError
+	if !errors.As(err, &mfaErr) {
+		t.Fatalf("Login = %v, want MFARequiredError", err)
+	}
+	return mfaErr.ChallengeToken
+}
+
+// emailFailures — счётчик неудачных входов адреса; 0, если записи нет
+func emailFailures(t *testing.T, env *testEnv, email string) (failures int, locked bool) {
+	t.Helper()
+	row, err := repository.NewLoginThrottleRepository(env.db).Find(throttleEmail, models.NormalizeEmail(email))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return row.Failures, row.LockedUntil != nil && row.LockedUntil.After(time.Now())
+}
+
+func TestVerifyMFACountsWrongCodesAsLoginFailures(t *testing.T) {
+	env := newTestEnv(t)
+	const email = "mfa-guard@example.com"
+	user := registerUser(t, env, email)
+	secret := enableTOTP(t, env, user.ID)
+
+	if _, _, err := env.users.Login(email, "wrong password", testClient); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Login with wrong password = %v", err)
+	}
+	challenge := loginChallenge(t, env, email)
+	if n, _ := emailFailures(t, env, email); n != 1 {
+		t.Fatalf("failures after correct password = %d, want 1: the password alone must not reset the counter", n)
+	}
+
+	for want := 2; want <= 3; want++ {
+		if _, _, err := env.users.VerifyMFA(challenge, wrongMFACode, testClient); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("VerifyMFA with wrong code = %v", err)
+		}
+		n, locked := emailFailures(t, env, email)
+		if want < env.cfg.Login.MaxFailures && n != want {
+			t.Fatalf("failures after wrong code = %d, want %d", n, want)
+		}
+		if want == env.cfg.Login.MaxFailures && !locked {
+			t.Fatalf("account not locked after %d failures", want)
+		}
+	}
+
+	var throttled *LoginThrottledError
+	if _, _, err := env.users.VerifyMFA(challenge, nextTOTPCode(secret), testClient); !errors.As(err, &throttled) {
+		t.Fatalf("VerifyMFA on locked account = %v, want LoginThrottledError", err)
+	}
+	if _, _, err := env.users.Login(email, testPassword, testClient); !errors.As(err, &throttled) {
+		t.Fatalf("Login on locked account = %v, want LoginThrottledError", err)
+	}
+}
+
+func TestVerifyMFAResetsFailuresOnlyAfterSecondFactor(t *testing.T) {
+	env := newTestEnv(t)
+	const email = "mfa-reset@example.com"
+	user := registerUser(t, env, email)
+	secret := enableTOTP(t, env, user.ID)
+
+	if _, _, err := env.users.Login(email, "wrong password", testClient); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Login with wrong password = %v", err)
+	}
+	challenge := loginChallenge(t, env, email)
+	access, refresh, err := env.users.VerifyMFA(challenge, nextTOTPCode(secret), testClient)
+	if err != nil || access == "" || refresh == "" {
+		t.Fatalf("VerifyMFA = %q, %q, %v", access, refresh, err)
+	}
+	if n, _ := emailFailures(t, env, email); n != 0 {
+		t.Fatalf("failures after second factor = %d, want 0", n)
+	}
+	// Вызов одноразовый
+	if _, _, err := env.users.VerifyMFA(challenge, nextTOTPCode(secret), testClient); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("VerifyMFA with used challenge = %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"auth-service/internal/models"
+	"auth-service/internal/oauthclient"
+	"auth-service/internal/repository"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown identity provider")
+	ErrOAuthFailed     = errors.New("external login failed")
+	// ErrEmailNotVerified — провайдер не подтвердил email, привязать вход к аккаунту нельзя
+	ErrEmailNotVerified = errors.New("provider email is not verified")
+	// ErrAccountNotVerified — аккаунт с этим email есть, но его владелец не подтвердил адрес
+	ErrAccountNotVerified = errors.New("account email is not verified")
+	ErrIdentityNotFound   = errors.New("identity not found")
+)
+
+// OAuthService — вход через внешних провайдеров (Google, GitHub, любой OIDC)
+type OAuthService struct {
+	providers map[string]*oauthclient.Provider
+	repo      *repository.OAuthRepository
+	userRepo  *repository.UserRepository
+	users     *UserService
+	tx        *repository.Transactor
+	stateTTL  time.Duration
+	log       *zap.Logger
+}
+
+func NewOAuthService(providers []*oauthclient.Provider, repo *repository.OAuthRepository, userRepo *repository.UserRepository, users *UserService, tx *repository.Transactor, stateTTL time.Duration, log *zap.Logger) *OAuthService {
+	byName := make(map[string]*oauthclient.Provider, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
+	return &OAuthService{providers: byName, repo: repo, userRepo: userRepo, users: users, tx: tx, stateTTL: stateTTL, log: log}
+}
+
+// Providers возвращает имена настроенных провайдеров
+func (s *OAuthService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BeginOAuth создаёт state, PKCE и nonce и возвращает адрес, на который нужно отправить пользователя
+func (s *OAuthService) BeginOAuth(ctx context.Context, provider string) (string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+	state, err := oauthclient.RandomString(32)
+	if err != nil {
+		return "", err
+	}
+	verifier, challenge, err := oauthclient.NewPKCE()
+	if err != nil {
+		return "", err
+	}
+	var nonce string
+	if p.OIDC() {
+		if nonce, err = oauthclient.RandomString(16); err != nil {
+			return "", err
+		}
+	}
+	authURL, err := p.AuthCodeURL(ctx, state, challenge, nonce)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrOAuthFailed, err)
+	}
+	err = s.repo.CreateState(&models.OAuthState{
+		State:        state,
+		Provider:     provider,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(s.stateTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return authURL, nil
+}
+
+// CompleteOAuth обрабатывает возврат от провайдера (state и code из redirect URL) и выдаёт пару токенов.
+// Включённый второй фактор запрашивается так же, как при входе по паролю.
+func (s *OAuthService) CompleteOAuth(ctx context.Context, provider, state, code string, client ClientInfo) (access, refresh string, err error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", "", ErrUnknownProvider
+	}
+	st, err := s.repo.TakeState(state, provider)
+	if err != nil {
+		return "", "", ErrInvalidToken
+	}
+	identity, err := p.Exchange(ctx, code, st.CodeVerifier, st.Nonce)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %w", ErrOAuthFailed, err)
+	}
+	userID, err := s.resolveUser(provider, identity)
+	if err != nil {
+		return "", "", err
+	}
+	return s.users.completeLogin(userID, client)
+}
+
+// resolveUser находит пользователя по привязке или привязывает учётную запись провайдера по подтверждённому email.
+// Новый пользователь создаётся без пароля: задать его можно через сброс пароля.
+func (s *OAuthService) resolveUser(provider string, identity *oauthclient.Identity) (uuid.UUID, error) {
+	existing, err := s.repo.FindIdentity(provider, identity.Subject)
+	if err == nil {
+		if err := s.repo.TouchIdentity(existing.ID, identity.Email); err != nil {
+			s.log.Warn("Не удалось обновить привязку провайдера", zap.String("provider", provider), zap.Error(err))
+		}
+		return existing.UserID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return uuid.Nil, err
+	}
+	if identity.Email == "" || !identity.EmailVerified {
+		return uuid.Nil, ErrEmailNotVerified
+	}
+
+	var userID uuid.UUID
+	err = s.tx.Do(func(tx *gorm.DB) error {
+		users := s.userRepo.WithTx(tx)
+		user, err := users.FindByEmail(identity.Email)
+		switch {
+		case err == nil:
+			// Аккаунт с неподтверждённым адресом мог создать кто угодно — не отдаём его владельцу адреса у провайдера
+			if !user.EmailVerified {
+				return ErrAccountNotVerified
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			user = &models.User{
+				Name:          displayName(identity),
+				Email:         identity.Email,
+				EmailVerified: true,
+			}
+			if err := users.Create(user); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+		userID = user.ID
+		now := time.Now()
+		return s.repo.WithTx(tx).CreateIdentity(&models.ExternalIdentity{
+			UserID:      user.ID,
+			Provider:    provider,
+			Subject:     identity.Subject,
+			Email:       identity.Email,
+			LastLoginAt: &now,
+		})
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	s.log.Info("Привязан внешний провайдер", zap.String("provider", provider), zap.String("user_id", userID.String()))
+	return userID, nil
+}
+
+func (s *OAuthService) ListIdentities(userID uuid.UUID) ([]models.ExternalIdentity, error) {
+	return s.repo.ListIdentities(userID)
+}
+
+// UnlinkIdentity отвязывает провайдера; вход через него снова привяжет учётную запись по email
+func (s *OAuthService) UnlinkIdentity(userID, id uuid.UUID) error {
+	n, err := s.repo.DeleteIdentity(userID, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrIdentityNotFound
+	}
+	return nil
+}
+
+func displayName(identity *oauthclient.Identity) string {
+	if identity.Name != "" {
+		return identity.Name
+	}
+	name, _, _ := strings.Cut(identity.Email, "@")
+	return name
+}
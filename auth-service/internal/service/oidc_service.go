@@ -0,0 +1,394 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"auth-service/config"
+	"auth-service/internal/jwt"
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidClient = errors.New("invalid client")
+	// ErrInvalidRedirectURI — redirect_uri не зарегистрирован; об этой ошибке нельзя сообщать через redirect
+	ErrInvalidRedirectURI      = errors.New("invalid redirect uri")
+	ErrUnsupportedResponseType = errors.New("unsupported response type")
+	ErrInvalidScope            = errors.New("invalid scope")
+	ErrPKCERequired            = errors.New("code_challenge with method S256 is required")
+	ErrInvalidGrant            = errors.New("invalid grant")
+)
+
+// OIDCScopes — scope, которые выдаёт провайдер; openid обязателен
+var OIDCScopes = []string{"openid", "profile", "email"}
+
+// OIDCService — LinkVault как OpenID-провайдер: коды авторизации с PKCE, токены и userinfo.
+// Сессии клиентов хранятся в общем хранилище refresh-токенов и видны в списке сессий пользователя.
+type OIDCService struct {
+	repo  *repository.OIDCRepository
+	users *UserService
+	cfg   config.OIDCConfig
+	log   *zap.Logger
+}
+
+func NewOIDCService(repo *repository.OIDCRepository, users *UserService, cfg config.OIDCConfig, log *zap.Logger) *OIDCService {
+	return &OIDCService{repo: repo, users: users, cfg: cfg, log: log}
+}
+
+func (s *OIDCService) Issuer() string {
+	return s.cfg.Issuer
+}
+
+// LoginURL — страница входа LinkVault, на которую отправляется запрос авторизации
+func (s *OIDCService) LoginURL() string {
+	return s.cfg.LoginURL
+}
+
+// AccessTokenTTL — срок access-токенов (expires_in ответа token endpoint)
+func (s *OIDCService) AccessTokenTTL() time.Duration {
+	return s.users.Cfg.JWT.AccessExp
+}
+
+// AuthorizeRequest — параметры запроса авторизации (RFC 6749 §4.1.1, RFC 7636)
+type AuthorizeRequest struct {
+	ClientID            string
+	RedirectURI         string
+	ResponseType        string
+	Scope               string
+	State               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// TokenResponse — ответ token endpoint
+type TokenResponse struct {
+	AccessToken  string
+	RefreshToken string
+	IDToken      string
+	Scope        string
+}
+
+// CheckRedirect проверяет клиента и redirect_uri. Пока они не проверены, ошибки отдаются
+// пользователю напрямую, а не на redirect_uri (RFC 6749 §4.1.2.1).
+func (s *OIDCService) CheckRedirect(clientID, redirectURI string) (*models.OIDCClient, error) {
+	client, err := s.repo.FindClient(clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidClient
+		}
+		return nil, err
+	}
+	for _, uri := range client.RedirectURIs {
+		if uri == redirectURI {
+			return client, nil
+		}
+	}
+	return nil, ErrInvalidRedirectURI
+}
+
+// ValidateAuthorize проверяет запрос авторизации целиком и возвращает нормализованный scope
+func (s *OIDCService) ValidateAuthorize(req AuthorizeRequest) (string, error) {
+	if _, err := s.CheckRedirect(req.ClientID, req.RedirectURI); err != nil {
+		return "", err
+	}
+	if req.ResponseType != "code" {
+		return "", ErrUnsupportedResponseType
+	}
+	scope, err := normalizeScope(req.Scope)
+	if err != nil {
+		return "", err
+	}
+	// PKCE обязателен и для конфиденциальных клиентов (OAuth 2.0 Security BCP)
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		return "", ErrPKCERequired
+	}
+	return scope, nil
+}
+
+// Authorize выдаёт код авторизации пользователю, уже вошедшему в LinkVault: страница входа
+// предъявляет его access-токен. Согласие не запрашивается — клиенты регистрирует администратор.
+func (s *OIDCService) Authorize(accessToken string, req AuthorizeRequest) (string, error) {
+	claims, err := s.users.ValidateAccessToken(accessToken)
+	if err != nil {
+		return "", err
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return "", ErrInvalidToken
+	}
+	scope, err := s.ValidateAuthorize(req)
+	if err != nil {
+		return "", err
+	}
+	code, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	err = s.repo.CreateCode(&models.OIDCAuthCode{
+		CodeHash:      hashSecret(code),
+		ClientID:      req.ClientID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         scope,
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     time.Now().Add(s.cfg.CodeTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// ExchangeCode обменивает код авторизации на access-, refresh- и ID token
+func (s *OIDCService) ExchangeCode(clientID, clientSecret, code, redirectURI, verifier string, info ClientInfo) (*TokenResponse, error) {
+	client, err := s.authenticateClient(clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+	ac, err := s.repo.TakeCode(hashSecret(code))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidGrant
+		}
+		return nil, err
+	}
+	if ac.ClientID != client.ClientID || ac.RedirectURI != redirectURI || !verifyPKCE(verifier, ac.CodeChallenge) {
+		return nil, ErrInvalidGrant
+	}
+	user, err := s.users.repo.FindByID(ac.UserID)
+	if err != nil {
+		return nil, ErrInvalidGrant
+	}
+
+	info.ClientName = client.Name
+	info.ClientID = client.ClientID
+	info.Scope = ac.Scope
+	access, refresh, err := s.users.issueSession(user.ID, info)
+	if err != nil {
+		return nil, err
+	}
+	idToken, err := s.idToken(user, client.ClientID, ac.Scope, ac.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenResponse{AccessToken: access, RefreshToken: refresh, IDToken: idToken, Scope: ac.Scope}, nil
+}
+
+// RefreshToken обновляет токены клиента с ротацией и обнаружением повторного использования, как Refresh
+func (s *OIDCService) RefreshToken(clientID, clientSecret, refreshToken string, info ClientInfo) (*TokenResponse, error) {
+	client, err := s.authenticateClient(clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+	info.ClientName = client.Name
+	info.ClientID = client.ClientID
+	access, refresh, err := s.users.Refresh(refreshToken, info)
+	if err != nil {
+		if errors.Is(err, ErrInvalidToken) {
+			return nil, ErrInvalidGrant
+		}
+		return nil, err
+	}
+	return &TokenResponse{AccessToken: access, RefreshToken: refresh}, nil
+}
+
+// UserInfo возвращает claims пользователя в пределах scope access-токена клиента
+func (s *OIDCService) UserInfo(accessToken string) (map[string]any, error) {
+	claims, err := s.users.ValidateClientAccessToken(accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if !hasScope(claims.Scope, "openid") {
+		return nil, ErrInvalidToken
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	user, err := s.users.repo.FindByID(userID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	info := map[string]any{"sub": user.ID.String()}
+	if hasScope(claims.Scope, "profile") {
+		info["name"] = user.Name
+	}
+	if hasScope(claims.Scope, "email") {
+		info["email"] = user.Email
+		info["email_verified"] = user.EmailVerified
+	}
+	return info, nil
+}
+
+func (s *OIDCService) idToken(user *models.User, clientID, scope, nonce string) (string, error) {
+	now := time.Now()
+	claims := jwt.IDTokenClaims{
+		Nonce: nonce,
+		RegisteredClaims: gojwt.RegisteredClaims{
+			Issuer:    s.cfg.Issuer,
+			Subject:   user.ID.String(),
+			Audience:  gojwt.ClaimStrings{clientID},
+			ExpiresAt: gojwt.NewNumericDate(now.Add(s.users.Cfg.JWT.AccessExp)),
+			IssuedAt:  gojwt.NewNumericDate(now),
+		},
+	}
+	if hasScope(scope, "profile") {
+		claims.Name = user.Name
+	}
+	if hasScope(scope, "email") {
+		verified := user.EmailVerified
+		claims.Email = user.Email
+		claims.EmailVerified = &verified
+	}
+	return jwt.GenerateIDToken(claims, s.users.AccessKeys)
+}
+
+// authenticateClient проверяет секрет конфиденциального клиента; публичный клиент секрета не передаёт
+func (s *OIDCService) authenticateClient(clientID, secret string) (*models.OIDCClient, error) {
+	client, err := s.repo.FindClient(clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidClient
+		}
+		return nil, err
+	}
+	if client.SecretHash == "" {
+		if secret != "" {
+			return nil, ErrInvalidClient
+		}
+		return client, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(client.SecretHash)) != 1 {
+		return nil, ErrInvalidClient
+	}
+	return client, nil
+}
+
+// RegisterClient регистрирует клиента. Секрет возвращается один раз; у публичного клиента его нет.
+func (s *OIDCService) RegisterClient(name string, redirectURIs []string, public bool) (*models.OIDCClient, string, error) {
+	if name == "" || len(redirectURIs) == 0 {
+		return nil, "", fmt.Errorf("%w: name and at least one redirect uri are required", ErrInvalidClient)
+	}
+	for _, uri := range redirectURIs {
+		if err := validateRedirectURI(uri); err != nil {
+			return nil, "", err
+		}
+	}
+	clientID, err := randomToken(16)
+	if err != nil {
+		return nil, "", err
+	}
+	client := &models.OIDCClient{
+		ClientID:     clientID,
+		Name:         name,
+		RedirectURIs: redirectURIs,
+	}
+	var secret string
+	if !public {
+		if secret, err = randomToken(32); err != nil {
+			return nil, "", err
+		}
+		client.SecretHash = hashSecret(secret)
+	}
+	if err := s.repo.CreateClient(client); err != nil {
+		return nil, "", err
+	}
+	s.log.Info("Зарегистрирован OIDC-клиент", zap.String("client_id", client.ClientID), zap.String("name", name))
+	return client, secret, nil
+}
+
+func (s *OIDCService) ListClients() ([]models.OIDCClient, error) {
+	return s.repo.ListClients()
+}
+
+// DeleteClient удаляет клиента; выданные ему refresh-токены перестают обмениваться
+func (s *OIDCService) DeleteClient(clientID string) error {
+	n, err := s.repo.DeleteClient(clientID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrInvalidClient
+	}
+	return nil
+}
+
+// validateRedirectURI допускает абсолютные https-адреса без фрагмента и http только для loopback (RFC 8252)
+func validateRedirectURI(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || u.Fragment != "" {
+		return fmt.Errorf("%w: %q", ErrInvalidRedirectURI, raw)
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if host := u.Hostname(); host == "localhost" || host == "127.0.0.1" || host == "::1" {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q must use https", ErrInvalidRedirectURI, raw)
+}
+
+// normalizeScope убирает повторы и проверяет, что все scope известны и есть openid
+func normalizeScope(scope string) (string, error) {
+	seen := map[string]bool{}
+	for _, sc := range strings.Fields(scope) {
+		seen[sc] = true
+	}
+	if !seen["openid"] {
+		return "", fmt.Errorf("%w: openid scope is required", ErrInvalidScope)
+	}
+	granted := make([]string, 0, len(seen))
+	for _, sc := range OIDCScopes {
+		if seen[sc] {
+			granted = append(granted, sc)
+			delete(seen, sc)
+		}
+	}
+	for sc := range seen {
+		return "", fmt.Errorf("%w: %q", ErrInvalidScope, sc)
+	}
+	return strings.Join(granted, " "), nil
+}
+
+func hasScope(scope, want string) bool {
+	for _, sc := range strings.Fields(scope) {
+		if sc == want {
+			return true
+		}
+	}
+	return false
+}
+
+func randomToken(n int) (string, error) {
+	raw := make([]byte, n)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// verifyPKCE сравнивает BASE64URL(SHA256(verifier)) с code_challenge (RFC 7636 §4.6)
+func verifyPKCE(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return subtle.ConstantTimeCompare([]byte(base64.RawURLEncoding.EncodeToString(sum[:])), []byte(challenge)) == 1
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"auth-service/internal/jwt"
+	"auth-service/internal/models"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+)
+
+const testRedirectURI = "https://app.example.com/callback"
+
+// pkcePair возвращает code_verifier и code_challenge для метода S256
+func pkcePair(t *testing.T) (verifier, challenge string) {
+	t.Helper()
+	verifier, err := randomToken(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// registerOIDCClient регистрирует клиента с одним redirect_uri; у публичного секрет пустой
+func registerOIDCClient(t *testing.T, env *testEnv, public bool) (clientID, secret string) {
+	t.Helper()
+	client, secret, err := env.oidc.RegisterClient("Test app", []string{testRedirectURI}, public)
+	if err != nil {
+		t.Fatalf("RegisterClient: %v", err)
+	}
+	return client.ClientID, secret
+}
+
+// loginAccessToken регистрирует пользователя и возвращает его access-токен LinkVault
+func loginAccessToken(t *testing.T, env *testEnv, email string) (*models.User, string) {
+	t.Helper()
+	user := registerUser(t, env, email)
+	access, _, err := env.users.Login(email, testPassword, testClient)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	return user, access
+}
+
+func authorizeRequest(clientID, challenge string) AuthorizeRequest {
+	return AuthorizeRequest{
+		ClientID:            clientID,
+		RedirectURI:         testRedirectURI,
+		ResponseType:        "code",
+		Scope:               "openid email",
+		State:               "state-1",
+		Nonce:               "nonce-1",
+		CodeChallenge:       challenge,
+		CodeChallengeMethod: "S256",
+	}
+}
+
+// authorize выдаёт код авторизации клиенту clientID и возвращает его вместе с code_verifier
+func authorize(t *testing.T, env *testEnv, access, clientID string) (code, verifier string) {
+	t.Helper()
+	verifier, challenge := pkcePair(t)
+	code, err := env.oidc.Authorize(access, authorizeRequest(clientID, challenge))
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	return code, verifier
+}
+
+func TestOIDCRedirectURIExactMatch(t *testing.T) {
+	env := newTestEnv(t)
+	clientID, _ := registerOIDCClient(t, env, true)
+
+	cases := []struct {
+		name, clientID, redirectURI string
+		want                        error
+	}{
+		{"exact", clientID, testRedirectURI, nil},
+		{"trailing slash", clientID, testRedirectURI + "/", ErrInvalidRedirectURI},
+		{"extra path", clientID, testRedirectURI + "/evil", ErrInvalidRedirectURI},
+		{"extra query", clientID, testRedirectURI + "?next=https://evil.example", ErrInvalidRedirectURI},
+		{"other host", clientID, "https://app.example.com.evil.example/callback", ErrInvalidRedirectURI},
+		{"uppercase host", clientID, "https://APP.example.com/callback", ErrInvalidRedirectURI},
+		{"http downgrade", clientID, "http://app.example.com/callback", ErrInvalidRedirectURI},
+		{"unknown client", "unknown", testRedirectURI, ErrInvalidClient},
+	}
+	for _, tc := range cases {
+		_, err := env.oidc.CheckRedirect(tc.clientID, tc.redirectURI)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: CheckRedirect = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestOIDCAuthorizeRequiresS256(t *testing.T) {
+	env := newTestEnv(t)
+	clientID, _ := registerOIDCClient(t, env, true)
+	_, access := loginAccessToken(t, env, "oidc-s256@example.com")
+	verifier, challenge := pkcePair(t)
+
+	cases := []struct {
+		name              string
+		challenge, method string
+	}{
+		{"no challenge", "", ""},
+		{"no method", challenge, ""},
+		{"plain", verifier, "plain"},
+	}
+	for _, tc := range cases {
+		req := authorizeRequest(clientID, tc.challenge)
+		req.CodeChallengeMethod = tc.method
+		if _, err := env.oidc.Authorize(access, req); !errors.Is(err, ErrPKCERequired) {
+			t.Errorf("%s: Authorize = %v, want ErrPKCERequired", tc.name, err)
+		}
+	}
+}
+
+func TestOIDCExchangeCodePKCE(t *testing.T) {
+	env := newTestEnv(t)
+	clientID, _ := registerOIDCClient(t, env, true)
+	_, access := loginAccessToken(t, env, "oidc-pkce@example.com")
+	other, challenge := pkcePair(t)
+
+	cases := []struct {
+		name     string
+		verifier func(verifier string) string
+	}{
+		{"missing verifier", func(string) string { return "" }},
+		{"other verifier", func(string) string { return other }},
+		{"challenge as verifier", func(string) string { return challenge }},
+		{"truncated verifier", func(v string) string { return v[:len(v)-1] }},
+	}
+	for _, tc := range cases {
+		code, verifier := authorize(t, env, access, clientID)
+		if _, err := env.oidc.ExchangeCode(clientID, "", code, testRedirectURI, tc.verifier(verifier), testClient); !errors.Is(err, ErrInvalidGrant) {
+			t.Errorf("%s: ExchangeCode = %v, want ErrInvalidGrant", tc.name, err)
+		}
+		// Неудачный обмен сжигает код: подобрать verifier повторными попытками нельзя
+		if _, err := env.oidc.ExchangeCode(clientID, "", code, testRedirectURI, verifier, testClient); !errors.Is(err, ErrInvalidGrant) {
+			t.Errorf("%s: ExchangeCode after failure = %v, want ErrInvalidGrant", tc.name, err)
+		}
+	}
+}
+
+func TestOIDCExchangeCodeSingleUseAndExpiry(t *testing.T) {
+	env := newTestEnv(t)
+	clientID, _ := registerOIDCClient(t, env, true)
+	_, access := loginAccessToken(t, env, "oidc-code@example.com")
+
+	code, verifier := authorize(t, env, access, clientID)
+	if _, err := env.oidc.ExchangeCode(clientID, "", code, testRedirectURI, verifier, testClient); err != nil {
+		t.Fatalf("ExchangeCode: %v", err)
+	}
+	if _, err := env.oidc.ExchangeCode(clientID, "", code, testRedirectURI, verifier, testClient); !errors.Is(err, ErrInvalidGrant) {
+		t.Fatalf("ExchangeCode reused = %v, want ErrInvalidGrant", err)
+	}
+
+	code, verifier = authorize(t, env, access, clientID)
+	err := env.db.Model(&models.OIDCAuthCode{}).
+		Where("code_hash = ?", hashSecret(code)).
+		Update("expires_at", time.Now().Add(-time.Second)).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.oidc.ExchangeCode(clientID, "", code, testRedirectURI, verifier, testClient); !errors.Is(err, ErrInvalidGrant) {
+		t.Fatalf("ExchangeCode expired = %v, want ErrInvalidGrant", err)
+	}
+
+	// Код привязан к redirect_uri запроса авторизации
+	code, verifier = authorize(t, env, access, clientID)
+	if _, err := env.oidc.ExchangeCode(clientID, "", code, testRedirectURI+"/other", verifier, testClient); !errors.Is(err, ErrInvalidGrant) {
+		t.Fatalf("ExchangeCode with other redirect_uri = %v, want ErrInvalidGrant", err)
+	}
+}
+
+func TestOIDCClientAuthentication(t *testing.T) {
+	env := newTestEnv(t)
+	publicID, _ := registerOIDCClient(t, env, true)
+	confidentialID, secret := registerOIDCClient(t, env, false)
+	_, access := loginAccessToken(t, env, "oidc-client@example.com")
+
+	cases := []struct {
+		name string
+		// Клиент, которому выдан код, и клиент, который его обменивает
+		issuedTo, clientID, secret string
+		want                       error
+	}{
+		{"public without secret", publicID, publicID, "", nil},
+		{"public with secret", publicID, publicID, "guess", ErrInvalidClient},
+		{"confidential with secret", confidentialID, confidentialID, secret, nil},
+		{"confidential without secret", confidentialID, confidentialID, "", ErrInvalidClient},
+		{"confidential with wrong secret", confidentialID, confidentialID, secret + "x", ErrInvalidClient},
+		{"unknown client", publicID, "unknown", "", ErrInvalidClient},
+		{"code of another client", confidentialID, publicID, "", ErrInvalidGrant},
+	}
+	for _, tc := range cases {
+		code, verifier := authorize(t, env, access, tc.issuedTo)
+		_, err := env.oidc.ExchangeCode(tc.clientID, tc.secret, code, testRedirectURI, verifier, testClient)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: ExchangeCode = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestOIDCIDTokenClaims(t *testing.T) {
+	env := newTestEnv(t)
+	clientID, _ := registerOIDCClient(t, env, true)
+	user, access := loginAccessToken(t, env, "oidc-id@example.com")
+
+	code, verifier := authorize(t, env, access, clientID)
+	tokens, err := env.oidc.ExchangeCode(clientID, "", code, testRedirectURI, verifier, testClient)
+	if err != nil {
+		t.Fatalf("ExchangeCode: %v", err)
+	}
+	if tokens.Scope != "openid email" {
+		t.Errorf("scope = %q, want %q", tokens.Scope, "openid email")
+	}
+
+	claims := &jwt.IDTokenClaims{}
+	_, err = gojwt.ParseWithClaims(tokens.IDToken, claims, func(token *gojwt.Token) (interface{}, error) {
+		key, err := env.users.AccessKeys.VerifyKey(token.Header["kid"].(string))
+		if err != nil {
+			return nil, err
+		}
+		return key.Verify, nil
+	}, gojwt.WithIssuer(env.cfg.OIDC.Issuer), gojwt.WithAudience(clientID), gojwt.WithExpirationRequired())
+	if err != nil {
+		t.Fatalf("parse ID token: %v", err)
+	}
+	if claims.Subject != user.ID.String() {
+		t.Errorf("sub = %q, want %q", claims.Subject, user.ID)
+	}
+	if len(claims.Audience) != 1 {
+		t.Errorf("aud = %v, want only %q", claims.Audience, clientID)
+	}
+	if claims.Nonce != "nonce-1" {
+		t.Errorf("nonce = %q, want %q", claims.Nonce, "nonce-1")
+	}
+	if claims.Email != user.Email || claims.EmailVerified == nil {
+		t.Errorf("email = %q (verified %v), want %q", claims.Email, claims.EmailVerified, user.Email)
+	}
+	if claims.Name != "" {
+		t.Errorf("name = %q without profile scope", claims.Name)
+	}
+}
+
+func TestOIDCUserInfoRequiresClientAccessToken(t *testing.T) {
+	env := newTestEnv(t)
+	clientID, _ := registerOIDCClient(t, env, true)
+	user, access := loginAccessToken(t, env, "oidc-userinfo@example.com")
+
+	if _, err := env.oidc.UserInfo(access); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("UserInfo with LinkVault access token = %v, want ErrInvalidToken", err)
+	}
+
+	code, verifier := authorize(t, env, access, clientID)
+	tokens, err := env.oidc.ExchangeCode(clientID, "", code, testRedirectURI, verifier, testClient)
+	if err != nil {
+		t.Fatalf("ExchangeCode: %v", err)
+	}
+	info, err := env.oidc.UserInfo(tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("UserInfo: %v", err)
+	}
+	if info["sub"] != user.ID.String() || info["email"] != user.Email {
+		t.Errorf("UserInfo = %v", info)
+	}
+	if _, ok := info["name"]; ok {
+		t.Errorf("UserInfo returned name without profile scope: %v", info)
+	}
+	// Токен клиента не заменяет токен LinkVault
+	if _, err := env.users.ValidateAccessToken(tokens.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("ValidateAccessToken with client token = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestVerifyPKCE(t *testing.T) {
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	// Пример из RFC 7636, приложение B
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	cases := []struct {
+		name, verifier, challenge string
+		want                      bool
+	}{
+		{"rfc example", verifier, challenge, true},
+		{"empty verifier", "", challenge, false},
+		{"verifier equals challenge", challenge, challenge, false},
+		{"other verifier", verifier[:42] + "Y", challenge, false},
+		{"too short", verifier[:42], challenge, false},
+		{"empty challenge", verifier, "", false},
+	}
+	for _, tc := range cases {
+		if got := verifyPKCE(tc.verifier, tc.challenge); got != tc.want {
+			t.Errorf("%s: verifyPKCE = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
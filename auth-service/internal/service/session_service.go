@@ -0,0 +1,101 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// ClientInfo — сведения об устройстве, которые сохраняются вместе с refresh-токеном
+type ClientInfo struct {
+	UserAgent  string
+	IP         string
+	ClientName string
+	// OAuth-клиент (OIDC), которому выдаётся сессия, и разрешённые ему scope
+	ClientID string
+	Scope    string
+}
+
+// Session — активная сессия пользователя (семейство refresh-токенов)
+type Session struct {
+	ID         uuid.UUID
+	UserAgent  string
+	IP         string
+	ClientName string
+	CreatedAt  time.Time
+	// Последний вход или обновление токенов
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+	// Сессия, в которой выдан access-токен запроса
+	Current bool
+}
+
+// ListSessions возвращает активные сессии пользователя, последние использованные первыми
+func (s *UserService) ListSessions(userID, currentID uuid.UUID) ([]Session, error) {
+	tokens, err := s.rtRepo.ListActiveByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]Session, 0, len(tokens))
+	for i := range tokens {
+		rt := &tokens[i]
+		id := tokenFamily(rt)
+		createdAt := rt.CreatedAt
+		if rt.SessionStartedAt != nil {
+			createdAt = *rt.SessionStartedAt
+		}
+		sessions = append(sessions, Session{
+			ID:         id,
+			UserAgent:  rt.UserAgent,
+			IP:         rt.IP,
+			ClientName: rt.ClientName,
+			CreatedAt:  createdAt,
+			LastUsedAt: rt.CreatedAt,
+			ExpiresAt:  rt.ExpiresAt,
+			Current:    id == currentID,
+		})
+	}
+	return sessions, nil
+}
+
+// RevokeSession завершает одну сессию пользователя вместе с уже выданными в ней access-токенами
+func (s *UserService) RevokeSession(userID, sessionID uuid.UUID) error {
+	return s.tx.Do(func(tx *gorm.DB) error {
+		n, err := s.rtRepo.WithTx(tx).RevokeSession(userID, sessionID)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return ErrSessionNotFound
+		}
+		return s.revokeSessionAccess(tx, userID, []uuid.UUID{sessionID})
+	})
+}
+
+// RevokeOtherSessions завершает все сессии пользователя, кроме текущей, и возвращает их число
+func (s *UserService) RevokeOtherSessions(userID, currentID uuid.UUID) (int64, error) {
+	if currentID == uuid.Nil {
+		return 0, ErrSessionNotFound
+	}
+	var sessions []uuid.UUID
+	err := s.tx.Do(func(tx *gorm.DB) error {
+		var err error
+		if sessions, err = s.rtRepo.WithTx(tx).RevokeOtherSessions(userID, currentID); err != nil {
+			return err
+		}
+		return s.revokeSessionAccess(tx, userID, sessions)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(sessions)), nil
+}
+
+// revokeSessionAccess отзывает access-токены сессий: они действуют не дольше ACCESS_EXP от текущего момента
+func (s *UserService) revokeSessionAccess(tx *gorm.DB, userID uuid.UUID, sessionIDs []uuid.UUID) error {
+	return s.revocationRepo.WithTx(tx).RevokeSessions(userID, sessionIDs, time.Now().Add(s.Cfg.JWT.AccessExp))
+}
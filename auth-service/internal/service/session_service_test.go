@@ -0,0 +1,105 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"auth-service/internal/jwt"
+
+	"github.com/google/uuid"
+)
+
+// testSession — сессия пользователя и выданная в ней пара токенов
+type testSession struct {
+	id              uuid.UUID
+	access, refresh string
+}
+
+func openSession(t *testing.T, env *testEnv, email string) testSession {
+	t.Helper()
+	access, refresh := loginTokens(t, env, email)
+	claims, err := jwt.ParseAccessToken(access, env.users.AccessKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testSession{id: uuid.MustParse(claims.SessionID), access: access, refresh: refresh}
+}
+
+// assertSessionRevoked проверяет, что не действуют ни access-, ни refresh-токен сессии
+func assertSessionRevoked(t *testing.T, env *testEnv, name string, s testSession, revoked bool) {
+	t.Helper()
+	_, accessErr := env.users.ValidateAccessToken(s.access)
+	_, _, refreshErr := env.users.Refresh(s.refresh, testClient)
+	if revoked && (!errors.Is(accessErr, ErrTokenRevoked) || !errors.Is(refreshErr, ErrInvalidToken)) {
+		t.Errorf("%s: access = %v, refresh = %v, want both revoked", name, accessErr, refreshErr)
+	}
+	if !revoked && (accessErr != nil || refreshErr != nil) {
+		t.Errorf("%s: access = %v, refresh = %v, want session untouched", name, accessErr, refreshErr)
+	}
+}
+
+func TestRevokeSessionRevokesAccessTokens(t *testing.T) {
+	env := newTestEnv(t)
+	const email = "revoke-session@example.com"
+	user := registerUser(t, env, email)
+	revoked := openSession(t, env, email)
+	kept := openSession(t, env, email)
+
+	if err := env.users.RevokeSession(user.ID, revoked.id); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	assertSessionRevoked(t, env, "revoked session", revoked, true)
+	assertSessionRevoked(t, env, "other session", kept, false)
+
+	_, sessions, _, err := env.users.ActiveRevocations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].SessionID != revoked.id {
+		t.Errorf("revocation feed sessions = %+v, want %s", sessions, revoked.id)
+	}
+
+	other := registerUser(t, env, "revoke-session-other@example.com")
+	cases := []struct {
+		name              string
+		userID, sessionID uuid.UUID
+	}{
+		{"already revoked", user.ID, revoked.id},
+		{"unknown session", user.ID, uuid.New()},
+		{"session of other user", other.ID, kept.id},
+	}
+	for _, tc := range cases {
+		if err := env.users.RevokeSession(tc.userID, tc.sessionID); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("%s: RevokeSession = %v, want ErrSessionNotFound", tc.name, err)
+		}
+	}
+	// Неудачная попытка не должна отзывать токены чужой сессии
+	if _, err := env.users.ValidateAccessToken(kept.access); err != nil {
+		t.Errorf("session after foreign RevokeSession = %v", err)
+	}
+}
+
+func TestRevokeOtherSessionsRevokesAccessTokens(t *testing.T) {
+	env := newTestEnv(t)
+	const email = "revoke-others@example.com"
+	user := registerUser(t, env, email)
+	first := openSession(t, env, email)
+	second := openSession(t, env, email)
+	current := openSession(t, env, email)
+
+	other := registerUser(t, env, "revoke-others-other@example.com")
+	foreign := openSession(t, env, other.Email)
+
+	n, err := env.users.RevokeOtherSessions(user.ID, current.id)
+	if err != nil || n != 2 {
+		t.Fatalf("RevokeOtherSessions = %d, %v, want 2", n, err)
+	}
+	assertSessionRevoked(t, env, "first session", first, true)
+	assertSessionRevoked(t, env, "second session", second, true)
+	assertSessionRevoked(t, env, "current session", current, false)
+	assertSessionRevoked(t, env, "other user's session", foreign, false)
+
+	if _, err := env.users.RevokeOtherSessions(user.ID, uuid.Nil); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("RevokeOtherSessions without current session = %v, want ErrSessionNotFound", err)
+	}
+}
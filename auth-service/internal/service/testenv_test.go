@@ -0,0 +1,102 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"auth-service/config"
+	"auth-service/internal/jwt"
+	"auth-service/internal/repository"
+	"auth-service/internal/storage/testdb"
+	"auth-service/internal/totp"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// testEnv — сервисы поверх настоящих репозиториев в отдельной схеме PostgreSQL (см. testdb)
+type testEnv struct {
+	db    *gorm.DB
+	cfg   *config.Config
+	users *UserService
+	mfa   *MFAService
+	oidc  *OIDCService
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	db := testdb.Open(t)
+	cfg := &config.Config{
+		JWT:        config.JWTConfig{AccessExp: 15 * time.Minute, RefreshExp: 24 * time.Hour, NotifyReuse: true},
+		KafkaTopic: "emails.send",
+		MFA:        config.MFAConfig{Issuer: "LinkVault", ChallengeTTL: 5 * time.Minute, MaxAttempts: 5},
+		OIDC:       config.OIDCConfig{Issuer: "https://auth.linkvault.test", LoginURL: "https://app.linkvault.test/login", CodeTTL: time.Minute},
+		// Без пауз: тестам нужны только счётчики и блокировка
+		Login: config.LoginGuardConfig{
+			DelayAfter:    100,
+			DelayMax:      time.Second,
+			MaxFailures:   3,
+			IPMaxFailures: 100,
+			Lockout:       15 * time.Minute,
+			FailureWindow: time.Hour,
+		},
+	}
+
+	accessKeys, refreshKeys := testKeyRings(t)
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	cipher, err := totp.NewCipher(base64.StdEncoding.EncodeToString(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := zap.NewNop()
+	tx := repository.NewTransactor(db)
+	userRepo := repository.NewUserRepository(db)
+	mfa := NewMFAService(repository.NewMFARepository(db), userRepo, tx, cipher, cfg.MFA, log)
+	guard := NewLoginGuard(repository.NewLoginThrottleRepository(db), cfg.Login, log)
+	users := NewUserService(
+		userRepo,
+		repository.NewRefreshTokenRepository(db),
+		repository.NewEmailVerificationTokenRepository(db),
+		repository.NewPasswordResetTokenRepository(db),
+		repository.NewMagicLinkTokenRepository(db),
+		repository.NewOutboxRepository(db),
+		repository.NewAccessRevocationRepository(db),
+		mfa, guard, tx, accessKeys, refreshKeys, cfg, log,
+	)
+	oidc := NewOIDCService(repository.NewOIDCRepository(db), users, cfg.OIDC, log)
+	return &testEnv{db: db, cfg: cfg, users: users, mfa: mfa, oidc: oidc}
+}
+
+// testKeyRings — связки с ключом access из временного файла и одним секретом refresh
+func testKeyRings(t *testing.T) (access, refresh *jwt.KeyRing) {
+	t.Helper()
+	k, err := jwt.GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pem, err := jwt.MarshalSigningKey(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "access.pem")
+	if err := os.WriteFile(file, pem, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	access, _, err = jwt.NewAccessRing(file, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refresh, err = jwt.NewRefreshRing("test-refresh-secret", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return access, refresh
+}
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"auth-service/internal/models"
+	"auth-service/internal/repository"
+	"auth-service/internal/webauthn"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrWebAuthnUnavailable = errors.New("webauthn is not configured")
+	ErrWebAuthnFailed      = errors.New("webauthn verification failed")
+	ErrCredentialNotFound  = errors.New("credential not found")
+)
+
+// WebAuthnService — регистрация ключей доступа и вход по ним без пароля.
+// Ключ с проверкой пользователя (PIN, биометрия) уже двухфакторный, поэтому TOTP при таком входе не запрашивается.
+type WebAuthnService struct {
+	repo     *repository.WebAuthnRepository
+	userRepo *repository.UserRepository
+	users    *UserService
+	// nil, если WEBAUTHN_RP_ID не задан
+	rp  *webauthn.RelyingParty
+	ttl time.Duration
+	log *zap.Logger
+}
+
+func NewWebAuthnService(repo *repository.WebAuthnRepository, userRepo *repository.UserRepository, users *UserService, rp *webauthn.RelyingParty, ttl time.Duration, log *zap.Logger) *WebAuthnService {
+	return &WebAuthnService{repo: repo, userRepo: userRepo, users: users, rp: rp, ttl: ttl, log: log}
+}
+
+// BeginRegistration выдаёт параметры для navigator.credentials.create авторизованному пользователю
+func (s *WebAuthnService) BeginRegistration(userID uuid.UUID) (*webauthn.CreationOptions, error) {
+	if s.rp == nil {
+		return nil, ErrWebAuthnUnavailable
+	}
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	existing, err := s.credentialIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+	challenge, err := s.startCeremony(models.WebAuthnRegistration, &userID)
+	if err != nil {
+		return nil, err
+	}
+
+	params := make([]webauthn.CredentialParameter, 0, len(webauthn.SupportedAlgorithms))
+	for _, alg := range webauthn.SupportedAlgorithms {
+		params = append(params, webauthn.CredentialParameter{Type: "public-key", Alg: alg})
+	}
+	return &webauthn.CreationOptions{
+		RP:                 webauthn.RPEntity{ID: s.rp.ID, Name: s.rp.Name},
+		User:               webauthn.UserEntity{ID: userID[:], Name: user.Email, DisplayName: user.Name},
+		Challenge:          challenge,
+		PubKeyCredParams:   params,
+		Timeout:            s.ttl.Milliseconds(),
+		ExcludeCredentials: webauthn.Descriptors(existing),
+		AuthenticatorSelection: webauthn.AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: "required",
+		},
+		Attestation: "none",
+	}, nil
+}
+
+// FinishRegistration проверяет ответ аутентификатора и сохраняет ключ под именем name
+func (s *WebAuthnService) FinishRegistration(userID uuid.UUID, name string, clientDataJSON, attestationObject []byte) (*models.WebAuthnCredential, error) {
+	if s.rp == nil {
+		return nil, ErrWebAuthnUnavailable
+	}
+	ceremony, err := s.takeCeremony(models.WebAuthnRegistration, clientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	if ceremony.UserID == nil || *ceremony.UserID != userID {
+		return nil, ErrInvalidToken
+	}
+	cred, err := s.rp.VerifyRegistration(ceremony.Challenge, clientDataJSON, attestationObject, true)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrWebAuthnFailed, err)
+	}
+	stored := &models.WebAuthnCredential{
+		UserID:       userID,
+		CredentialID: cred.ID,
+		PublicKey:    cred.PublicKey,
+		Algorithm:    cred.Algorithm,
+		SignCount:    int64(cred.SignCount),
+		AAGUID:       cred.AAGUID,
+		Name:         name,
+	}
+	if err := s.repo.CreateCredential(stored); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// BeginLogin выдаёт параметры для navigator.credentials.get. Без email список ключей пуст:
+// браузер предложит сохранённые passkeys, а пользователь определится по ключу.
+func (s *WebAuthnService) BeginLogin(email string) (*webauthn.RequestOptions, error) {
+	if s.rp == nil {
+		return nil, ErrWebAuthnUnavailable
+	}
+	var userID *uuid.UUID
+	var allowed [][]byte
+	if email != "" {
+		user, err := s.userRepo.FindByEmail(email)
+		if err != nil {
+			return nil, ErrUserNotFound
+		}
+		if allowed, err = s.credentialIDs(user.ID); err != nil {
+			return nil, err
+		}
+		if len(allowed) == 0 {
+			return nil, ErrCredentialNotFound
+		}
+		userID = &user.ID
+	}
+	challenge, err := s.startCeremony(models.WebAuthnLogin, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &webauthn.RequestOptions{
+		Challenge:        challenge,
+		Timeout:          s.ttl.Milliseconds(),
+		RPID:             s.rp.ID,
+		AllowCredentials: webauthn.Descriptors(allowed),
+		UserVerification: "required",
+	}, nil
+}
+
+// FinishLogin проверяет подпись аутентификатора и выдаёт ту же пару токенов, что и UserService.Login
+func (s *WebAuthnService) FinishLogin(credentialID, clientDataJSON, authenticatorData, signature, userHandle []byte, client ClientInfo) (access, refresh string, err error) {
+	if s.rp == nil {
+		return "", "", ErrWebAuthnUnavailable
+	}
+	ceremony, err := s.takeCeremony(models.WebAuthnLogin, clientDataJSON)
+	if err != nil {
+		return "", "", err
+	}
+	stored, err := s.repo.FindCredential(credentialID)
+	if err != nil {
+		return "", "", ErrCredentialNotFound
+	}
+	if ceremony.UserID != nil && *ceremony.UserID != stored.UserID {
+		return "", "", ErrCredentialNotFound
+	}
+	// userHandle — это user.id из регистрации; для passkeys он обязан совпасть с владельцем ключа
+	if len(userHandle) > 0 && !bytes.Equal(userHandle, stored.UserID[:]) {
+		return "", "", ErrCredentialNotFound
+	}
+
+	cred := webauthn.Credential{
+		ID:        stored.CredentialID,
+		PublicKey: stored.PublicKey,
+		Algorithm: stored.Algorithm,
+		SignCount: uint32(stored.SignCount),
+	}
+	count, err := s.rp.VerifyAssertion(ceremony.Challenge, cred, clientDataJSON, authenticatorData, signature, true)
+	if errors.Is(err, webauthn.ErrSignCount) {
+		s.log.Warn("Счётчик подписей WebAuthn не вырос: возможна копия ключа",
+			zap.String("user_id", stored.UserID.String()),
+			zap.String("credential", stored.ID.String()),
+		)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %w", ErrWebAuthnFailed, err)
+	}
+	updated, err := s.repo.UpdateSignCount(stored.ID, stored.SignCount, int64(count))
+	if err != nil {
+		return "", "", err
+	}
+	if !updated {
+		return "", "", fmt.Errorf("%w: %w", ErrWebAuthnFailed, webauthn.ErrSignCount)
+	}
+	return s.users.issueSession(stored.UserID, client)
+}
+
+func (s *WebAuthnService) ListCredentials(userID uuid.UUID) ([]models.WebAuthnCredential, error) {
+	return s.repo.ListCredentials(userID)
+}
+
+func (s *WebAuthnService) DeleteCredential(userID, id uuid.UUID) error {
+	n, err := s.repo.DeleteCredential(userID, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrCredentialNotFound
+	}
+	return nil
+}
+
+func (s *WebAuthnService) credentialIDs(userID uuid.UUID) ([][]byte, error) {
+	creds, err := s.repo.ListCredentials(userID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([][]byte, 0, len(creds))
+	for _, c := range creds {
+		ids = append(ids, c.CredentialID)
+	}
+	return ids, nil
+}
+
+func (s *WebAuthnService) startCeremony(kind string, userID *uuid.UUID) ([]byte, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return nil, err
+	}
+	err = s.repo.CreateCeremony(&models.WebAuthnCeremony{
+		Challenge: challenge,
+		Kind:      kind,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(s.ttl),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// takeCeremony находит церемонию по challenge из clientDataJSON и гасит её
+func (s *WebAuthnService) takeCeremony(kind string, clientDataJSON []byte) (*models.WebAuthnCeremony, error) {
+	challenge, err := webauthn.ChallengeOf(clientDataJSON)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrWebAuthnFailed, err)
+	}
+	ceremony, err := s.repo.TakeCeremony(challenge, kind)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return ceremony, nil
+}
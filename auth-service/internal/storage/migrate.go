@@ -13,8 +13,43 @@ func Migrate(db *gorm.DB, log *zap.Logger) {
 		&models.RefreshToken{},
 		&models.EmailVerificationToken{},
 		&models.PasswordResetToken{},
+		&models.MagicLinkToken{},
+		&models.OutboxMessage{},
+		&models.SigningKeyState{},
+		&models.RevokedAccessToken{},
+		&models.AccessTokenCutoff{},
+		&models.RevokedSession{},
+		&models.UserTOTP{},
+		&models.RecoveryCode{},
+		&models.MFAChallenge{},
+		&models.WebAuthnCredential{},
+		&models.WebAuthnCeremony{},
+		&models.OAuthState{},
+		&models.ExternalIdentity{},
+		&models.OIDCClient{},
+		&models.OIDCAuthCode{},
+		&models.LoginThrottle{},
 	); err != nil {
 		log.Fatal("Не удалось выполнить миграцию базы данных", zap.Error(err))
 	}
+	normalizeEmails(db, log)
 	log.Info("Миграция базы данных успешно выполнена")
 }
+
+// normalizeEmails приводит адреса, сохранённые до models.NormalizeEmail, к нижнему регистру.
+// Адреса, которые после этого совпали бы с адресом другого пользователя, не меняются: такие аккаунты нужно объединить вручную.
+func normalizeEmails(db *gorm.DB, log *zap.Logger) {
+	res := db.Exec(`UPDATE users u SET email = lower(trim(u.email))
+		WHERE u.email <> lower(trim(u.email))
+		AND NOT EXISTS (SELECT 1 FROM users o WHERE o.id <> u.id AND lower(trim(o.email)) = lower(trim(u.email)))`)
+	if res.Error != nil {
+		log.Fatal("Не удалось нормализовать email пользователей", zap.Error(res.Error))
+	}
+	var conflicts int64
+	if err := db.Model(&models.User{}).Where("email <> lower(trim(email))").Count(&conflicts).Error; err != nil {
+		log.Fatal("Не удалось нормализовать email пользователей", zap.Error(err))
+	}
+	if conflicts > 0 {
+		log.Warn("Есть пользователи с адресами, отличающимися только регистром: они не найдутся по email", zap.Int64("count", conflicts))
+	}
+}
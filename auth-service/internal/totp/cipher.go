@@ -0,0 +1,54 @@
+package totp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+var ErrCiphertext = errors.New("malformed ciphertext")
+
+// Cipher шифрует секреты TOTP для хранения в БД (AES-256-GCM, nonce в начале шифротекста)
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher принимает ключ из 32 байт в base64
+func NewCipher(keyB64 string) (*Cipher, error) {
+	key, err := base64.StdEncoding.DecodeString(keyB64)
+	if err != nil {
+		return nil, fmt.Errorf("decode mfa key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("mfa key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// Seal шифрует plaintext; aad привязывает шифротекст к владельцу, чтобы его нельзя было переставить другому пользователю
+func (c *Cipher) Seal(plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func (c *Cipher) Open(ciphertext, aad []byte) ([]byte, error) {
+	n := c.aead.NonceSize()
+	if len(ciphertext) < n {
+		return nil, ErrCiphertext
+	}
+	return c.aead.Open(nil, ciphertext[:n], ciphertext[n:], aad)
+}
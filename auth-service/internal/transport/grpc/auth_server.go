@@ -1,15 +1,19 @@
 package grpc
 
 import (
-	"auth-service/internal/jwt"
+	"auth-service/internal/clientip"
 	"auth-service/internal/service"
 	"context"
 	"errors"
+	"math"
+	"strconv"
 
 	authv1 "github.com/Anabol1ks/linkvault-proto/auth/v1"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
@@ -17,11 +21,13 @@ import (
 type AuthServer struct {
 	authv1.UnimplementedAuthServiceServer
 	userService *service.UserService
+	clients     *clientip.Resolver
 }
 
-func NewAuthServer(userService *service.UserService, log *zap.Logger) *AuthServer {
+func NewAuthServer(userService *service.UserService, clients *clientip.Resolver, log *zap.Logger) *AuthServer {
 	return &AuthServer{
 		userService: userService,
+		clients:     clients,
 	}
 }
 
@@ -55,15 +61,24 @@ func (s *AuthServer) Login(ctx context.Context, req *authv1.LoginRequest) (*auth
 		s.userService.Log.Warn("failed", zap.String("op", "Login"), zap.Error(err))
 		return nil, status.Errorf(codes.InvalidArgument, "validation failed: %v", err)
 	}
-	access, refresh, err := s.userService.Login(req.Email, req.Password)
+	access, refresh, err := s.userService.Login(req.Email, req.Password, clientInfo(ctx, s.clients))
 	if err != nil {
+		var mfaErr *service.MFARequiredError
+		var throttled *service.LoginThrottledError
 		switch {
-		case errors.Is(err, service.ErrUserNotFound):
+		case errors.As(err, &mfaErr):
+			// Токен вызова передаётся в trailer: в TokenPair для него нет поля
+			_ = grpc.SetTrailer(ctx, metadata.Pairs("x-mfa-challenge", mfaErr.ChallengeToken))
+			s.userService.Log.Info("mfa required", zap.String("op", "Login"))
+			return nil, status.Error(codes.FailedPrecondition, "mfa required")
+		case errors.Is(err, service.ErrInvalidCredentials):
+			// Один код и текст для неизвестного адреса и неверного пароля — без перечисления пользователей
 			s.userService.Log.Warn("failed", zap.String("op", "Login"), zap.Error(err))
-			return nil, status.Errorf(codes.NotFound, "user not found: %v", err)
-		case errors.Is(err, service.ErrInvalidPassword):
+			return nil, status.Error(codes.Unauthenticated, "invalid email or password")
+		case errors.As(err, &throttled):
 			s.userService.Log.Warn("failed", zap.String("op", "Login"), zap.Error(err))
-			return nil, status.Errorf(codes.Unauthenticated, "invalid password: %v", err)
+			_ = grpc.SetTrailer(ctx, metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds())))))
+			return nil, status.Error(codes.ResourceExhausted, "too many failed login attempts")
 		default:
 			s.userService.Log.Error("failed", zap.String("op", "Login"), zap.Error(err))
 			return nil, status.Errorf(codes.Internal, "internal server error: %v", err)
@@ -82,7 +97,7 @@ func (s *AuthServer) Refresh(ctx context.Context, req *authv1.RefreshRequest) (*
 		s.userService.Log.Warn("failed", zap.String("op", "Refresh"), zap.Error(err))
 		return nil, status.Errorf(codes.InvalidArgument, "validation failed: %v", err)
 	}
-	access, refresh, err := s.userService.Refresh(req.RefreshToken)
+	access, refresh, err := s.userService.Refresh(req.RefreshToken, clientInfo(ctx, s.clients))
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrInvalidToken):
@@ -135,8 +150,12 @@ func (s *AuthServer) Logout(ctx context.Context, req *authv1.LogoutRequest) (*em
 
 func (s *AuthServer) ValidateAccessToken(ctx context.Context, req *authv1.ValidateAccessTokenRequest) (*authv1.ValidateAccessTokenResponse, error) {
 	s.userService.Log.Info("start", zap.String("op", "ValidateAccessToken"))
-	claims, err := jwt.ParseAccessToken(req.AccessToken, s.userService.Cfg.JWT.Access)
+	claims, err := s.userService.ValidateAccessToken(req.AccessToken)
 	if err != nil {
+		if !errors.Is(err, service.ErrInvalidToken) && !errors.Is(err, service.ErrTokenRevoked) {
+			s.userService.Log.Error("failed", zap.String("op", "ValidateAccessToken"), zap.Error(err))
+			return nil, status.Errorf(codes.Internal, "internal server error: %v", err)
+		}
 		s.userService.Log.Warn("failed", zap.String("op", "ValidateAccessToken"), zap.Error(err))
 		return &authv1.ValidateAccessTokenResponse{
 			Valid: false,
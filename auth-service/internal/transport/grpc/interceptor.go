@@ -2,19 +2,21 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"strings"
 
-	"auth-service/config"
-	"auth-service/internal/jwt"
+	"auth-service/internal/clientip"
+	"auth-service/internal/service"
 
 	"github.com/google/uuid"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
-func AuthInterceptor(jwtCfg *config.JWTConfig) grpc.UnaryServerInterceptor {
+func AuthInterceptor(userService *service.UserService) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
 		req interface{},
@@ -33,19 +35,54 @@ func AuthInterceptor(jwtCfg *config.JWTConfig) grpc.UnaryServerInterceptor {
 			return nil, status.Error(codes.Unauthenticated, "missing or invalid token")
 		}
 		tokenStr := strings.TrimPrefix(authHeaders[0], "Bearer ")
-		claims, err := jwt.ParseAccessToken(tokenStr, jwtCfg.Access)
-		if err != nil {
+		claims, err := userService.ValidateAccessToken(tokenStr)
+		if errors.Is(err, service.ErrInvalidToken) || errors.Is(err, service.ErrTokenRevoked) {
 			return nil, status.Error(codes.Unauthenticated, "invalid token")
 		}
+		if err != nil {
+			return nil, status.Error(codes.Internal, "token validation failed")
+		}
 		userID, err := uuid.Parse(claims.UserID)
 		if err != nil {
 			return nil, status.Error(codes.Unauthenticated, "invalid user_id in token")
 		}
 		ctx = context.WithValue(ctx, "user_id", userID)
+		if sessionID, err := uuid.Parse(claims.SessionID); err == nil {
+			ctx = context.WithValue(ctx, "session_id", sessionID)
+		}
 		return handler(ctx, req)
 	}
 }
 
+// clientInfo собирает сведения об устройстве из metadata: user-agent, x-client-name и адрес клиента.
+// Адрес берётся из соединения; x-forwarded-for учитывается, только если соединение пришло от доверенного шлюза.
+func clientInfo(ctx context.Context, clients *clientip.Resolver) service.ClientInfo {
+	var info service.ClientInfo
+	var forwarded string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if vals := md.Get("user-agent"); len(vals) > 0 {
+			info.UserAgent = truncate(vals[0], 512)
+		}
+		if vals := md.Get("x-client-name"); len(vals) > 0 {
+			info.ClientName = truncate(strings.TrimSpace(vals[0]), 128)
+		}
+		// Шлюз может добавить своё значение отдельной записью: учитывается вся цепочка
+		forwarded = strings.Join(md.Get("x-forwarded-for"), ",")
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		info.IP = truncate(clients.Resolve(p.Addr.String(), forwarded), 64)
+	}
+	return info
+}
+
+// truncate обрезает строку до n байт, не разрывая UTF-8 символы
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return strings.ToValidUTF8(s[:n], "")
+	}
+	return s
+}
+
 var authRequiredMethods = map[string]bool{
 	"/auth.v1.AuthService/GetProfile":              true,
 	"/auth.v1.AuthService/Logout":                  true,
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"auth-service/internal/clientip"
+	"auth-service/internal/service"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// api — общие зависимости обработчиков пользовательского HTTP API (/api/...)
+type api struct {
+	users   *service.UserService
+	clients *clientip.Resolver
+	log     *zap.Logger
+}
+
+// authed пропускает запрос только с действительным access-токеном LinkVault и передаёт обработчику
+// пользователя и сессию, в которой выдан токен. Токены OAuth-клиентов (client_access) не принимаются.
+func (a *api) authed(next func(w http.ResponseWriter, r *http.Request, userID, sessionID uuid.UUID)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		claims, err := a.users.ValidateAccessToken(token)
+		if errors.Is(err, service.ErrInvalidToken) || errors.Is(err, service.ErrTokenRevoked) {
+			writeError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+		if err != nil {
+			a.fail(w, r, err)
+			return
+		}
+		userID, err := uuid.Parse(claims.UserID)
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+		// Токены без sid выданы до появления сессий; у них нет текущей сессии
+		sessionID, _ := uuid.Parse(claims.SessionID)
+		next(w, r, userID, sessionID)
+	}
+}
+
+// decodeJSON читает тело запроса не больше 64 KiB; при ошибке сам отвечает 400
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10))
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return false
+	}
+	return true
+}
+
+// pathUUID читает UUID из сегмента пути; при ошибке сам отвечает 400
+func pathUUID(w http.ResponseWriter, r *http.Request, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue(name))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid "+name)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// fail отвечает 500 и пишет ошибку в лог
+func (a *api) fail(w http.ResponseWriter, r *http.Request, err error) {
+	a.log.Error("HTTP request failed", zap.String("method", r.Method), zap.String("path", r.URL.Path), zap.Error(err))
+	writeError(w, http.StatusInternalServerError, "internal error")
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// writeTokens отвечает парой токенов в том же виде, что TokenPair в gRPC
+func writeTokens(w http.ResponseWriter, access, refresh string) {
+	writeJSON(w, http.StatusOK, map[string]string{"access_token": access, "refresh_token": refresh})
+}
+
+// writeMFAChallenge отвечает 403 с токеном вызова, если вход требует второго фактора.
+// Клиент завершает вход через POST /api/mfa/verify — так же, как после x-mfa-challenge в gRPC Login.
+func writeMFAChallenge(w http.ResponseWriter, err error) bool {
+	var mfaErr *service.MFARequiredError
+	if !errors.As(err, &mfaErr) {
+		return false
+	}
+	writeJSON(w, http.StatusForbidden, map[string]string{"error": "mfa required", "mfa_challenge": mfaErr.ChallengeToken})
+	return true
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return token, ok && token != ""
+}
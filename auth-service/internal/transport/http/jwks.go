@@ -0,0 +1,23 @@
+package http
+
+import (
+	"auth-service/internal/jwt"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// jwksHandler отдаёт открытые ключи проверки access-токенов
+func jwksHandler(keys *jwt.KeyRing, log *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := keys.JWKS()
+		if err != nil {
+			log.Error("Failed to encode JWKS", zap.Error(err))
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/jwk-set+json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		_, _ = w.Write(body)
+	}
+}
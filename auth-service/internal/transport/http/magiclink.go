@@ -0,0 +1,53 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"auth-service/internal/service"
+)
+
+// registerMagicLink подключает вход по одноразовой ссылке из письма
+func registerMagicLink(mux *http.ServeMux, a *api) {
+	mux.HandleFunc("POST /api/magic-link", a.requestMagicLink)
+	mux.HandleFunc("POST /api/magic-link/consume", a.consumeMagicLink)
+}
+
+// requestMagicLink всегда отвечает 202: по ответу нельзя узнать, зарегистрирован ли адрес
+func (a *api) requestMagicLink(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if !strings.Contains(req.Email, "@") {
+		writeError(w, http.StatusBadRequest, "invalid email")
+		return
+	}
+	if err := a.users.RequestMagicLink(req.Email); err != nil {
+		a.fail(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (a *api) consumeMagicLink(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	access, refresh, err := a.users.ConsumeMagicLink(req.Token, clientInfo(r, a.clients))
+	switch {
+	case err == nil:
+		writeTokens(w, access, refresh)
+	case writeMFAChallenge(w, err):
+	case errors.Is(err, service.ErrInvalidToken), errors.Is(err, service.ErrUserNotFound):
+		writeError(w, http.StatusUnauthorized, "invalid or expired link")
+	default:
+		a.fail(w, r, err)
+	}
+}
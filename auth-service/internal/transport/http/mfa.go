@@ -0,0 +1,134 @@
+package http
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"auth-service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// registerMFA подключает настройку TOTP и второй шаг входа: POST /api/mfa/verify обменивает
+// токен вызова из trailer x-mfa-challenge ответа Login и код на пару токенов
+func registerMFA(mux *http.ServeMux, a *api, mfa *service.MFAService) {
+	h := &mfaHandler{api: a, mfa: mfa}
+	mux.HandleFunc("GET /api/mfa", a.authed(h.status))
+	mux.HandleFunc("POST /api/mfa/totp", a.authed(h.enroll))
+	mux.HandleFunc("POST /api/mfa/totp/confirm", a.authed(h.confirm))
+	mux.HandleFunc("POST /api/mfa/totp/disable", a.authed(h.disable))
+	mux.HandleFunc("POST /api/mfa/recovery-codes", a.authed(h.regenerate))
+	mux.HandleFunc("POST /api/mfa/verify", h.verify)
+}
+
+type mfaHandler struct {
+	*api
+	mfa *service.MFAService
+}
+
+type codeRequest struct {
+	Code string `json:"code"`
+}
+
+func (h *mfaHandler) mfaError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrMFAUnavailable):
+		writeError(w, http.StatusNotImplemented, err.Error())
+	case errors.Is(err, service.ErrMFAAlreadyEnabled), errors.Is(err, service.ErrMFANotEnabled):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrInvalidMFACode):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		h.fail(w, r, err)
+	}
+}
+
+func (h *mfaHandler) status(w http.ResponseWriter, r *http.Request, userID, _ uuid.UUID) {
+	enabled, err := h.mfa.Enabled(userID)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	left, err := h.mfa.RecoveryCodesLeft(userID)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"enabled": enabled, "recovery_codes_left": left})
+}
+
+func (h *mfaHandler) enroll(w http.ResponseWriter, r *http.Request, userID, _ uuid.UUID) {
+	enrollment, err := h.mfa.EnrollTOTP(userID)
+	if err != nil {
+		h.mfaError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"secret": enrollment.Secret, "uri": enrollment.URI})
+}
+
+func (h *mfaHandler) confirm(w http.ResponseWriter, r *http.Request, userID, _ uuid.UUID) {
+	var req codeRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	codes, err := h.mfa.ConfirmTOTP(userID, req.Code)
+	if err != nil {
+		h.mfaError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"recovery_codes": codes})
+}
+
+func (h *mfaHandler) disable(w http.ResponseWriter, r *http.Request, userID, _ uuid.UUID) {
+	var req codeRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if err := h.mfa.DisableTOTP(userID, req.Code); err != nil {
+		h.mfaError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *mfaHandler) regenerate(w http.ResponseWriter, r *http.Request, userID, _ uuid.UUID) {
+	var req codeRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	codes, err := h.mfa.RegenerateRecoveryCodes(userID, req.Code)
+	if err != nil {
+		h.mfaError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"recovery_codes": codes})
+}
+
+func (h *mfaHandler) verify(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Challenge string `json:"challenge"`
+		Code      string `json:"code"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	access, refresh, err := h.users.VerifyMFA(req.Challenge, req.Code, clientInfo(r, h.clients))
+	var throttled *service.LoginThrottledError
+	switch {
+	case err == nil:
+		writeTokens(w, access, refresh)
+	case errors.As(err, &throttled):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, "too many failed login attempts")
+	case errors.Is(err, service.ErrInvalidMFACode):
+		// Код можно ввести снова с тем же вызовом, пока не исчерпаны попытки
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrInvalidToken), errors.Is(err, service.ErrMFANotEnabled):
+		// Вызов истёк или израсходован — нужен новый Login
+		writeError(w, http.StatusUnauthorized, "invalid or expired challenge")
+	default:
+		h.fail(w, r, err)
+	}
+}
@@ -0,0 +1,126 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"auth-service/internal/service"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// registerOAuth подключает вход через внешних провайдеров. start перенаправляет браузер к провайдеру,
+// провайдер возвращает его на OAUTH_<NAME>_REDIRECT_URL приложения, и приложение передаёт state и code в callback.
+func registerOAuth(mux *http.ServeMux, a *api, oauth *service.OAuthService) {
+	h := &oauthHandler{api: a, oauth: oauth}
+	mux.HandleFunc("GET /api/oauth/providers", h.providers)
+	mux.HandleFunc("GET /api/oauth/{provider}/start", h.start)
+	mux.HandleFunc("POST /api/oauth/{provider}/callback", h.callback)
+	mux.HandleFunc("GET /api/oauth/identities", a.authed(h.listIdentities))
+	mux.HandleFunc("DELETE /api/oauth/identities/{id}", a.authed(h.unlinkIdentity))
+}
+
+type oauthHandler struct {
+	*api
+	oauth *service.OAuthService
+}
+
+func (h *oauthHandler) providers(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string][]string{"providers": h.oauth.Providers()})
+}
+
+// start отвечает 302 на страницу входа провайдера; с Accept: application/json — {"url": ...}
+func (h *oauthHandler) start(w http.ResponseWriter, r *http.Request) {
+	authURL, err := h.oauth.BeginOAuth(r.Context(), r.PathValue("provider"))
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrUnknownProvider):
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, service.ErrOAuthFailed):
+		h.log.Warn("OAuth start failed", zap.String("provider", r.PathValue("provider")), zap.Error(err))
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	default:
+		h.fail(w, r, err)
+		return
+	}
+	if r.Header.Get("Accept") == "application/json" {
+		writeJSON(w, http.StatusOK, map[string]string{"url": authURL})
+		return
+	}
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+func (h *oauthHandler) callback(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		State string `json:"state"`
+		Code  string `json:"code"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	access, refresh, err := h.oauth.CompleteOAuth(r.Context(), r.PathValue("provider"), req.State, req.Code, clientInfo(r, h.clients))
+	switch {
+	case err == nil:
+		writeTokens(w, access, refresh)
+	case writeMFAChallenge(w, err):
+	case errors.Is(err, service.ErrUnknownProvider):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidToken):
+		writeError(w, http.StatusBadRequest, "invalid or expired state")
+	case errors.Is(err, service.ErrOAuthFailed):
+		// Подробности — только в лог: в них ответ провайдера
+		h.log.Warn("OAuth callback failed", zap.String("provider", r.PathValue("provider")), zap.Error(err))
+		writeError(w, http.StatusUnauthorized, service.ErrOAuthFailed.Error())
+	case errors.Is(err, service.ErrEmailNotVerified), errors.Is(err, service.ErrAccountNotVerified):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		h.fail(w, r, err)
+	}
+}
+
+type identityResponse struct {
+	ID          string     `json:"id"`
+	Provider    string     `json:"provider"`
+	Email       string     `json:"email"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+func (h *oauthHandler) listIdentities(w http.ResponseWriter, r *http.Request, userID, _ uuid.UUID) {
+	identities, err := h.oauth.ListIdentities(userID)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	resp := make([]identityResponse, 0, len(identities))
+	for _, i := range identities {
+		resp = append(resp, identityResponse{
+			ID:          i.ID.String(),
+			Provider:    i.Provider,
+			Email:       i.Email,
+			CreatedAt:   i.CreatedAt,
+			LastLoginAt: i.LastLoginAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"identities": resp})
+}
+
+func (h *oauthHandler) unlinkIdentity(w http.ResponseWriter, r *http.Request, userID, _ uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	err := h.oauth.UnlinkIdentity(userID, id)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, service.ErrIdentityNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	default:
+		h.fail(w, r, err)
+	}
+}
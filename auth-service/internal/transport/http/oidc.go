@@ -0,0 +1,253 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"auth-service/internal/clientip"
+	"auth-service/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// registerOIDC подключает эндпоинты OpenID-провайдера.
+// Запрос авторизации пересылается на страницу входа LinkVault; после входа она отправляет
+// те же параметры POST-запросом с access-токеном пользователя и получает адрес возврата с кодом.
+func registerOIDC(mux *http.ServeMux, oidc *service.OIDCService, clients *clientip.Resolver, log *zap.Logger) {
+	h := &oidcHandler{oidc: oidc, clients: clients, log: log}
+	mux.HandleFunc("GET /.well-known/openid-configuration", h.discovery)
+	mux.HandleFunc("GET /oauth/authorize", h.authorizeRedirect)
+	mux.HandleFunc("POST /oauth/authorize", h.authorize)
+	mux.HandleFunc("POST /oauth/token", h.token)
+	mux.HandleFunc("GET /oauth/userinfo", h.userinfo)
+	mux.HandleFunc("POST /oauth/userinfo", h.userinfo)
+}
+
+type oidcHandler struct {
+	oidc    *service.OIDCService
+	clients *clientip.Resolver
+	log     *zap.Logger
+}
+
+func (h *oidcHandler) discovery(w http.ResponseWriter, r *http.Request) {
+	issuer := h.oidc.Issuer()
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/oauth/authorize",
+		"token_endpoint":                        issuer + "/oauth/token",
+		"userinfo_endpoint":                     issuer + "/oauth/userinfo",
+		"jwks_uri":                              issuer + "/.well-known/jwks.json",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"EdDSA"},
+		"scopes_supported":                      service.OIDCScopes,
+		"claims_supported":                      []string{"sub", "iss", "aud", "exp", "iat", "nonce", "name", "email", "email_verified"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func authorizeRequest(q url.Values) service.AuthorizeRequest {
+	return service.AuthorizeRequest{
+		ClientID:            q.Get("client_id"),
+		RedirectURI:         q.Get("redirect_uri"),
+		ResponseType:        q.Get("response_type"),
+		Scope:               q.Get("scope"),
+		State:               q.Get("state"),
+		Nonce:               q.Get("nonce"),
+		CodeChallenge:       q.Get("code_challenge"),
+		CodeChallengeMethod: q.Get("code_challenge_method"),
+	}
+}
+
+// authorizeRedirect проверяет запрос и отправляет пользователя на страницу входа с теми же параметрами
+func (h *oidcHandler) authorizeRedirect(w http.ResponseWriter, r *http.Request) {
+	req := authorizeRequest(r.URL.Query())
+	if _, err := h.oidc.ValidateAuthorize(req); err != nil {
+		if target, ok := h.errorRedirect(req, err); ok {
+			http.Redirect(w, r, target, http.StatusFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, h.oidc.LoginURL()+"?"+r.URL.RawQuery, http.StatusFound)
+}
+
+// authorize выдаёт код вошедшему пользователю и возвращает адрес, на который его нужно перенаправить
+func (h *oidcHandler) authorize(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(r)
+	if !ok {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_token")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+	req := authorizeRequest(r.PostForm)
+	code, err := h.oidc.Authorize(token, req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidToken) || errors.Is(err, service.ErrTokenRevoked) {
+			writeOAuthError(w, http.StatusUnauthorized, "invalid_token")
+			return
+		}
+		if target, ok := h.errorRedirect(req, err); ok {
+			writeJSON(w, http.StatusOK, map[string]string{"redirect_to": target})
+			return
+		}
+		h.writeError(w, err)
+		return
+	}
+	params := url.Values{"code": {code}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"redirect_to": withQuery(req.RedirectURI, params)})
+}
+
+// errorRedirect возвращает адрес возврата с ошибкой, если клиент и redirect_uri уже проверены
+func (h *oidcHandler) errorRedirect(req service.AuthorizeRequest, err error) (string, bool) {
+	code := oauthErrorCode(err)
+	if code == "invalid_client" || errors.Is(err, service.ErrInvalidRedirectURI) || code == "server_error" {
+		return "", false
+	}
+	params := url.Values{"error": {code}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	return withQuery(req.RedirectURI, params), true
+}
+
+func (h *oidcHandler) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+	clientID, secret, ok := r.BasicAuth()
+	if ok {
+		// RFC 6749 §2.3.1: идентификатор и секрет в Basic кодируются form-urlencoded
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	var (
+		resp *service.TokenResponse
+		err  error
+	)
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		resp, err = h.oidc.ExchangeCode(clientID, secret, r.PostForm.Get("code"), r.PostForm.Get("redirect_uri"), r.PostForm.Get("code_verifier"), clientInfo(r, h.clients))
+	case "refresh_token":
+		resp, err = h.oidc.RefreshToken(clientID, secret, r.PostForm.Get("refresh_token"), clientInfo(r, h.clients))
+	default:
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	body := map[string]any{
+		"access_token":  resp.AccessToken,
+		"token_type":    "Bearer",
+		"expires_in":    int(h.oidc.AccessTokenTTL().Seconds()),
+		"refresh_token": resp.RefreshToken,
+	}
+	if resp.IDToken != "" {
+		body["id_token"] = resp.IDToken
+	}
+	if resp.Scope != "" {
+		body["scope"] = resp.Scope
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (h *oidcHandler) userinfo(w http.ResponseWriter, r *http.Request) {
+	token, ok := bearerToken(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Bearer`)
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_token")
+		return
+	}
+	info, err := h.oidc.UserInfo(token)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidToken) || errors.Is(err, service.ErrTokenRevoked) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			writeOAuthError(w, http.StatusUnauthorized, "invalid_token")
+			return
+		}
+		h.writeError(w, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, info)
+}
+
+func (h *oidcHandler) writeError(w http.ResponseWriter, err error) {
+	code := oauthErrorCode(err)
+	switch code {
+	case "server_error":
+		h.log.Error("OIDC request failed", zap.Error(err))
+		writeOAuthError(w, http.StatusInternalServerError, code)
+	case "invalid_client":
+		writeOAuthError(w, http.StatusUnauthorized, code)
+	default:
+		writeOAuthError(w, http.StatusBadRequest, code)
+	}
+}
+
+// oauthErrorCode переводит ошибку сервиса в код ошибки OAuth 2.0
+func oauthErrorCode(err error) string {
+	switch {
+	case errors.Is(err, service.ErrInvalidClient):
+		return "invalid_client"
+	case errors.Is(err, service.ErrInvalidGrant):
+		return "invalid_grant"
+	case errors.Is(err, service.ErrInvalidScope):
+		return "invalid_scope"
+	case errors.Is(err, service.ErrUnsupportedResponseType):
+		return "unsupported_response_type"
+	case errors.Is(err, service.ErrInvalidRedirectURI), errors.Is(err, service.ErrPKCERequired):
+		return "invalid_request"
+	default:
+		return "server_error"
+	}
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, map[string]string{"error": code})
+}
+
+func withQuery(uri string, params url.Values) string {
+	sep := "?"
+	if strings.Contains(uri, "?") {
+		sep = "&"
+	}
+	return uri + sep + params.Encode()
+}
+
+// clientInfo — устройство, с которого выполняется вход; сохраняется в сессии.
+// X-Forwarded-For учитывается, только если запрос пришёл от доверенного шлюза.
+func clientInfo(r *http.Request, clients *clientip.Resolver) service.ClientInfo {
+	return service.ClientInfo{
+		UserAgent: truncate(r.UserAgent(), 512),
+		IP:        truncate(clients.Resolve(r.RemoteAddr, strings.Join(r.Header.Values("X-Forwarded-For"), ",")), 64),
+	}
+}
+
+// truncate обрезает строку до n байт, не разрывая UTF-8 символы
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return strings.ToValidUTF8(s[:n], "")
+	}
+	return s
+}
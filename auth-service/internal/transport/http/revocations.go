@@ -0,0 +1,64 @@
+package http
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"auth-service/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// registerRevocations публикует действующие записи об отзыве access-токенов.
+// Сервисы, проверяющие токены локально по JWKS, периодически забирают список целиком;
+// записи живут не дольше ACCESS_EXP, поэтому список невелик.
+func registerRevocations(mux *http.ServeMux, users *service.UserService, token string, log *zap.Logger) {
+	mux.HandleFunc("GET /internal/revocations", func(w http.ResponseWriter, r *http.Request) {
+		got, ok := bearerToken(r)
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+		tokens, sessions, cutoffs, err := users.ActiveRevocations()
+		if err != nil {
+			log.Error("Failed to list access token revocations", zap.Error(err))
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		type revokedToken struct {
+			JTI       string    `json:"jti"`
+			ExpiresAt time.Time `json:"expires_at"`
+		}
+		type revokedSession struct {
+			SessionID string    `json:"session_id"`
+			ExpiresAt time.Time `json:"expires_at"`
+		}
+		type userCutoff struct {
+			UserID        string    `json:"user_id"`
+			RevokedBefore time.Time `json:"revoked_before"`
+			ExpiresAt     time.Time `json:"expires_at"`
+		}
+		resp := struct {
+			Tokens   []revokedToken   `json:"tokens"`
+			Sessions []revokedSession `json:"sessions"`
+			Cutoffs  []userCutoff     `json:"cutoffs"`
+		}{
+			Tokens:   make([]revokedToken, 0, len(tokens)),
+			Sessions: make([]revokedSession, 0, len(sessions)),
+			Cutoffs:  make([]userCutoff, 0, len(cutoffs)),
+		}
+		for _, t := range tokens {
+			resp.Tokens = append(resp.Tokens, revokedToken{JTI: t.JTI, ExpiresAt: t.ExpiresAt})
+		}
+		for _, s := range sessions {
+			resp.Sessions = append(resp.Sessions, revokedSession{SessionID: s.SessionID.String(), ExpiresAt: s.ExpiresAt})
+		}
+		for _, c := range cutoffs {
+			resp.Cutoffs = append(resp.Cutoffs, userCutoff{UserID: c.UserID.String(), RevokedBefore: c.RevokedBefore, ExpiresAt: c.ExpiresAt})
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, http.StatusOK, resp)
+	})
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"auth-service/config"
+	"auth-service/internal/clientip"
+	"auth-service/internal/jwt"
+	"auth-service/internal/service"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Services — сервисы, доступные через HTTP; nil — группа маршрутов не подключается
+type Services struct {
+	Users    *service.UserService
+	MFA      *service.MFAService
+	WebAuthn *service.WebAuthnService
+	OAuth    *service.OAuthService
+	OIDC     *service.OIDCService
+}
+
+// NewServer отдаёт открытые ключи проверки access-токенов по /.well-known/jwks.json,
+// список отозванных токенов для других сервисов, пользовательский API для возможностей,
+// которых нет в linkvault-proto, и, при заданном OIDC, эндпоинты OpenID-провайдера
+func NewServer(cfg *config.Config, keys *jwt.KeyRing, svc Services, clients *clientip.Resolver, log *zap.Logger) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/jwks.json", jwksHandler(keys, log))
+	if svc.Users != nil {
+		a := &api{users: svc.Users, clients: clients, log: log}
+		registerSessions(mux, a)
+		registerMagicLink(mux, a)
+		registerUnlock(mux, a)
+		if svc.MFA != nil {
+			registerMFA(mux, a, svc.MFA)
+		}
+		if svc.WebAuthn != nil {
+			registerWebAuthn(mux, a, svc.WebAuthn)
+		}
+		if svc.OAuth != nil {
+			registerOAuth(mux, a, svc.OAuth)
+		}
+		if cfg.JWT.RevocationFeedToken != "" {
+			registerRevocations(mux, svc.Users, cfg.JWT.RevocationFeedToken, log)
+		}
+	}
+	if svc.OIDC != nil {
+		registerOIDC(mux, svc.OIDC, clients, log)
+	}
+	return &http.Server{
+		Addr:              cfg.JWKSPort,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
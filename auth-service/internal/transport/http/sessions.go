@@ -0,0 +1,81 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"auth-service/internal/service"
+
+	"github.com/google/uuid"
+)
+
+// registerSessions подключает список сессий пользователя и их завершение.
+// Текущая сессия определяется по claim sid access-токена запроса.
+func registerSessions(mux *http.ServeMux, a *api) {
+	mux.HandleFunc("GET /api/sessions", a.authed(a.listSessions))
+	mux.HandleFunc("DELETE /api/sessions/{id}", a.authed(a.revokeSession))
+	mux.HandleFunc("POST /api/sessions/revoke-others", a.authed(a.revokeOtherSessions))
+}
+
+type sessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	ClientName string    `json:"client_name,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+func (a *api) listSessions(w http.ResponseWriter, r *http.Request, userID, sessionID uuid.UUID) {
+	sessions, err := a.users.ListSessions(userID, sessionID)
+	if err != nil {
+		a.fail(w, r, err)
+		return
+	}
+	resp := make([]sessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		resp = append(resp, sessionResponse{
+			ID:         s.ID.String(),
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			ClientName: s.ClientName,
+			CreatedAt:  s.CreatedAt,
+			LastUsedAt: s.LastUsedAt,
+			ExpiresAt:  s.ExpiresAt,
+			Current:    s.Current,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"sessions": resp})
+}
+
+func (a *api) revokeSession(w http.ResponseWriter, r *http.Request, userID, _ uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	err := a.users.RevokeSession(userID, id)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, service.ErrSessionNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	default:
+		a.fail(w, r, err)
+	}
+}
+
+func (a *api) revokeOtherSessions(w http.ResponseWriter, r *http.Request, userID, sessionID uuid.UUID) {
+	n, err := a.users.RevokeOtherSessions(userID, sessionID)
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, map[string]int64{"revoked": n})
+	case errors.Is(err, service.ErrSessionNotFound):
+		// Токен выдан без sid — неясно, какую сессию оставить
+		writeError(w, http.StatusConflict, "current session is unknown")
+	default:
+		a.fail(w, r, err)
+	}
+}
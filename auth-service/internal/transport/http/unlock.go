@@ -0,0 +1,32 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"auth-service/internal/service"
+)
+
+// registerUnlock подключает досрочное снятие блокировки входа. Письмо account_locked ведёт
+// на https://app/unlock?token=..., и приложение передаёт токен сюда.
+func registerUnlock(mux *http.ServeMux, a *api) {
+	mux.HandleFunc("POST /api/unlock", a.unlockAccount)
+}
+
+func (a *api) unlockAccount(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	err := a.users.UnlockAccount(req.Token)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, service.ErrInvalidToken):
+		writeError(w, http.StatusBadRequest, "invalid or expired token")
+	default:
+		a.fail(w, r, err)
+	}
+}
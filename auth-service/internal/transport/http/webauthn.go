@@ -0,0 +1,156 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"auth-service/internal/models"
+	"auth-service/internal/service"
+	"auth-service/internal/webauthn"
+
+	"github.com/google/uuid"
+)
+
+// registerWebAuthn подключает регистрацию ключей доступа, вход по ним и управление ключами.
+// Ответы аутентификатора принимаются в виде PublicKeyCredential.toJSON(): бинарные поля — base64url.
+func registerWebAuthn(mux *http.ServeMux, a *api, webauthnService *service.WebAuthnService) {
+	h := &webauthnHandler{api: a, webauthn: webauthnService}
+	mux.HandleFunc("POST /api/webauthn/register/begin", a.authed(h.beginRegistration))
+	mux.HandleFunc("POST /api/webauthn/register/finish", a.authed(h.finishRegistration))
+	mux.HandleFunc("POST /api/webauthn/login/begin", h.beginLogin)
+	mux.HandleFunc("POST /api/webauthn/login/finish", h.finishLogin)
+	mux.HandleFunc("GET /api/webauthn/credentials", a.authed(h.listCredentials))
+	mux.HandleFunc("DELETE /api/webauthn/credentials/{id}", a.authed(h.deleteCredential))
+}
+
+type webauthnHandler struct {
+	*api
+	webauthn *service.WebAuthnService
+}
+
+// publicKeyCredential — поля PublicKeyCredential, которые нужны сервису
+type publicKeyCredential struct {
+	RawID    webauthn.Bytes `json:"rawId"`
+	Response struct {
+		ClientDataJSON    webauthn.Bytes `json:"clientDataJSON"`
+		AttestationObject webauthn.Bytes `json:"attestationObject"`
+		AuthenticatorData webauthn.Bytes `json:"authenticatorData"`
+		Signature         webauthn.Bytes `json:"signature"`
+		UserHandle        webauthn.Bytes `json:"userHandle"`
+	} `json:"response"`
+}
+
+type credentialResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+func toCredentialResponse(c *models.WebAuthnCredential) credentialResponse {
+	return credentialResponse{ID: c.ID.String(), Name: c.Name, CreatedAt: c.CreatedAt, LastUsedAt: c.LastUsedAt}
+}
+
+func (h *webauthnHandler) webauthnError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrWebAuthnUnavailable):
+		writeError(w, http.StatusNotImplemented, err.Error())
+	case errors.Is(err, service.ErrWebAuthnFailed), errors.Is(err, service.ErrInvalidToken):
+		writeError(w, http.StatusBadRequest, "webauthn verification failed")
+	default:
+		h.fail(w, r, err)
+	}
+}
+
+func (h *webauthnHandler) beginRegistration(w http.ResponseWriter, r *http.Request, userID, _ uuid.UUID) {
+	options, err := h.webauthn.BeginRegistration(userID)
+	if err != nil {
+		h.webauthnError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"publicKey": options})
+}
+
+func (h *webauthnHandler) finishRegistration(w http.ResponseWriter, r *http.Request, userID, _ uuid.UUID) {
+	var req struct {
+		Name       string              `json:"name"`
+		Credential publicKeyCredential `json:"credential"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	cred, err := h.webauthn.FinishRegistration(userID, truncate(req.Name, 128), req.Credential.Response.ClientDataJSON, req.Credential.Response.AttestationObject)
+	if err != nil {
+		h.webauthnError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toCredentialResponse(cred))
+}
+
+// beginLogin принимает необязательный email; без него браузер предложит сохранённые passkeys
+func (h *webauthnHandler) beginLogin(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	options, err := h.webauthn.BeginLogin(req.Email)
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, map[string]any{"publicKey": options})
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrCredentialNotFound):
+		// Один ответ для неизвестного адреса и аккаунта без ключей — без перечисления пользователей
+		writeError(w, http.StatusNotFound, "no passkeys for this account")
+	default:
+		h.webauthnError(w, r, err)
+	}
+}
+
+func (h *webauthnHandler) finishLogin(w http.ResponseWriter, r *http.Request) {
+	var req publicKeyCredential
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	access, refresh, err := h.webauthn.FinishLogin(req.RawID, req.Response.ClientDataJSON, req.Response.AuthenticatorData, req.Response.Signature, req.Response.UserHandle, clientInfo(r, h.clients))
+	switch {
+	case err == nil:
+		writeTokens(w, access, refresh)
+	case errors.Is(err, service.ErrWebAuthnFailed),
+		errors.Is(err, service.ErrInvalidToken),
+		errors.Is(err, service.ErrCredentialNotFound):
+		writeError(w, http.StatusUnauthorized, "webauthn verification failed")
+	default:
+		h.webauthnError(w, r, err)
+	}
+}
+
+func (h *webauthnHandler) listCredentials(w http.ResponseWriter, r *http.Request, userID, _ uuid.UUID) {
+	creds, err := h.webauthn.ListCredentials(userID)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	resp := make([]credentialResponse, 0, len(creds))
+	for i := range creds {
+		resp = append(resp, toCredentialResponse(&creds[i]))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"credentials": resp})
+}
+
+func (h *webauthnHandler) deleteCredential(w http.ResponseWriter, r *http.Request, userID, _ uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	err := h.webauthn.DeleteCredential(userID, id)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, service.ErrCredentialNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	default:
+		h.fail(w, r, err)
+	}
+}
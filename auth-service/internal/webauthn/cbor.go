@@ -0,0 +1,154 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+var errCBOR = errors.New("malformed cbor")
+
+// Минимальный декодер CBOR (RFC 8949) для объектов аттестации и ключей COSE.
+// Аутентификаторы кодируют их в каноничной форме CTAP2, поэтому строки и массивы
+// неопределённой длины и числа с плавающей точкой не поддерживаются.
+// Целые возвращаются как int64, строки байтов как []byte, map — как map[any]any.
+
+const maxCBORDepth = 16
+
+// decodeCBOR декодирует первый элемент data и возвращает число прочитанных байт
+func decodeCBOR(data []byte) (any, int, error) {
+	d := cborDecoder{data: data}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, 0, err
+	}
+	return v, d.pos, nil
+}
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *cborDecoder) value(depth int) (any, error) {
+	if depth > maxCBORDepth || d.pos >= len(d.data) {
+		return nil, errCBOR
+	}
+	b := d.data[d.pos]
+	d.pos++
+	major, info := b>>5, b&0x1f
+
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22, 23:
+			return nil, nil
+		default:
+			return nil, errCBOR
+		}
+	}
+
+	arg, err := d.argument(info)
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case 0:
+		if arg > math.MaxInt64 {
+			return nil, errCBOR
+		}
+		return int64(arg), nil
+	case 1:
+		if arg > math.MaxInt64 {
+			return nil, errCBOR
+		}
+		return -1 - int64(arg), nil
+	case 2, 3:
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, errCBOR
+		}
+		raw := d.data[d.pos : d.pos+int(arg)]
+		d.pos += int(arg)
+		if major == 3 {
+			return string(raw), nil
+		}
+		return append([]byte(nil), raw...), nil
+	case 4:
+		// Каждый элемент занимает минимум байт — длину можно проверить заранее
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, errCBOR
+		}
+		items := make([]any, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			v, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	case 5:
+		if arg > uint64(len(d.data)-d.pos)/2 {
+			return nil, errCBOR
+		}
+		m := make(map[any]any, arg)
+		for i := uint64(0); i < arg; i++ {
+			k, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			switch k.(type) {
+			case int64, string:
+			default:
+				return nil, errCBOR
+			}
+			v, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = v
+		}
+		return m, nil
+	case 6:
+		// Теги не влияют на проверку — возвращается помеченное значение
+		return d.value(depth + 1)
+	}
+	return nil, errCBOR
+}
+
+func (d *cborDecoder) argument(info byte) (uint64, error) {
+	var n int
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info == 24:
+		n = 1
+	case info == 25:
+		n = 2
+	case info == 26:
+		n = 4
+	case info == 27:
+		n = 8
+	default:
+		return 0, errCBOR
+	}
+	if len(d.data)-d.pos < n {
+		return 0, errCBOR
+	}
+	raw := d.data[d.pos : d.pos+n]
+	d.pos += n
+	switch n {
+	case 1:
+		return uint64(raw[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(raw)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(raw)), nil
+	default:
+		return binary.BigEndian.Uint64(raw), nil
+	}
+}
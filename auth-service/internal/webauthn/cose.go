@@ -0,0 +1,109 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"math/big"
+)
+
+// Алгоритмы COSE (RFC 9053), которые принимает сервис
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// SupportedAlgorithms — в порядке предпочтения для pubKeyCredParams
+var SupportedAlgorithms = []int64{AlgES256, AlgEdDSA, AlgRS256}
+
+var (
+	ErrUnsupportedKey = errors.New("unsupported credential public key")
+	ErrSignature      = errors.New("invalid signature")
+)
+
+// Параметры ключа COSE_Key
+const (
+	coseKty = 1
+	coseAlg = 3
+	coseCrv = -1
+	coseX   = -2
+	coseY   = -3
+	coseN   = -1
+	coseE   = -2
+
+	ktyOKP = 1
+	ktyEC2 = 2
+	ktyRSA = 3
+
+	crvP256    = 1
+	crvEd25519 = 6
+)
+
+// parseCOSEKey разбирает открытый ключ из authenticatorData
+func parseCOSEKey(m map[any]any) (int64, crypto.PublicKey, error) {
+	kty, _ := m[int64(coseKty)].(int64)
+	alg, _ := m[int64(coseAlg)].(int64)
+	switch {
+	case kty == ktyEC2 && alg == AlgES256:
+		crv, _ := m[int64(coseCrv)].(int64)
+		x, _ := m[int64(coseX)].([]byte)
+		y, _ := m[int64(coseY)].([]byte)
+		if crv != crvP256 || len(x) != 32 || len(y) != 32 {
+			return 0, nil, ErrUnsupportedKey
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return 0, nil, ErrUnsupportedKey
+		}
+		return alg, pub, nil
+	case kty == ktyOKP && alg == AlgEdDSA:
+		crv, _ := m[int64(coseCrv)].(int64)
+		x, _ := m[int64(coseX)].([]byte)
+		if crv != crvEd25519 || len(x) != ed25519.PublicKeySize {
+			return 0, nil, ErrUnsupportedKey
+		}
+		return alg, ed25519.PublicKey(x), nil
+	case kty == ktyRSA && alg == AlgRS256:
+		n, _ := m[int64(coseN)].([]byte)
+		e, _ := m[int64(coseE)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return 0, nil, ErrUnsupportedKey
+		}
+		return alg, &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	}
+	return 0, nil, ErrUnsupportedKey
+}
+
+// verifySignature проверяет подпись data ключом pub в формате PKIX DER
+func verifySignature(alg int64, pkix, data, sig []byte) error {
+	key, err := x509.ParsePKIXPublicKey(pkix)
+	if err != nil {
+		return err
+	}
+	return verifyWithKey(alg, key, data, sig)
+}
+
+func verifyWithKey(alg int64, key crypto.PublicKey, data, sig []byte) error {
+	digest := sha256.Sum256(data)
+	switch pub := key.(type) {
+	case *ecdsa.PublicKey:
+		if alg == AlgES256 && ecdsa.VerifyASN1(pub, digest[:], sig) {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if alg == AlgEdDSA && ed25519.Verify(pub, data, sig) {
+			return nil
+		}
+	case *rsa.PublicKey:
+		if alg == AlgRS256 && rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig) == nil {
+			return nil
+		}
+	}
+	return ErrSignature
+}
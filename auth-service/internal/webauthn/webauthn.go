@@ -0,0 +1,330 @@
+// Package webauthn реализует серверную часть церемоний WebAuthn Level 2:
+// выдачу параметров для navigator.credentials.create/get и проверку ответов аутентификатора.
+// Аттестация не проверяется: сервис запрашивает attestation "none" и принимает
+// форматы "none" и "packed" с самоаттестацией.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrClientData  = errors.New("invalid client data")
+	ErrChallenge   = errors.New("challenge mismatch")
+	ErrOrigin      = errors.New("origin not allowed")
+	ErrAuthData    = errors.New("invalid authenticator data")
+	ErrRPID        = errors.New("rp id hash mismatch")
+	ErrUserPresent = errors.New("user presence not confirmed")
+	ErrUserVerify  = errors.New("user verification required")
+	ErrAttestation = errors.New("unsupported attestation")
+	// ErrSignCount — счётчик подписей не вырос: возможно, ключ скопирован
+	ErrSignCount = errors.New("sign count did not increase")
+)
+
+// Флаги authenticatorData
+const (
+	flagUP = 0x01
+	flagUV = 0x04
+	flagAT = 0x40
+)
+
+// RelyingParty — сайт, к которому привязываются ключи
+type RelyingParty struct {
+	// Домен, например linkvault.app
+	ID   string
+	Name string
+	// Разрешённые origin страниц, выполняющих церемонию, например https://linkvault.app
+	Origins []string
+}
+
+// Bytes сериализуется в JSON как base64url без выравнивания — так его ожидает клиентский код
+type Bytes []byte
+
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return err
+	}
+	*b = raw
+	return nil
+}
+
+type CredentialDescriptor struct {
+	Type string `json:"type"`
+	ID   Bytes  `json:"id"`
+}
+
+type RPEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	ID          Bytes  `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions — PublicKeyCredentialCreationOptions
+type CreationOptions struct {
+	RP                     RPEntity               `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	Challenge              Bytes                  `json:"challenge"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions — PublicKeyCredentialRequestOptions
+type RequestOptions struct {
+	Challenge        Bytes                  `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// Credential — проверенный ключ после регистрации
+type Credential struct {
+	ID []byte
+	// Открытый ключ в PKIX DER
+	PublicKey []byte
+	Algorithm int64
+	SignCount uint32
+	AAGUID    []byte
+}
+
+// NewChallenge возвращает 32 случайных байта
+func NewChallenge() ([]byte, error) {
+	challenge := make([]byte, 32)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+// Descriptors собирает список ключей для allowCredentials / excludeCredentials
+func Descriptors(ids [][]byte) []CredentialDescriptor {
+	out := make([]CredentialDescriptor, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, CredentialDescriptor{Type: "public-key", ID: id})
+	}
+	return out
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// ChallengeOf извлекает challenge из clientDataJSON, чтобы найти незавершённую церемонию
+func ChallengeOf(clientDataJSON []byte) ([]byte, error) {
+	var cd clientData
+	if err := json.Unmarshal(clientDataJSON, &cd); err != nil {
+		return nil, ErrClientData
+	}
+	challenge, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(cd.Challenge, "="))
+	if err != nil {
+		return nil, ErrClientData
+	}
+	return challenge, nil
+}
+
+func (rp *RelyingParty) checkClientData(clientDataJSON []byte, ceremony string, challenge []byte) error {
+	var cd clientData
+	if err := json.Unmarshal(clientDataJSON, &cd); err != nil || cd.Type != ceremony {
+		return ErrClientData
+	}
+	got, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(cd.Challenge, "="))
+	if err != nil || subtle.ConstantTimeCompare(got, challenge) != 1 {
+		return ErrChallenge
+	}
+	if !slices.Contains(rp.Origins, cd.Origin) {
+		return ErrOrigin
+	}
+	return nil
+}
+
+type authenticatorData struct {
+	raw          []byte
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	aaguid       []byte
+	credentialID []byte
+	publicKey    map[any]any
+}
+
+func parseAuthData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, ErrAuthData
+	}
+	ad := &authenticatorData{
+		raw:       raw,
+		rpIDHash:  raw[:32],
+		flags:     raw[32],
+		signCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+	if ad.flags&flagAT == 0 {
+		return ad, nil
+	}
+	rest := raw[37:]
+	if len(rest) < 18 {
+		return nil, ErrAuthData
+	}
+	ad.aaguid = rest[:16]
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLen == 0 || idLen > 1023 || len(rest) < idLen {
+		return nil, ErrAuthData
+	}
+	ad.credentialID = rest[:idLen]
+	key, _, err := decodeCBOR(rest[idLen:])
+	if err != nil {
+		return nil, ErrAuthData
+	}
+	m, ok := key.(map[any]any)
+	if !ok {
+		return nil, ErrAuthData
+	}
+	ad.publicKey = m
+	return ad, nil
+}
+
+func (rp *RelyingParty) checkAuthData(ad *authenticatorData, requireUV bool) error {
+	want := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(ad.rpIDHash, want[:]) {
+		return ErrRPID
+	}
+	if ad.flags&flagUP == 0 {
+		return ErrUserPresent
+	}
+	if requireUV && ad.flags&flagUV == 0 {
+		return ErrUserVerify
+	}
+	return nil
+}
+
+// VerifyRegistration проверяет ответ navigator.credentials.create и возвращает новый ключ
+func (rp *RelyingParty) VerifyRegistration(challenge, clientDataJSON, attestationObject []byte, requireUV bool) (*Credential, error) {
+	if err := rp.checkClientData(clientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+	obj, _, err := decodeCBOR(attestationObject)
+	if err != nil {
+		return nil, ErrAttestation
+	}
+	att, ok := obj.(map[any]any)
+	if !ok {
+		return nil, ErrAttestation
+	}
+	format, _ := att["fmt"].(string)
+	rawAuthData, _ := att["authData"].([]byte)
+	stmt, _ := att["attStmt"].(map[any]any)
+
+	ad, err := parseAuthData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.checkAuthData(ad, requireUV); err != nil {
+		return nil, err
+	}
+	if ad.flags&flagAT == 0 {
+		return nil, ErrAuthData
+	}
+	alg, key, err := parseCOSEKey(ad.publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case "none":
+		if len(stmt) != 0 {
+			return nil, ErrAttestation
+		}
+	case "packed":
+		// Самоаттестация: подпись ключом самой учётной записи; цепочки x5c не поддерживаются
+		if _, hasX5C := stmt["x5c"]; hasX5C {
+			return nil, ErrAttestation
+		}
+		stmtAlg, _ := stmt["alg"].(int64)
+		sig, _ := stmt["sig"].([]byte)
+		if stmtAlg != alg {
+			return nil, ErrAttestation
+		}
+		clientHash := sha256.Sum256(clientDataJSON)
+		if err := verifyWithKey(alg, key, append(append([]byte(nil), rawAuthData...), clientHash[:]...), sig); err != nil {
+			return nil, ErrAttestation
+		}
+	default:
+		return nil, ErrAttestation
+	}
+
+	pkix, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Credential{
+		ID:        append([]byte(nil), ad.credentialID...),
+		PublicKey: pkix,
+		Algorithm: alg,
+		SignCount: ad.signCount,
+		AAGUID:    append([]byte(nil), ad.aaguid...),
+	}, nil
+}
+
+// VerifyAssertion проверяет ответ navigator.credentials.get подписью сохранённого ключа
+// и возвращает новое значение счётчика подписей
+func (rp *RelyingParty) VerifyAssertion(challenge []byte, cred Credential, clientDataJSON, authData, signature []byte, requireUV bool) (uint32, error) {
+	if err := rp.checkClientData(clientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+	ad, err := parseAuthData(authData)
+	if err != nil {
+		return 0, err
+	}
+	if err := rp.checkAuthData(ad, requireUV); err != nil {
+		return 0, err
+	}
+	clientHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte(nil), authData...), clientHash[:]...)
+	if err := verifySignature(cred.Algorithm, cred.PublicKey, signed, signature); err != nil {
+		return 0, err
+	}
+	// Аутентификаторы без счётчика (многие passkeys) всегда присылают 0
+	if (ad.signCount != 0 || cred.SignCount != 0) && ad.signCount <= cred.SignCount {
+		return 0, ErrSignCount
+	}
+	return ad.signCount, nil
+}
@@ -0,0 +1,261 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+const (
+	testRPID   = "linkvault.test"
+	testOrigin = "https://linkvault.test"
+)
+
+var testRP = &RelyingParty{ID: testRPID, Name: "LinkVault", Origins: []string{testOrigin}}
+
+// cborMap — CBOR map с ключами и значениями через один; порядок сохраняется
+type cborMap []any
+
+// encodeCBOR кодирует то подмножество CBOR, которое присылают аутентификаторы
+func encodeCBOR(v any) []byte {
+	head := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n <= 0xff:
+			return []byte{major<<5 | 24, byte(n)}
+		default:
+			return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+		}
+	}
+	switch v := v.(type) {
+	case int:
+		if v < 0 {
+			return head(1, uint64(-1-v))
+		}
+		return head(0, uint64(v))
+	case int64:
+		return encodeCBOR(int(v))
+	case []byte:
+		return append(head(2, uint64(len(v))), v...)
+	case string:
+		return append(head(3, uint64(len(v))), v...)
+	case cborMap:
+		out := head(5, uint64(len(v)/2))
+		for _, item := range v {
+			out = append(out, encodeCBOR(item)...)
+		}
+		return out
+	}
+	panic("encodeCBOR: unsupported type")
+}
+
+// softAuthenticator — программный аутентификатор с одним ключом ES256
+type softAuthenticator struct {
+	key       *ecdsa.PrivateKey
+	id        []byte
+	signCount uint32
+	flags     byte
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return &softAuthenticator{key: key, id: id, flags: flagUP | flagUV}
+}
+
+func clientDataJSON(ceremony string, challenge []byte, origin string) []byte {
+	data, _ := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    origin,
+	})
+	return data
+}
+
+func (a *softAuthenticator) authData(rpID string, attested bool) []byte {
+	rpHash := sha256.Sum256([]byte(rpID))
+	flags := a.flags
+	if attested {
+		flags |= flagAT
+	}
+	data := append(rpHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if !attested {
+		return data
+	}
+	data = append(data, make([]byte, 16)...) // AAGUID
+	data = binary.BigEndian.AppendUint16(data, uint16(len(a.id)))
+	data = append(data, a.id...)
+	coseKey := cborMap{
+		coseKty, ktyEC2,
+		coseAlg, AlgES256,
+		coseCrv, crvP256,
+		coseX, a.key.X.FillBytes(make([]byte, 32)),
+		coseY, a.key.Y.FillBytes(make([]byte, 32)),
+	}
+	return append(data, encodeCBOR(coseKey)...)
+}
+
+func (a *softAuthenticator) sign(t *testing.T, authData, clientData []byte) []byte {
+	clientHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientHash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+// create повторяет navigator.credentials.create: attestationObject в формате none или packed
+func (a *softAuthenticator) create(t *testing.T, challenge []byte, origin, format string) (clientData, attestationObject []byte) {
+	clientData = clientDataJSON("webauthn.create", challenge, origin)
+	authData := a.authData(testRPID, true)
+	stmt := cborMap{}
+	if format == "packed" {
+		stmt = cborMap{"alg", AlgES256, "sig", a.sign(t, authData, clientData)}
+	}
+	return clientData, encodeCBOR(cborMap{"fmt", format, "attStmt", stmt, "authData", authData})
+}
+
+// get повторяет navigator.credentials.get; каждый вызов увеличивает счётчик подписей
+func (a *softAuthenticator) get(t *testing.T, challenge []byte, origin string) (clientData, authData, signature []byte) {
+	a.signCount++
+	clientData = clientDataJSON("webauthn.get", challenge, origin)
+	authData = a.authData(testRPID, false)
+	return clientData, authData, a.sign(t, authData, clientData)
+}
+
+func register(t *testing.T, a *softAuthenticator, format string) *Credential {
+	challenge, err := NewChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientData, attestation := a.create(t, challenge, testOrigin, format)
+	cred, err := testRP.VerifyRegistration(challenge, clientData, attestation, true)
+	if err != nil {
+		t.Fatalf("VerifyRegistration(%s): %v", format, err)
+	}
+	return cred
+}
+
+func TestRegistrationAndLogin(t *testing.T) {
+	for _, format := range []string{"none", "packed"} {
+		t.Run(format, func(t *testing.T) {
+			a := newSoftAuthenticator(t)
+			cred := register(t, a, format)
+			if string(cred.ID) != string(a.id) || cred.Algorithm != AlgES256 {
+				t.Fatalf("credential = %x alg %d, want %x ES256", cred.ID, cred.Algorithm, a.id)
+			}
+
+			for i := 0; i < 2; i++ {
+				challenge, _ := NewChallenge()
+				got, err := ChallengeOf(clientDataJSON("webauthn.get", challenge, testOrigin))
+				if err != nil || string(got) != string(challenge) {
+					t.Fatalf("ChallengeOf = %x, %v", got, err)
+				}
+				clientData, authData, sig := a.get(t, challenge, testOrigin)
+				count, err := testRP.VerifyAssertion(challenge, *cred, clientData, authData, sig, true)
+				if err != nil {
+					t.Fatalf("VerifyAssertion #%d: %v", i+1, err)
+				}
+				cred.SignCount = count
+			}
+			if cred.SignCount != 2 {
+				t.Fatalf("sign count = %d, want 2", cred.SignCount)
+			}
+		})
+	}
+}
+
+func TestRegistrationRejects(t *testing.T) {
+	challenge, _ := NewChallenge()
+	other, _ := NewChallenge()
+
+	cases := []struct {
+		name   string
+		modify func(a *softAuthenticator)
+		// Параметры ответа аутентификатора
+		challenge []byte
+		origin    string
+		want      error
+	}{
+		{name: "foreign origin", challenge: challenge, origin: "https://evil.test", want: ErrOrigin},
+		{name: "other challenge", challenge: other, origin: testOrigin, want: ErrChallenge},
+		{name: "no user verification", modify: func(a *softAuthenticator) { a.flags = flagUP }, challenge: challenge, origin: testOrigin, want: ErrUserVerify},
+		{name: "no user presence", modify: func(a *softAuthenticator) { a.flags = flagUV }, challenge: challenge, origin: testOrigin, want: ErrUserPresent},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := newSoftAuthenticator(t)
+			if tc.modify != nil {
+				tc.modify(a)
+			}
+			clientData, attestation := a.create(t, tc.challenge, tc.origin, "none")
+			if _, err := testRP.VerifyRegistration(challenge, clientData, attestation, true); !errors.Is(err, tc.want) {
+				t.Fatalf("err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+
+	t.Run("packed signature by another key", func(t *testing.T) {
+		a := newSoftAuthenticator(t)
+		clientData := clientDataJSON("webauthn.create", challenge, testOrigin)
+		authData := a.authData(testRPID, true)
+		a.key = newSoftAuthenticator(t).key
+		stmt := cborMap{"alg", AlgES256, "sig", a.sign(t, authData, clientData)}
+		attestation := encodeCBOR(cborMap{"fmt", "packed", "attStmt", stmt, "authData", authData})
+		if _, err := testRP.VerifyRegistration(challenge, clientData, attestation, true); !errors.Is(err, ErrAttestation) {
+			t.Fatalf("err = %v, want ErrAttestation", err)
+		}
+	})
+}
+
+func TestAssertionRejects(t *testing.T) {
+	a := newSoftAuthenticator(t)
+	cred := register(t, a, "none")
+	challenge, _ := NewChallenge()
+
+	t.Run("registration client data", func(t *testing.T) {
+		clientData := clientDataJSON("webauthn.create", challenge, testOrigin)
+		authData := a.authData(testRPID, false)
+		if _, err := testRP.VerifyAssertion(challenge, *cred, clientData, authData, a.sign(t, authData, clientData), true); !errors.Is(err, ErrClientData) {
+			t.Fatalf("err = %v, want ErrClientData", err)
+		}
+	})
+
+	t.Run("other rp id", func(t *testing.T) {
+		clientData := clientDataJSON("webauthn.get", challenge, testOrigin)
+		authData := a.authData("evil.test", false)
+		if _, err := testRP.VerifyAssertion(challenge, *cred, clientData, authData, a.sign(t, authData, clientData), true); !errors.Is(err, ErrRPID) {
+			t.Fatalf("err = %v, want ErrRPID", err)
+		}
+	})
+
+	t.Run("tampered authenticator data", func(t *testing.T) {
+		clientData, authData, sig := a.get(t, challenge, testOrigin)
+		authData[len(authData)-1]++
+		if _, err := testRP.VerifyAssertion(challenge, *cred, clientData, authData, sig, true); !errors.Is(err, ErrSignature) {
+			t.Fatalf("err = %v, want ErrSignature", err)
+		}
+	})
+
+	t.Run("sign count did not increase", func(t *testing.T) {
+		stored := *cred
+		clientData, authData, sig := a.get(t, challenge, testOrigin)
+		stored.SignCount = a.signCount
+		if _, err := testRP.VerifyAssertion(challenge, stored, clientData, authData, sig, true); !errors.Is(err, ErrSignCount) {
+			t.Fatalf("err = %v, want ErrSignCount", err)
+		}
+	})
+}
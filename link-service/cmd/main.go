@@ -2,17 +2,30 @@ package main
 
 import (
 	"context"
+	"errors"
 	"link-service/config"
+	"link-service/internal/authclient"
+	"link-service/internal/clientip"
+	"link-service/internal/events"
+	"link-service/internal/linkcheck"
 	"link-service/internal/maintenance"
+	"link-service/internal/metadata"
+	"link-service/internal/outbox"
+	"link-service/internal/ratelimit"
 	"link-service/internal/repository"
 	"link-service/internal/service"
 	"link-service/internal/storage"
 	grpcserver "link-service/internal/transport/grpc"
+	httpserver "link-service/internal/transport/http"
+	"link-service/internal/urlpolicy"
+	"link-service/internal/webhook"
 	"link-service/pkg/logger"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"sync"
 
@@ -56,18 +69,104 @@ func main() {
 	log.Info("auth connected")
 	defer authConn.Close()
 
+	policy, err := buildURLPolicy(cfg)
+	if err != nil {
+		log.Fatal("url policy error", zap.Error(err))
+	}
+
+	// События ссылок пишутся в outbox; релей отправляет их в Kafka, если брокеры заданы, иначе — в лог
+	var sender outbox.Sender = outbox.NewLogSender(log)
+	if len(cfg.KafkaBrokers) > 0 {
+		kafkaSender := outbox.NewKafkaSender(cfg.KafkaBrokers)
+		defer kafkaSender.Close()
+		sender = kafkaSender
+	}
+	tx := repository.NewTransactor(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	eventsOutbox := events.NewOutbox(outboxRepo, cfg.KafkaEventsTopic)
+	webhookRepo := repository.NewWebhookRepository(db)
+	eventsOutbox.Subscribe(webhook.NewFanout(webhookRepo))
+
 	shortLinkRepo := repository.NewShortLinkRepository(db)
-	shortLinkService := service.NewShortLinkService(shortLinkRepo, log)
+	domainRepo := repository.NewDomainRepository(db)
+	fetcher := metadata.NewFetcher(metadata.Config{
+		Timeout:      cfg.Metadata.Timeout,
+		MaxBodyBytes: cfg.Metadata.MaxBodyBytes,
+	})
+	planRepo := repository.NewPlanRepository(db)
+	quotaService := service.NewQuotaService(planRepo, shortLinkRepo, cfg.DefaultPlan, log)
+	shortLinkService := service.NewShortLinkService(shortLinkRepo, domainRepo, policy, fetcher, tx, eventsOutbox, quotaService, log)
 
 	clickRepo := repository.NewClickRepository(db)
-	clickService := service.NewClickService(clickRepo, log)
+	tagService := service.NewTagService(repository.NewTagRepository(db), repository.NewFolderRepository(db), shortLinkRepo, log)
+	clickService := service.NewClickService(clickRepo, tagService, tx, eventsOutbox, log)
+
+	reportRepo := repository.NewLinkReportRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	abuseService := service.NewAbuseService(shortLinkService, shortLinkRepo, reportRepo, auditRepo, cfg.AdminUserIDs, log)
 
-	scheduler := maintenance.NewScheduler(log, shortLinkRepo, clickRepo)
+	healthRepo := repository.NewLinkHealthRepository(db)
+	checker := linkcheck.NewChecker(linkcheck.Config{
+		Timeout:         cfg.LinkCheck.Timeout,
+		Concurrency:     cfg.LinkCheck.Concurrency,
+		PerHostInterval: cfg.LinkCheck.PerHostInterval,
+	})
+	healthService := service.NewHealthService(shortLinkRepo, healthRepo, checker, tx, eventsOutbox, log)
+
+	// Общее хранилище лимитов чистит планировщик; при memory-бэкенде оно не используется
+	var sharedRateStore *ratelimit.SharedStore
+	if cfg.RateLimit.Backend == "postgres" {
+		sharedRateStore = ratelimit.NewSharedStore(db)
+	}
+
+	scheduler := maintenance.NewScheduler(log, shortLinkRepo, clickRepo, reportRepo, healthRepo, outboxRepo, cfg.Outbox.Retention, webhookRepo, shortLinkService, healthService, quotaService, sharedRateStore)
 	appCtx, cancelScheduler := context.WithCancel(context.Background())
 	if err := scheduler.Start(appCtx); err != nil {
 		log.Error("Не удалось запустить планировщик", zap.Error(err))
 	}
 
+	relay := outbox.NewRelay(tx, outboxRepo, sender, outbox.Config{
+		Interval:    cfg.Outbox.RelayInterval,
+		BatchSize:   cfg.Outbox.BatchSize,
+		MaxAttempts: cfg.Outbox.MaxAttempts,
+	}, log)
+	go relay.Run(appCtx)
+
+	webhookWorker := webhook.NewWorker(webhookRepo, webhook.Config{
+		Timeout:     cfg.Webhook.Timeout,
+		Concurrency: cfg.Webhook.Concurrency,
+		MaxAttempts: cfg.Webhook.MaxAttempts,
+	}, log)
+	go webhookWorker.Run(appCtx)
+
+	var jwks *authclient.KeySet
+	if cfg.AuthCache.JWKSURL != "" {
+		// Без списка отзыва локальная проверка принимала бы отозванные токены до exp
+		if cfg.AuthCache.RevocationsURL == "" {
+			log.Fatal("AUTH_JWKS_URL задан без AUTH_REVOCATIONS_URL: локальная проверка токенов требует списка отзыва")
+		}
+		jwks = authclient.NewKeySet(cfg.AuthCache.JWKSURL, log)
+		go jwks.Run(appCtx, cfg.AuthCache.JWKSRefresh)
+	}
+	var revocations *authclient.Revocations
+	if jwks != nil {
+		// Список, не обновлявшийся три периода, считается устаревшим: токены снова проверяет Auth Service
+		revocations = authclient.NewRevocations(cfg.AuthCache.RevocationsURL, cfg.AuthCache.RevocationsToken, 3*cfg.AuthCache.RevocationsRefresh, log)
+		go revocations.Run(appCtx, cfg.AuthCache.RevocationsRefresh)
+	}
+	tokenValidator := authclient.NewValidator(authClient, jwks, revocations, authclient.Config{
+		CacheTTL:        cfg.AuthCache.TTL,
+		NegativeTTL:     cfg.AuthCache.NegativeTTL,
+		Timeout:         cfg.AuthCache.Timeout,
+		BreakerFailures: cfg.AuthCache.BreakerFailures,
+		BreakerCooldown: cfg.AuthCache.BreakerCooldown,
+	}, log)
+
+	var rateStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimit.Backend == "postgres" {
+		rateStore = sharedRateStore
+	}
+
 	var wg sync.WaitGroup
 
 	lis, err := net.Listen("tcp", cfg.Port)
@@ -78,8 +177,10 @@ func main() {
 	grpcServer := grpc.NewServer(
 		grpc.UnaryInterceptor(
 			grpc_middleware.ChainUnaryServer(
-				grpcserver.AuthInterceptor(authClient),
-				grpcserver.OptionalAuthInterceptor(authClient),
+				grpcserver.AuthInterceptor(tokenValidator),
+				grpcserver.OptionalAuthInterceptor(tokenValidator, cfg.AuthCache.OptionalFailOpen),
+				grpcserver.RateLimitInterceptor(rateStore, grpcserver.RateLimitPolicies(cfg.RateLimit), clientip.NewResolver(cfg.TrustedProxies, log), log),
+				grpcserver.QuotaInterceptor(quotaService, log),
 			),
 		),
 	)
@@ -90,7 +191,7 @@ func main() {
 
 	reflection.Register(grpcServer)
 
-	linkv1.RegisterLinkServiceServer(grpcServer, grpcserver.NewLinkServer(shortLinkService, clickService, cfg, &wg))
+	linkv1.RegisterLinkServiceServer(grpcServer, grpcserver.NewLinkServer(shortLinkService, clickService, quotaService, cfg, &wg))
 
 	go func() {
 		log.Info("Starting gRPC server", zap.String("addr", cfg.Port))
@@ -99,11 +200,32 @@ func main() {
 		}
 	}()
 
+	httpServer := httpserver.NewServer(cfg, tokenValidator, httpserver.Services{
+		Links:    shortLinkService,
+		Health:   healthService,
+		QR:       service.NewQRService(shortLinkRepo, cfg.Domain, log),
+		Domains:  service.NewDomainService(domainRepo, nil, cfg.Domain, log),
+		Abuse:    abuseService,
+		Tags:     tagService,
+		Clicks:   clickService,
+		Quota:    quotaService,
+		Webhooks: service.NewWebhookService(webhookRepo, policy, log),
+	}, log)
+	go func() {
+		log.Info("Starting HTTP server", zap.String("addr", cfg.HTTP.Port))
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("HTTP server failed", zap.Error(err))
+		}
+	}()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Info("Shutting down gRPC server...")
 
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	_ = httpServer.Shutdown(shutdownCtx)
 	grpcServer.GracefulStop()
 	wg.Wait()
 	cancelScheduler()
@@ -119,3 +241,33 @@ func createAuthClient(authAddr string) (authv1.AuthServiceClient, *grpc.ClientCo
 	client := authv1.NewAuthServiceClient(conn)
 	return client, conn, nil
 }
+
+func buildURLPolicy(cfg *config.Config) (*urlpolicy.Policy, error) {
+	opts := []urlpolicy.Option{
+		urlpolicy.WithAllowedSchemes(cfg.URLPolicy.AllowedSchemes...),
+		urlpolicy.WithPrivateIPBlocking(cfg.URLPolicy.BlockPrivateIPs),
+		urlpolicy.WithOwnDomains(cfg.Domain),
+	}
+	if cfg.URLPolicy.BlocklistFile != "" {
+		list, err := urlpolicy.LoadDomainList(cfg.URLPolicy.BlocklistFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, urlpolicy.WithBlocklist(list))
+	}
+	if cfg.URLPolicy.AllowlistFile != "" {
+		list, err := urlpolicy.LoadDomainList(cfg.URLPolicy.AllowlistFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, urlpolicy.WithAllowlist(list))
+	}
+	if cfg.URLPolicy.HashlistFile != "" {
+		checker, err := urlpolicy.LoadHashListChecker(cfg.URLPolicy.HashlistFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, urlpolicy.WithReputationChecker(checker))
+	}
+	return urlpolicy.New(opts...), nil
+}
@@ -2,19 +2,109 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 )
 
 type Config struct {
 	Port     string
+	HTTP     HTTPConfig
 	DB       DBConfig
 	Domain   string
 	AuthAddr string
 
+	// Адреса и подсети шлюзов, которым доверяется metadata x-forwarded-for в gRPC
+	TrustedProxies []string
+
 	KafkaBrokers []string
 	KafkaTopic   string
+
+	// Топик событий жизненного цикла ссылок
+	KafkaEventsTopic string
+
+	URLPolicy URLPolicyConfig
+
+	AdminUserIDs   []string
+	BlockedPageURL string
+
+	Metadata  MetadataConfig
+	LinkCheck LinkCheckConfig
+	Outbox    OutboxConfig
+	Webhook   WebhookConfig
+
+	// План для пользователей без назначенного плана
+	DefaultPlan string
+
+	RateLimit RateLimitConfig
+	AuthCache AuthCacheConfig
+}
+
+type AuthCacheConfig struct {
+	TTL             time.Duration
+	NegativeTTL     time.Duration
+	Timeout         time.Duration
+	BreakerFailures int
+	BreakerCooldown time.Duration
+	// Продолжать методы с необязательной авторизацией анонимно, если Auth Service недоступен
+	OptionalFailOpen bool
+	// JWKS Auth Service для локальной проверки токенов; пусто — проверка через ValidateAccessToken
+	JWKSURL     string
+	JWKSRefresh time.Duration
+	// Список отозванных токенов Auth Service для локальной проверки и токен доступа к нему
+	RevocationsURL     string
+	RevocationsToken   string
+	RevocationsRefresh time.Duration
+}
+
+type RateLimitConfig struct {
+	// memory — в памяти процесса, postgres — общие лимиты для всех экземпляров
+	Backend             string
+	CreateAnonPerMinute int
+	CreateUserPerMinute int
+	RedirectPerMinute   int
+}
+
+type WebhookConfig struct {
+	Timeout     time.Duration
+	Concurrency int
+	MaxAttempts int
+}
+
+type OutboxConfig struct {
+	RelayInterval time.Duration
+	BatchSize     int
+	// После стольких неудачных попыток сообщение больше не отправляется
+	MaxAttempts int
+	// Сколько хранить отправленные сообщения
+	Retention time.Duration
+}
+
+type LinkCheckConfig struct {
+	Timeout         time.Duration
+	Concurrency     int
+	PerHostInterval time.Duration
+}
+
+type MetadataConfig struct {
+	Timeout      time.Duration
+	MaxBodyBytes int64
+}
+
+type HTTPConfig struct {
+	Port string
+	// Адреса и подсети шлюзов, которым доверяется X-Forwarded-For
+	TrustedProxies []string
+}
+
+type URLPolicyConfig struct {
+	AllowedSchemes  []string
+	BlockPrivateIPs bool
+	BlocklistFile   string
+	AllowlistFile   string
+	HashlistFile    string
 }
 
 type DBConfig struct {
@@ -27,8 +117,9 @@ type DBConfig struct {
 }
 
 func Load(log *zap.Logger) *Config {
-	return &Config{
-		Port: getEnv("APP_PORT", log),
+	cfg := &Config{
+		Port:           getEnv("APP_PORT", log),
+		TrustedProxies: splitAndTrim(os.Getenv("GRPC_TRUSTED_PROXIES")),
 		DB: DBConfig{
 			Host:     getEnv("DB_HOST", log),
 			Port:     getEnv("DB_PORT", log),
@@ -40,9 +131,67 @@ func Load(log *zap.Logger) *Config {
 		KafkaBrokers: splitAndTrim(os.Getenv("KAFKA_BROKERS")),
 		KafkaTopic:   getEnv("KAFKA_TOPIC_EMAIL", log),
 
+		KafkaEventsTopic: getEnvDefault("KAFKA_TOPIC_LINK_EVENTS", "link-events"),
+
 		Domain:   getEnv("DOMAIN", log),
 		AuthAddr: getEnv("AUTH_SERVICE_ADDR", log),
+
+		URLPolicy: URLPolicyConfig{
+			AllowedSchemes:  splitAndTrim(getEnvDefault("URL_ALLOWED_SCHEMES", "http,https")),
+			BlockPrivateIPs: getEnvDefault("URL_BLOCK_PRIVATE_IPS", "true") == "true",
+			BlocklistFile:   os.Getenv("URL_BLOCKLIST_FILE"),
+			AllowlistFile:   os.Getenv("URL_ALLOWLIST_FILE"),
+			HashlistFile:    os.Getenv("URL_HASHLIST_FILE"),
+		},
+	}
+	cfg.HTTP = HTTPConfig{
+		Port:           getEnvDefault("HTTP_PORT", ":8092"),
+		TrustedProxies: splitAndTrim(os.Getenv("HTTP_TRUSTED_PROXIES")),
+	}
+	cfg.AdminUserIDs = splitAndTrim(os.Getenv("ADMIN_USER_IDS"))
+	cfg.BlockedPageURL = getEnvDefault("BLOCKED_PAGE_URL", cfg.Domain+"/blocked")
+	cfg.Metadata = MetadataConfig{
+		Timeout:      getEnvDuration("METADATA_FETCH_TIMEOUT", 5*time.Second, log),
+		MaxBodyBytes: getEnvInt64("METADATA_MAX_BYTES", 512<<10, log),
+	}
+	cfg.LinkCheck = LinkCheckConfig{
+		Timeout:         getEnvDuration("LINK_CHECK_TIMEOUT", 10*time.Second, log),
+		Concurrency:     int(getEnvInt64("LINK_CHECK_CONCURRENCY", 8, log)),
+		PerHostInterval: getEnvDuration("LINK_CHECK_HOST_INTERVAL", time.Second, log),
 	}
+	cfg.Outbox = OutboxConfig{
+		RelayInterval: getEnvDuration("OUTBOX_RELAY_INTERVAL", time.Second, log),
+		BatchSize:     int(getEnvInt64("OUTBOX_BATCH_SIZE", 100, log)),
+		MaxAttempts:   int(getEnvInt64("OUTBOX_MAX_ATTEMPTS", 10, log)),
+		Retention:     getEnvDuration("OUTBOX_RETENTION", 7*24*time.Hour, log),
+	}
+	cfg.Webhook = WebhookConfig{
+		Timeout:     getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second, log),
+		Concurrency: int(getEnvInt64("WEBHOOK_CONCURRENCY", 4, log)),
+		MaxAttempts: int(getEnvInt64("WEBHOOK_MAX_ATTEMPTS", 8, log)),
+	}
+	cfg.DefaultPlan = getEnvDefault("DEFAULT_PLAN", "free")
+	cfg.RateLimit = RateLimitConfig{
+		Backend:             getEnvDefault("RATE_LIMIT_BACKEND", "memory"),
+		CreateAnonPerMinute: int(getEnvInt64("RATE_LIMIT_CREATE_ANON_PER_MIN", 10, log)),
+		CreateUserPerMinute: int(getEnvInt64("RATE_LIMIT_CREATE_USER_PER_MIN", 60, log)),
+		RedirectPerMinute:   int(getEnvInt64("RATE_LIMIT_REDIRECT_PER_MIN", 600, log)),
+	}
+	cfg.AuthCache = AuthCacheConfig{
+		TTL:              getEnvDuration("AUTH_CACHE_TTL", time.Minute, log),
+		NegativeTTL:      getEnvDuration("AUTH_NEGATIVE_CACHE_TTL", 10*time.Second, log),
+		Timeout:          getEnvDuration("AUTH_TIMEOUT", 2*time.Second, log),
+		BreakerFailures:  int(getEnvInt64("AUTH_BREAKER_FAILURES", 5, log)),
+		BreakerCooldown:  getEnvDuration("AUTH_BREAKER_COOLDOWN", 10*time.Second, log),
+		OptionalFailOpen: getEnvDefault("AUTH_OPTIONAL_FAIL_OPEN", "true") == "true",
+		JWKSURL:          os.Getenv("AUTH_JWKS_URL"),
+		JWKSRefresh:      getEnvDuration("AUTH_JWKS_REFRESH", 10*time.Minute, log),
+
+		RevocationsURL:     os.Getenv("AUTH_REVOCATIONS_URL"),
+		RevocationsToken:   os.Getenv("AUTH_REVOCATIONS_TOKEN"),
+		RevocationsRefresh: getEnvDuration("AUTH_REVOCATIONS_REFRESH", 5*time.Second, log),
+	}
+	return cfg
 }
 
 func getEnv(key string, log *zap.Logger) string {
@@ -53,6 +202,39 @@ func getEnv(key string, log *zap.Logger) string {
 	panic("missing required environment variable: " + key)
 }
 
+func getEnvDefault(key, def string) string {
+	if val, exists := os.LookupEnv(key); exists && val != "" {
+		return val
+	}
+	return def
+}
+
+func getEnvDuration(key string, def time.Duration, log *zap.Logger) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		log.Warn("Некорректная длительность в переменной окружения, используется значение по умолчанию", zap.String("key", key), zap.Error(err))
+		return def
+	}
+	return d
+}
+
+func getEnvInt64(key string, def int64, log *zap.Logger) int64 {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+	n, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		log.Warn("Некорректное число в переменной окружения, используется значение по умолчанию", zap.String("key", key), zap.Error(err))
+		return def
+	}
+	return n
+}
+
 func splitAndTrim(s string) []string {
 	if s == "" {
 		return nil
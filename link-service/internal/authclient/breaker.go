@@ -0,0 +1,68 @@
+package authclient
+
+import (
+	"sync"
+	"time"
+)
+
+// breaker — простой circuit breaker: после failureThreshold ошибок подряд
+// размыкается на cooldown, затем пропускает один пробный вызов
+type breaker struct {
+	mu               sync.Mutex
+	failureThreshold int
+	cooldown         time.Duration
+
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newBreaker(failureThreshold int, cooldown time.Duration) *breaker {
+	return &breaker{failureThreshold: failureThreshold, cooldown: cooldown}
+}
+
+// allow сообщает, можно ли сейчас обращаться к сервису
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.failureThreshold {
+		return true
+	}
+	if time.Now().Before(b.openUntil) || b.probing {
+		return false
+	}
+	// Полуоткрытое состояние: пропускаем один пробный вызов
+	b.probing = true
+	return true
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+}
+
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.probing = false
+	if b.failures >= b.failureThreshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// cancel завершает вызов, прерванный самим клиентом: ошибкой сервиса это не считается,
+// но пробный вызов полуоткрытого состояния освобождается
+func (b *breaker) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *breaker) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.failureThreshold
+}
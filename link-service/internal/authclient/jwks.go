@@ -0,0 +1,188 @@
+package authclient
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// Не чаще этого интервала JWKS перезагружается из-за неизвестного kid
+const unknownKidRefreshInterval = 30 * time.Second
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	Kid string `json:"kid"`
+	X   string `json:"x"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// KeySet — кэш открытых ключей Auth Service, загружаемых по JWKS URL
+type KeySet struct {
+	url    string
+	client *http.Client
+	log    *zap.Logger
+
+	mu          sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	lastRefresh time.Time
+	lastErr     error
+
+	refreshMu sync.Mutex
+}
+
+func NewKeySet(url string, log *zap.Logger) *KeySet {
+	return &KeySet{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+		log:    log,
+		keys:   map[string]crypto.PublicKey{},
+	}
+}
+
+// Key возвращает ключ по kid; неизвестный kid вызывает перезагрузку набора (не чаще unknownKidRefreshInterval)
+func (s *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if key, ok := s.lookup(kid); ok {
+		return key, nil
+	}
+
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	// Пока ждали блокировку, набор мог обновить другой запрос
+	if key, ok := s.lookup(kid); ok {
+		return key, nil
+	}
+	s.mu.RLock()
+	recent := time.Since(s.lastRefresh) < unknownKidRefreshInterval
+	lastErr := s.lastErr
+	s.mu.RUnlock()
+	if recent {
+		if lastErr != nil {
+			return nil, lastErr
+		}
+		return nil, ErrUnknownKey
+	}
+	if err := s.refresh(ctx); err != nil {
+		return nil, err
+	}
+	if key, ok := s.lookup(kid); ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// Refresh перезагружает набор ключей
+func (s *KeySet) Refresh(ctx context.Context) error {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	return s.refresh(ctx)
+}
+
+// Run периодически обновляет набор ключей до отмены ctx
+func (s *KeySet) Run(ctx context.Context, interval time.Duration) {
+	if err := s.Refresh(ctx); err != nil {
+		s.log.Warn("Failed to load JWKS", zap.String("url", s.url), zap.Error(err))
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				s.log.Warn("Failed to refresh JWKS", zap.String("url", s.url), zap.Error(err))
+			}
+		}
+	}
+}
+
+func (s *KeySet) lookup(kid string) (crypto.PublicKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+func (s *KeySet) refresh(ctx context.Context) error {
+	keys, err := s.fetch(ctx)
+	// Время фиксируется и при ошибке, чтобы поток неизвестных kid не превращался в поток запросов
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastRefresh = time.Now()
+	s.lastErr = err
+	if err == nil {
+		s.keys = keys
+	}
+	return err
+}
+
+func (s *KeySet) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks: unexpected status %d", resp.StatusCode)
+	}
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			s.log.Warn("Skipping unsupported JWK", zap.String("kid", k.Kid), zap.Error(err))
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch {
+	case k.Kty == "OKP" && k.Crv == "Ed25519":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	case k.Kty == "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
@@ -0,0 +1,136 @@
+package authclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// errRevocationsStale — список отзыва давно не обновлялся, локальной проверке доверять нельзя
+var errRevocationsStale = errors.New("revocation list is stale")
+
+// Revocations — список отозванных access-токенов Auth Service (GET /internal/revocations).
+// Нужен локальной проверке по JWKS: без него токен после Logout принимался бы до своего exp.
+type Revocations struct {
+	url    string
+	token  string
+	maxAge time.Duration
+	client *http.Client
+	log    *zap.Logger
+
+	mu       sync.RWMutex
+	tokens   map[string]time.Time
+	sessions map[uuid.UUID]time.Time
+	cutoffs  map[uuid.UUID]time.Time
+	loadedAt time.Time
+}
+
+// NewRevocations — список по url с токеном доступа token. Если список не удаётся обновить дольше maxAge,
+// Validator проверяет токены через ValidateAccessToken.
+func NewRevocations(url, token string, maxAge time.Duration, log *zap.Logger) *Revocations {
+	return &Revocations{
+		url:      url,
+		token:    token,
+		maxAge:   maxAge,
+		client:   &http.Client{Timeout: 5 * time.Second},
+		log:      log,
+		tokens:   map[string]time.Time{},
+		sessions: map[uuid.UUID]time.Time{},
+		cutoffs:  map[uuid.UUID]time.Time{},
+	}
+}
+
+// Run загружает список сразу и затем каждые interval до отмены ctx
+func (r *Revocations) Run(ctx context.Context, interval time.Duration) {
+	if err := r.Refresh(ctx); err != nil {
+		r.log.Warn("Failed to load revocation list", zap.String("url", r.url), zap.Error(err))
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Refresh(ctx); err != nil {
+				r.log.Warn("Failed to refresh revocation list", zap.String("url", r.url), zap.Error(err))
+			}
+		}
+	}
+}
+
+// Refresh заменяет список актуальным
+func (r *Revocations) Refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+r.token)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("revocations: unexpected status %d", resp.StatusCode)
+	}
+	var doc struct {
+		Tokens []struct {
+			JTI       string    `json:"jti"`
+			ExpiresAt time.Time `json:"expires_at"`
+		} `json:"tokens"`
+		Sessions []struct {
+			SessionID uuid.UUID `json:"session_id"`
+			ExpiresAt time.Time `json:"expires_at"`
+		} `json:"sessions"`
+		Cutoffs []struct {
+			UserID        uuid.UUID `json:"user_id"`
+			RevokedBefore time.Time `json:"revoked_before"`
+		} `json:"cutoffs"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 32<<20)).Decode(&doc); err != nil {
+		return fmt.Errorf("revocations: %w", err)
+	}
+
+	tokens := make(map[string]time.Time, len(doc.Tokens))
+	for _, t := range doc.Tokens {
+		tokens[t.JTI] = t.ExpiresAt
+	}
+	sessions := make(map[uuid.UUID]time.Time, len(doc.Sessions))
+	for _, s := range doc.Sessions {
+		sessions[s.SessionID] = s.ExpiresAt
+	}
+	cutoffs := make(map[uuid.UUID]time.Time, len(doc.Cutoffs))
+	for _, c := range doc.Cutoffs {
+		cutoffs[c.UserID] = c.RevokedBefore
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens, r.sessions, r.cutoffs, r.loadedAt = tokens, sessions, cutoffs, time.Now()
+	return nil
+}
+
+// revoked повторяет проверку Auth Service: JTI или сессия (sid) в списке либо iat раньше отсечки пользователя
+func (r *Revocations) revoked(jti string, userID, sessionID uuid.UUID, issuedAt time.Time) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if time.Since(r.loadedAt) > r.maxAge {
+		return false, errRevocationsStale
+	}
+	if _, ok := r.tokens[jti]; ok {
+		return true, nil
+	}
+	if _, ok := r.sessions[sessionID]; ok && sessionID != uuid.Nil {
+		return true, nil
+	}
+	before, ok := r.cutoffs[userID]
+	return ok && before.After(issuedAt), nil
+}
@@ -0,0 +1,237 @@
+package authclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	authv1 "github.com/Anabol1ks/linkvault-proto/auth/v1"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidToken = errors.New("invalid access token")
+var ErrUnavailable = errors.New("auth service unavailable")
+
+type Config struct {
+	// Верхняя граница хранения положительного результата; фактически не дольше exp токена
+	CacheTTL time.Duration
+	// Сколько помнить отклонённый токен
+	NegativeTTL time.Duration
+	// Таймаут вызова ValidateAccessToken
+	Timeout time.Duration
+	// Ошибок подряд до размыкания и пауза перед пробным вызовом
+	BreakerFailures int
+	BreakerCooldown time.Duration
+}
+
+type entry struct {
+	userID    uuid.UUID
+	valid     bool
+	expiresAt time.Time
+}
+
+// Порог числа записей, после которого кэш вычищает истёкшие
+const cachePruneThreshold = 10000
+
+// Validator проверяет access-токены: локально по JWKS, если набор ключей задан,
+// иначе через Auth Service, кэшируя результат по хэшу токена
+type Validator struct {
+	client      authv1.AuthServiceClient
+	keys        *KeySet
+	revocations *Revocations
+	cfg         Config
+	breaker     *breaker
+	log         *zap.Logger
+
+	mu    sync.Mutex
+	cache map[[32]byte]entry
+}
+
+// NewValidator — keys и revocations необязательны: без keys токены проверяет Auth Service,
+// без revocations локальная проверка не видит отозванные токены
+func NewValidator(client authv1.AuthServiceClient, keys *KeySet, revocations *Revocations, cfg Config, log *zap.Logger) *Validator {
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = time.Minute
+	}
+	if cfg.NegativeTTL <= 0 {
+		cfg.NegativeTTL = 10 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 2 * time.Second
+	}
+	if cfg.BreakerFailures <= 0 {
+		cfg.BreakerFailures = 5
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = 10 * time.Second
+	}
+	return &Validator{
+		client:      client,
+		keys:        keys,
+		revocations: revocations,
+		cfg:         cfg,
+		breaker:     newBreaker(cfg.BreakerFailures, cfg.BreakerCooldown),
+		log:         log,
+		cache:       make(map[[32]byte]entry),
+	}
+}
+
+// Validate возвращает пользователя токена, ErrInvalidToken или ErrUnavailable,
+// если Auth Service недоступен и результата нет в кэше
+func (v *Validator) Validate(ctx context.Context, token string) (uuid.UUID, error) {
+	if v.keys != nil {
+		userID, err := v.verifyLocal(ctx, token)
+		if !errors.Is(err, errKeysUnavailable) && !errors.Is(err, errRevocationsStale) {
+			return userID, err
+		}
+		// JWKS или список отзыва недоступны — проверяем через Auth Service
+		v.log.Debug("Local validation unavailable, falling back to remote validation", zap.Error(err))
+	}
+
+	key := sha256.Sum256([]byte(token))
+	now := time.Now()
+	if e, ok := v.lookup(key, now); ok {
+		if !e.valid {
+			return uuid.Nil, ErrInvalidToken
+		}
+		return e.userID, nil
+	}
+
+	if !v.breaker.allow() {
+		return uuid.Nil, ErrUnavailable
+	}
+	callCtx, cancel := context.WithTimeout(ctx, v.cfg.Timeout)
+	defer cancel()
+	resp, err := v.client.ValidateAccessToken(callCtx, &authv1.ValidateAccessTokenRequest{AccessToken: token})
+	if err != nil && ctx.Err() != nil {
+		// Вызывающий отменил запрос или исчерпал свой дедлайн — Auth Service тут ни при чём
+		v.breaker.cancel()
+		return uuid.Nil, ErrUnavailable
+	}
+	if err != nil {
+		v.breaker.failure()
+		if v.breaker.open() {
+			v.log.Warn("Auth service circuit open", zap.Error(err))
+		}
+		return uuid.Nil, ErrUnavailable
+	}
+	v.breaker.success()
+
+	if !resp.Valid {
+		v.store(key, entry{expiresAt: now.Add(v.cfg.NegativeTTL)})
+		return uuid.Nil, ErrInvalidToken
+	}
+	userID, err := uuid.Parse(resp.UserId)
+	if err != nil {
+		return uuid.Nil, ErrInvalidToken
+	}
+	expiresAt := now.Add(v.cfg.CacheTTL)
+	if exp, ok := tokenExpiry(token); ok && exp.Before(expiresAt) {
+		expiresAt = exp
+	}
+	v.store(key, entry{userID: userID, valid: true, expiresAt: expiresAt})
+	return userID, nil
+}
+
+var errKeysUnavailable = errors.New("signing keys unavailable")
+
+type accessClaims struct {
+	UserID    string `json:"user_id"`
+	Type      string `json:"type"`
+	SessionID string `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// verifyLocal проверяет подпись (EdDSA / RS256), срок действия и тип токена по ключам из JWKS,
+// а затем — что токен не отозван
+func (v *Validator) verifyLocal(ctx context.Context, token string) (uuid.UUID, error) {
+	var claims accessClaims
+	parsed, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := v.keys.Key(ctx, kid)
+		if err != nil && !errors.Is(err, ErrUnknownKey) {
+			return nil, fmt.Errorf("%w: %v", errKeysUnavailable, err)
+		}
+		return key, err
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+	if errors.Is(err, errKeysUnavailable) {
+		return uuid.Nil, err
+	}
+	if err != nil || !parsed.Valid || claims.Type != "access" {
+		return uuid.Nil, ErrInvalidToken
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return uuid.Nil, ErrInvalidToken
+	}
+	if v.revocations != nil {
+		var issuedAt time.Time
+		if claims.IssuedAt != nil {
+			issuedAt = claims.IssuedAt.Time
+		}
+		// Без sid (токены до появления сессий) проверяются только JTI и отсечка
+		sessionID, _ := uuid.Parse(claims.SessionID)
+		revoked, err := v.revocations.revoked(claims.ID, userID, sessionID, issuedAt)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if revoked {
+			return uuid.Nil, ErrInvalidToken
+		}
+	}
+	return userID, nil
+}
+
+func (v *Validator) lookup(key [32]byte, now time.Time) (entry, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	e, ok := v.cache[key]
+	if !ok || !now.Before(e.expiresAt) {
+		return entry{}, false
+	}
+	return e, true
+}
+
+func (v *Validator) store(key [32]byte, e entry) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if len(v.cache) >= cachePruneThreshold {
+		now := time.Now()
+		for k, old := range v.cache {
+			if !now.Before(old.expiresAt) {
+				delete(v.cache, k)
+			}
+		}
+	}
+	v.cache[key] = e
+}
+
+// tokenExpiry читает claim exp из JWT без проверки подписи — только чтобы не кэшировать токен дольше его жизни
+func tokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Exp, 0), true
+}
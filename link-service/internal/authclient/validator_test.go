@@ -0,0 +1,276 @@
+package authclient
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	authv1 "github.com/Anabol1ks/linkvault-proto/auth/v1"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// fakeAuthClient отвечает на ValidateAccessToken; остальные методы не используются
+type fakeAuthClient struct {
+	authv1.AuthServiceClient
+	userID uuid.UUID
+	err    error
+	// Ждать отмены контекста вызова вместо ответа
+	hang  bool
+	calls int
+}
+
+func (c *fakeAuthClient) ValidateAccessToken(ctx context.Context, _ *authv1.ValidateAccessTokenRequest, _ ...grpc.CallOption) (*authv1.ValidateAccessTokenResponse, error) {
+	c.calls++
+	if c.hang {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &authv1.ValidateAccessTokenResponse{Valid: true, UserId: c.userID.String()}, nil
+}
+
+func newTestValidator(client *fakeAuthClient) *Validator {
+	return NewValidator(client, nil, nil, Config{Timeout: 50 * time.Millisecond, BreakerFailures: 2, BreakerCooldown: time.Minute}, zap.NewNop())
+}
+
+func TestValidateCallerCancellationDoesNotOpenBreaker(t *testing.T) {
+	client := &fakeAuthClient{userID: uuid.New(), hang: true}
+	v := newTestValidator(client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 0; i < 5; i++ {
+		if _, err := v.Validate(ctx, "token-canceled"); !errors.Is(err, ErrUnavailable) {
+			t.Fatalf("err = %v, want ErrUnavailable", err)
+		}
+	}
+	deadline, cancelDeadline := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancelDeadline()
+	<-deadline.Done()
+	if _, err := v.Validate(deadline, "token-deadline"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("err = %v, want ErrUnavailable", err)
+	}
+
+	client.hang = false
+	userID, err := v.Validate(context.Background(), "token-ok")
+	if err != nil || userID != client.userID {
+		t.Fatalf("Validate after caller cancellations = %v, %v; breaker must stay closed", userID, err)
+	}
+}
+
+func TestValidateServiceTimeoutOpensBreaker(t *testing.T) {
+	client := &fakeAuthClient{hang: true}
+	v := newTestValidator(client)
+
+	// Таймаут самого вызова (Config.Timeout) — ошибка Auth Service
+	for i := 0; i < 2; i++ {
+		if _, err := v.Validate(context.Background(), "token"); !errors.Is(err, ErrUnavailable) {
+			t.Fatalf("err = %v, want ErrUnavailable", err)
+		}
+	}
+	calls := client.calls
+	client.hang = false
+	if _, err := v.Validate(context.Background(), "token"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("err = %v, want ErrUnavailable from open breaker", err)
+	}
+	if client.calls != calls {
+		t.Fatal("open breaker let the call through")
+	}
+}
+
+func TestValidateCancelledProbeReleasesHalfOpenBreaker(t *testing.T) {
+	client := &fakeAuthClient{err: errors.New("connection refused")}
+	v := newTestValidator(client)
+	v.breaker.cooldown = 0
+	for i := 0; i < 2; i++ {
+		_, _ = v.Validate(context.Background(), "token")
+	}
+
+	// Пробный вызов прерван клиентом — следующий запрос снова может стать пробным
+	client.err, client.hang = nil, true
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _ = v.Validate(ctx, "token")
+
+	client.hang, client.userID = false, uuid.New()
+	if _, err := v.Validate(context.Background(), "token"); err != nil {
+		t.Fatalf("probe after cancelled probe: %v", err)
+	}
+}
+
+// authServer — JWKS и список отзыва Auth Service на httptest
+type authServer struct {
+	*httptest.Server
+	key      ed25519.PrivateKey
+	mu       sync.Mutex
+	tokens   []string
+	sessions []uuid.UUID
+	cutoffs  map[uuid.UUID]time.Time
+	down     bool
+}
+
+func newAuthServer(t *testing.T) *authServer {
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &authServer{key: key, cutoffs: map[uuid.UUID]time.Time{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		x := base64.RawURLEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+		fmt.Fprintf(w, `{"keys":[{"kty":"OKP","crv":"Ed25519","kid":"k1","x":%q}]}`, x)
+	})
+	mux.HandleFunc("/internal/revocations", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer feed-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		type token struct {
+			JTI string `json:"jti"`
+		}
+		type cutoff struct {
+			UserID        uuid.UUID `json:"user_id"`
+			RevokedBefore time.Time `json:"revoked_before"`
+		}
+		type session struct {
+			SessionID uuid.UUID `json:"session_id"`
+		}
+		doc := struct {
+			Tokens   []token   `json:"tokens"`
+			Sessions []session `json:"sessions"`
+			Cutoffs  []cutoff  `json:"cutoffs"`
+		}{}
+		for _, jti := range s.tokens {
+			doc.Tokens = append(doc.Tokens, token{JTI: jti})
+		}
+		for _, id := range s.sessions {
+			doc.Sessions = append(doc.Sessions, session{SessionID: id})
+		}
+		for userID, before := range s.cutoffs {
+			doc.Cutoffs = append(doc.Cutoffs, cutoff{UserID: userID, RevokedBefore: before})
+		}
+		_ = json.NewEncoder(w).Encode(doc)
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// sign выдаёт access-токен; sessionID == uuid.Nil — токен без sid
+func (s *authServer) sign(t *testing.T, jti string, userID, sessionID uuid.UUID, issuedAt time.Time) string {
+	var sid string
+	if sessionID != uuid.Nil {
+		sid = sessionID.String()
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, accessClaims{
+		UserID:    userID.String(),
+		Type:      "access",
+		SessionID: sid,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(15 * time.Minute)),
+		},
+	})
+	token.Header["kid"] = "k1"
+	signed, err := token.SignedString(s.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func newLocalValidator(t *testing.T, srv *authServer, client *fakeAuthClient) (*Validator, *Revocations) {
+	revocations := NewRevocations(srv.URL+"/internal/revocations", "feed-token", time.Minute, zap.NewNop())
+	if err := revocations.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	keys := NewKeySet(srv.URL+"/.well-known/jwks.json", zap.NewNop())
+	return NewValidator(client, keys, revocations, Config{}, zap.NewNop()), revocations
+}
+
+func TestLocalValidationRejectsRevokedTokens(t *testing.T) {
+	srv := newAuthServer(t)
+	client := &fakeAuthClient{}
+	v, revocations := newLocalValidator(t, srv, client)
+
+	userID, otherID, sessionUserID := uuid.New(), uuid.New(), uuid.New()
+	revokedSession, keptSession := uuid.New(), uuid.New()
+	issued := time.Now().Add(-time.Minute).Truncate(time.Second)
+	revokedJTI := srv.sign(t, "jti-revoked", otherID, uuid.Nil, issued)
+	beforeLogout := srv.sign(t, "jti-old", userID, uuid.Nil, issued)
+	afterLogout := srv.sign(t, "jti-new", userID, uuid.Nil, issued.Add(30*time.Second))
+	inRevokedSession := srv.sign(t, "jti-session", sessionUserID, revokedSession, issued)
+	inKeptSession := srv.sign(t, "jti-kept", sessionUserID, keptSession, issued)
+
+	for _, token := range []string{revokedJTI, beforeLogout, afterLogout, inRevokedSession, inKeptSession} {
+		if _, err := v.Validate(context.Background(), token); err != nil {
+			t.Fatalf("Validate before revocation: %v", err)
+		}
+	}
+
+	srv.mu.Lock()
+	srv.tokens = []string{"jti-revoked"}
+	srv.sessions = []uuid.UUID{revokedSession}
+	srv.cutoffs[userID] = issued.Add(10 * time.Second)
+	srv.mu.Unlock()
+	if err := revocations.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	for name, token := range map[string]string{"revoked jti": revokedJTI, "issued before cutoff": beforeLogout, "revoked session": inRevokedSession} {
+		if _, err := v.Validate(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: err = %v, want ErrInvalidToken", name, err)
+		}
+	}
+	if got, err := v.Validate(context.Background(), afterLogout); err != nil || got != userID {
+		t.Errorf("token issued after cutoff = %v, %v", got, err)
+	}
+	if got, err := v.Validate(context.Background(), inKeptSession); err != nil || got != sessionUserID {
+		t.Errorf("token of other session = %v, %v", got, err)
+	}
+	if client.calls != 0 {
+		t.Errorf("auth service called %d times, want local validation only", client.calls)
+	}
+}
+
+func TestLocalValidationFallsBackWhenRevocationsStale(t *testing.T) {
+	srv := newAuthServer(t)
+	client := &fakeAuthClient{userID: uuid.New()}
+	v, revocations := newLocalValidator(t, srv, client)
+	revocations.maxAge = 0
+
+	srv.mu.Lock()
+	srv.down = true
+	srv.mu.Unlock()
+	if err := revocations.Refresh(context.Background()); err == nil {
+		t.Fatal("Refresh against a failing server succeeded")
+	}
+
+	token := srv.sign(t, "jti-1", client.userID, uuid.Nil, time.Now())
+	if _, err := v.Validate(context.Background(), token); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if client.calls != 1 {
+		t.Fatalf("auth service called %d times, want remote validation with a stale list", client.calls)
+	}
+}
@@ -0,0 +1,76 @@
+// Package clientip определяет адрес клиента. Заголовку X-Forwarded-For (или metadata
+// x-forwarded-for в gRPC) можно верить, только если соединение пришло от своего шлюза:
+// иначе клиент подставил бы любой адрес и обошёл лимиты по IP и защиту от повторных жалоб.
+package clientip
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Resolver хранит адреса и подсети доверенных шлюзов; нулевое значение не доверяет никому
+type Resolver struct {
+	proxies []netip.Prefix
+}
+
+// NewResolver разбирает адреса и подсети доверенных шлюзов; некорректные пропускаются
+func NewResolver(values []string, log *zap.Logger) *Resolver {
+	r := &Resolver{}
+	for _, v := range values {
+		if p, err := netip.ParsePrefix(v); err == nil {
+			r.proxies = append(r.proxies, p.Masked())
+			continue
+		}
+		if addr, err := netip.ParseAddr(v); err == nil {
+			r.proxies = append(r.proxies, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		log.Warn("Invalid trusted proxy in config", zap.String("value", v))
+	}
+	return r
+}
+
+// Resolve возвращает адрес клиента по адресу соединения remote (host:port или host) и
+// значению X-Forwarded-For. Каждый шлюз дописывает адрес своего собеседника справа, а всё левее
+// мог прислать сам клиент, поэтому список читается справа налево, пока адреса принадлежат
+// доверенным шлюзам: первый чужой адрес и есть клиент. Если remote не доверенный шлюз,
+// заголовок не учитывается.
+func (r *Resolver) Resolve(remote, forwarded string) string {
+	host, _, err := net.SplitHostPort(remote)
+	if err != nil {
+		host = remote
+	}
+	if r == nil || forwarded == "" {
+		return host
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !r.trusted(addr.Unmap()) {
+		return host
+	}
+	hops := strings.Split(forwarded, ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		addr, err := netip.ParseAddr(hop)
+		if err != nil {
+			// Дальше цепочка не проверяема: остаётся последний адрес, записанный своим шлюзом
+			return host
+		}
+		host = hop
+		if !r.trusted(addr.Unmap()) {
+			return host
+		}
+	}
+	return host
+}
+
+func (r *Resolver) trusted(addr netip.Addr) bool {
+	for _, p := range r.proxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"link-service/internal/models"
+	"link-service/internal/repository"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SchemaVersion — версия формата событий ссылок
+const SchemaVersion = 1
+
+const (
+	TypeLinkCreated     = "link.created"
+	TypeLinkUpdated     = "link.updated"
+	TypeLinkDeactivated = "link.deactivated"
+	TypeLinkExpired     = "link.expired"
+	TypeClickRecorded   = "click.recorded"
+	TypeLinkBroken      = "link.broken"
+	TypeLinkRecovered   = "link.recovered"
+)
+
+// Event — конверт события жизненного цикла ссылки
+type Event struct {
+	ID         string         `json:"id"`
+	Type       string         `json:"type"`
+	Version    int            `json:"version"`
+	OccurredAt time.Time      `json:"occurred_at"`
+	LinkID     string         `json:"link_id"`
+	UserID     string         `json:"user_id,omitempty"`
+	Data       map[string]any `json:"data,omitempty"`
+}
+
+func New(eventType string, linkID uuid.UUID, userID *uuid.UUID, data map[string]any) Event {
+	e := Event{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		Version:    SchemaVersion,
+		OccurredAt: time.Now().UTC(),
+		LinkID:     linkID.String(),
+		Data:       data,
+	}
+	if userID != nil {
+		e.UserID = userID.String()
+	}
+	return e
+}
+
+// Outbox записывает события в таблицу outbox; в Kafka их отправляет релей (internal/outbox).
+// Ключ сообщения — ID ссылки, поэтому события одной ссылки упорядочены внутри партиции.
+type Outbox struct {
+	repo        *repository.OutboxRepository
+	topic       string
+	subscribers []Subscriber
+}
+
+// Subscriber получает событие в той же транзакции, что и outbox (например, вебхуки)
+type Subscriber interface {
+	Handle(tx *gorm.DB, e Event) error
+}
+
+func (o *Outbox) Subscribe(s Subscriber) {
+	o.subscribers = append(o.subscribers, s)
+}
+
+func NewOutbox(repo *repository.OutboxRepository, topic string) *Outbox {
+	return &Outbox{repo: repo, topic: topic}
+}
+
+// Enqueue добавляет событие в транзакции tx
+func (o *Outbox) Enqueue(tx *gorm.DB, e Event) error {
+	msg, err := models.NewOutboxMessage(o.topic, e.LinkID, e, map[string]string{
+		"event-type":     e.Type,
+		"schema-version": strconv.Itoa(e.Version),
+	})
+	if err != nil {
+		return err
+	}
+	if err := o.repo.WithTx(tx).Create(msg); err != nil {
+		return err
+	}
+	for _, s := range o.subscribers {
+		if err := s.Handle(tx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package linkcheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"link-service/internal/urlpolicy"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Result — итог одной проверки целевого URL
+type Result struct {
+	StatusCode int
+	Err        error
+	Latency    time.Duration
+}
+
+// Broken — цель недоступна: сетевая ошибка, 404/410 или 5xx.
+// Прочие 4xx (401, 403, 429) часто означают защиту от ботов и не считаются поломкой.
+func (r Result) Broken() bool {
+	if r.Err != nil {
+		return true
+	}
+	return r.StatusCode == http.StatusNotFound || r.StatusCode == http.StatusGone || r.StatusCode >= 500
+}
+
+type Config struct {
+	Timeout     time.Duration
+	Concurrency int
+	// Минимальный интервал между запросами к одному хосту
+	PerHostInterval time.Duration
+	UserAgent       string
+	AllowPrivate    bool
+}
+
+// Target — ссылка для проверки
+type Target struct {
+	ID  string
+	URL string
+}
+
+type Checker struct {
+	client      *http.Client
+	concurrency int
+	limiter     *hostLimiter
+	userAgent   string
+}
+
+func NewChecker(cfg Config) *Checker {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 8
+	}
+	if cfg.PerHostInterval <= 0 {
+		cfg.PerHostInterval = time.Second
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = "LinkVaultBot/1.0 (+healthcheck)"
+	}
+
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivate {
+		dialer.Control = urlpolicy.DialControl
+	}
+	return &Checker{
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			Transport: &http.Transport{
+				// Без прокси: соединение через прокси обошло бы проверку адреса в DialControl
+				Proxy:                 nil,
+				DialContext:           dialer.DialContext,
+				TLSHandshakeTimeout:   cfg.Timeout,
+				ResponseHeaderTimeout: cfg.Timeout,
+				MaxIdleConnsPerHost:   2,
+				IdleConnTimeout:       30 * time.Second,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 10 {
+					return fmt.Errorf("stopped after %d redirects", len(via))
+				}
+				return nil
+			},
+		},
+		concurrency: cfg.Concurrency,
+		limiter:     newHostLimiter(cfg.PerHostInterval),
+		userAgent:   cfg.UserAgent,
+	}
+}
+
+// Check проверяет URL запросом HEAD; если сервер не поддерживает HEAD — повторяет GET
+func (c *Checker) Check(ctx context.Context, rawURL string) Result {
+	if err := c.waitHost(ctx, rawURL); err != nil {
+		return Result{Err: err}
+	}
+	return c.check(ctx, rawURL)
+}
+
+func (c *Checker) waitHost(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	return c.limiter.wait(ctx, strings.ToLower(u.Hostname()))
+}
+
+func (c *Checker) check(ctx context.Context, rawURL string) Result {
+	start := time.Now()
+	code, err := c.do(ctx, http.MethodHead, rawURL)
+	if err != nil || code == http.StatusMethodNotAllowed || code == http.StatusNotImplemented || code == http.StatusForbidden {
+		code, err = c.do(ctx, http.MethodGet, rawURL)
+	}
+	return Result{StatusCode: code, Err: err, Latency: time.Since(start)}
+}
+
+func (c *Checker) do(ctx context.Context, method, rawURL string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	if method == http.MethodGet {
+		// Тело не нужно — просим минимум
+		req.Header.Set("Range", "bytes=0-0")
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// CheckAll проверяет цели с ограничением параллелизма; fn вызывается для каждого результата.
+// Слот параллелизма занимается только после паузы для хоста: проверки, ждущие своей очереди
+// к одному хосту, не должны простаивать в слотах и задерживать проверки других хостов.
+// Цели, не проверенные из-за отмены ctx, в fn не передаются.
+func (c *Checker) CheckAll(ctx context.Context, targets []Target, fn func(Target, Result)) {
+	sem := make(chan struct{}, c.concurrency)
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(t Target) {
+			defer wg.Done()
+			if err := c.waitHost(ctx, t.URL); err != nil {
+				if ctx.Err() == nil {
+					fn(t, Result{Err: err})
+				}
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case sem <- struct{}{}:
+			}
+			defer func() { <-sem }()
+			fn(t, c.check(ctx, t.URL))
+		}(t)
+	}
+	wg.Wait()
+}
+
+// hostLimiter выдерживает минимальный интервал между запросами к одному хосту
+type hostLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     map[string]time.Time
+}
+
+func newHostLimiter(interval time.Duration) *hostLimiter {
+	return &hostLimiter{interval: interval, next: make(map[string]time.Time)}
+}
+
+func (l *hostLimiter) wait(ctx context.Context, host string) error {
+	l.mu.Lock()
+	now := time.Now()
+	if len(l.next) > 1024 {
+		for h, t := range l.next {
+			if t.Before(now) {
+				delete(l.next, h)
+			}
+		}
+	}
+	slot := l.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next[host] = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(slot)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -2,26 +2,65 @@ package maintenance
 
 import (
 	"context"
+	"link-service/internal/models"
+	"link-service/internal/ratelimit"
 	"link-service/internal/repository"
+	"link-service/internal/service"
+	"time"
 
 	"github.com/robfig/cron/v3"
 	"go.uber.org/zap"
 )
 
 type Scheduler struct {
-	c         *cron.Cron
-	log       *zap.Logger
-	shortRepo *repository.ShortLinkRepository
-	clickRepo *repository.ClickRepository
+	c          *cron.Cron
+	log        *zap.Logger
+	shortRepo  *repository.ShortLinkRepository
+	clickRepo  *repository.ClickRepository
+	reportRepo *repository.LinkReportRepository
+	healthRepo *repository.LinkHealthRepository
+	outboxRepo *repository.OutboxRepository
+	hookRepo   *repository.WebhookRepository
+
+	outboxRetention time.Duration
+
+	shortService  *service.ShortLinkService
+	healthService *service.HealthService
+	quotaService  *service.QuotaService
+	rateStore     *ratelimit.SharedStore
 }
 
-func NewScheduler(log *zap.Logger, shortRepo *repository.ShortLinkRepository, clickRepo *repository.ClickRepository) *Scheduler {
+func NewScheduler(
+	log *zap.Logger,
+	shortRepo *repository.ShortLinkRepository,
+	clickRepo *repository.ClickRepository,
+	reportRepo *repository.LinkReportRepository,
+	healthRepo *repository.LinkHealthRepository,
+	outboxRepo *repository.OutboxRepository,
+	outboxRetention time.Duration,
+	hookRepo *repository.WebhookRepository,
+	shortService *service.ShortLinkService,
+	healthService *service.HealthService,
+	quotaService *service.QuotaService,
+	rateStore *ratelimit.SharedStore,
+) *Scheduler {
 	// Используем cron с секундами отключёнными (стандартный 5-полюсный синтаксис) и локацией из системы.
 	c := cron.New(cron.WithParser(cron.NewParser(cron.Minute|cron.Hour|cron.Dom|cron.Month|cron.Dow)), cron.WithChain())
 	return &Scheduler{
 		c: c, log: log,
-		shortRepo: shortRepo,
-		clickRepo: clickRepo,
+		shortRepo:  shortRepo,
+		clickRepo:  clickRepo,
+		reportRepo: reportRepo,
+		healthRepo: healthRepo,
+		outboxRepo: outboxRepo,
+		hookRepo:   hookRepo,
+
+		outboxRetention: outboxRetention,
+
+		shortService:  shortService,
+		healthService: healthService,
+		quotaService:  quotaService,
+		rateStore:     rateStore,
 	}
 }
 
@@ -32,6 +71,18 @@ func (s *Scheduler) Start(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	_, err = s.c.AddFunc("15 * * * *", func() {
+		s.refreshMetadata(ctx)
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.c.AddFunc("30 */6 * * *", func() {
+		s.checkLinkHealth(ctx)
+	})
+	if err != nil {
+		return err
+	}
 	s.c.Start()
 	s.log.Info("Запущен планировщик")
 	// Очистка при старте
@@ -47,18 +98,21 @@ func (s *Scheduler) Start(ctx context.Context) error {
 
 func (s *Scheduler) cleanOldLinksAndClicks() {
 	// Автоматическая деактивация ссылок
-	if err := s.shortRepo.DeactivateExpiredAnonLinks(); err == nil {
-		s.log.Info("Деактивированы истёкшие анонимные ссылки")
-	}
-	if err := s.shortRepo.DeactivateExpiredUserLinks(); err == nil {
-		s.log.Info("Деактивированы истёкшие пользовательские ссылки")
+	if count, err := s.shortService.DeactivateExpiredLinks(); err != nil {
+		s.log.Error("Ошибка деактивации истёкших ссылок", zap.Error(err))
+	} else if count > 0 {
+		s.log.Info("Деактивированы истёкшие ссылки", zap.Int("count", count))
 	}
+	s.cleanHealthHistory()
+	s.cleanOutbox()
+	s.cleanWebhookDeliveries()
+	s.cleanAPIUsage()
+	s.cleanRateLimitBuckets()
 	s.log.Info("Запущена очистка старых ссылок и кликов")
 	anonLinks, err := s.shortRepo.FindExpiredAnonLinks()
 	if err == nil {
 		for _, link := range anonLinks {
-			s.clickRepo.DeleteClicksByShortLinkID(link.ID)
-			s.shortRepo.DeleteLink(link)
+			s.deleteLink(link)
 			s.log.Info("Удалена анонимная истёкшая ссылка", zap.String("short_code", link.ShortCode))
 		}
 	}
@@ -66,8 +120,7 @@ func (s *Scheduler) cleanOldLinksAndClicks() {
 	anonInactiveLinks, err := s.shortRepo.FindExpiredInactiveAnonLinks()
 	if err == nil {
 		for _, link := range anonInactiveLinks {
-			s.clickRepo.DeleteClicksByShortLinkID(link.ID)
-			s.shortRepo.DeleteLink(link)
+			s.deleteLink(link)
 			s.log.Info("Удалена анонимная деактивированная ссылка", zap.String("short_code", link.ShortCode))
 		}
 	}
@@ -75,9 +128,52 @@ func (s *Scheduler) cleanOldLinksAndClicks() {
 	userLinks, err := s.shortRepo.FindExpiredInactiveUserLinks()
 	if err == nil {
 		for _, link := range userLinks {
-			s.clickRepo.DeleteClicksByShortLinkID(link.ID)
-			s.shortRepo.DeleteLink(link)
+			s.deleteLink(link)
 			s.log.Info("Удалена пользовательская деактивированная истёкшая ссылка", zap.String("short_code", link.ShortCode))
 		}
 	}
 }
+
+// deleteLink удаляет ссылку вместе со связанными кликами, жалобами и историей проверок
+func (s *Scheduler) deleteLink(link *models.ShortLink) {
+	s.clickRepo.DeleteClicksByShortLinkID(link.ID)
+	if s.reportRepo != nil {
+		s.reportRepo.DeleteByShortLinkID(link.ID)
+	}
+	if s.healthRepo != nil {
+		s.healthRepo.DeleteByShortLinkID(link.ID)
+	}
+	s.shortRepo.DeleteLink(link)
+}
+
+// cleanOutbox удаляет отправленные сообщения outbox старше outboxRetention
+func (s *Scheduler) cleanOutbox() {
+	if s.outboxRepo == nil || s.outboxRetention <= 0 {
+		return
+	}
+	deleted, err := s.outboxRepo.DeleteDeliveredBefore(time.Now().Add(-s.outboxRetention))
+	if err != nil {
+		s.log.Error("Ошибка очистки отправленных сообщений outbox", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		s.log.Info("Удалены отправленные сообщения outbox", zap.Int64("count", deleted))
+	}
+}
+
+const webhookDeliveryRetention = 30 * 24 * time.Hour
+
+// cleanWebhookDeliveries удаляет завершённые доставки вебхуков из журнала
+func (s *Scheduler) cleanWebhookDeliveries() {
+	if s.hookRepo == nil {
+		return
+	}
+	deleted, err := s.hookRepo.DeleteDeliveriesBefore(time.Now().Add(-webhookDeliveryRetention))
+	if err != nil {
+		s.log.Error("Ошибка очистки журнала доставок вебхуков", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		s.log.Info("Удалены старые доставки вебхуков", zap.Int64("count", deleted))
+	}
+}
@@ -0,0 +1,39 @@
+package maintenance
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const healthHistoryRetention = 30 * 24 * time.Hour
+
+// checkLinkHealth проверяет доступность целевых URL пользовательских ссылок
+func (s *Scheduler) checkLinkHealth(ctx context.Context) {
+	if s.healthService == nil {
+		return
+	}
+	checked, err := s.healthService.RunChecks(ctx)
+	if err != nil {
+		s.log.Error("Ошибка проверки доступности ссылок", zap.Error(err))
+		return
+	}
+	if checked > 0 {
+		s.log.Info("Проверена доступность ссылок", zap.Int("count", checked))
+	}
+}
+
+func (s *Scheduler) cleanHealthHistory() {
+	if s.healthService == nil {
+		return
+	}
+	deleted, err := s.healthService.CleanupHistory(healthHistoryRetention)
+	if err != nil {
+		s.log.Error("Ошибка очистки истории проверок", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		s.log.Info("Удалена старая история проверок ссылок", zap.Int64("count", deleted))
+	}
+}
@@ -0,0 +1,38 @@
+package maintenance
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	metadataBatchSize = 100
+	metadataMaxAge    = 7 * 24 * time.Hour
+)
+
+// refreshMetadata догружает метаданные ссылок, у которых их нет или они устарели
+func (s *Scheduler) refreshMetadata(ctx context.Context) {
+	if s.shortService == nil {
+		return
+	}
+	links, err := s.shortRepo.FindWithoutMetadata(time.Now().Add(-metadataMaxAge), metadataBatchSize)
+	if err != nil {
+		s.log.Error("Ошибка выборки ссылок для загрузки метаданных", zap.Error(err))
+		return
+	}
+	for _, link := range links {
+		if ctx.Err() != nil {
+			return
+		}
+		fetchCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		if err := s.shortService.RefreshMetadata(fetchCtx, link); err != nil {
+			s.log.Warn("Не удалось сохранить метаданные ссылки", zap.String("short_code", link.ShortCode), zap.Error(err))
+		}
+		cancel()
+	}
+	if len(links) > 0 {
+		s.log.Info("Обновлены метаданные ссылок", zap.Int("count", len(links)))
+	}
+}
@@ -0,0 +1,41 @@
+package maintenance
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Сколько дней хранить дневные счётчики вызовов API
+const apiUsageRetentionDays = 90
+
+func (s *Scheduler) cleanAPIUsage() {
+	if s.quotaService == nil {
+		return
+	}
+	deleted, err := s.quotaService.CleanupAPIUsage(apiUsageRetentionDays)
+	if err != nil {
+		s.log.Error("Ошибка очистки счётчиков вызовов API", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		s.log.Info("Удалены старые счётчики вызовов API", zap.Int64("count", deleted))
+	}
+}
+
+// Бакет, не использовавшийся дольше часа, заведомо полон — его можно удалить
+const rateLimitIdleRetention = time.Hour
+
+func (s *Scheduler) cleanRateLimitBuckets() {
+	if s.rateStore == nil {
+		return
+	}
+	deleted, err := s.rateStore.DeleteIdleBefore(time.Now().Add(-rateLimitIdleRetention))
+	if err != nil {
+		s.log.Error("Ошибка очистки бакетов ограничения частоты", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		s.log.Info("Удалены неиспользуемые бакеты ограничения частоты", zap.Int64("count", deleted))
+	}
+}
@@ -0,0 +1,238 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"link-service/internal/urlpolicy"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+var ErrNotHTML = errors.New("destination is not an html page")
+
+const (
+	maxTitleLength       = 256
+	maxDescriptionLength = 1024
+	maxURLLength         = 2048
+)
+
+// Metadata — сведения о целевой странице ссылки
+type Metadata struct {
+	Title       string
+	Description string
+	Image       string
+	Favicon     string
+}
+
+type Config struct {
+	Timeout      time.Duration
+	MaxBodyBytes int64
+	MaxRedirects int
+	UserAgent    string
+	// AllowPrivate разрешает обращения к приватным адресам (только для тестов)
+	AllowPrivate bool
+}
+
+// Fetcher загружает HTML целевой страницы и извлекает title, description, og:image и favicon
+type Fetcher struct {
+	client       *http.Client
+	maxBodyBytes int64
+	userAgent    string
+}
+
+func NewFetcher(cfg Config) *Fetcher {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = 512 << 10
+	}
+	if cfg.MaxRedirects <= 0 {
+		cfg.MaxRedirects = 5
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = "LinkVaultBot/1.0 (+metadata)"
+	}
+
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivate {
+		dialer.Control = urlpolicy.DialControl
+	}
+
+	// Без прокси: соединение через прокси обошло бы проверку адреса в DialControl
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   cfg.Timeout,
+		ResponseHeaderTimeout: cfg.Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+	maxRedirects := cfg.MaxRedirects
+	return &Fetcher{
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: transport,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					return fmt.Errorf("stopped after %d redirects", maxRedirects)
+				}
+				return nil
+			},
+		},
+		maxBodyBytes: cfg.MaxBodyBytes,
+		userAgent:    cfg.UserAgent,
+	}
+}
+
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Metadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.1")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || (mediaType != "text/html" && mediaType != "application/xhtml+xml") {
+			return nil, ErrNotHTML
+		}
+	}
+
+	// Итоговый URL после редиректов — база для относительных ссылок
+	meta := parse(io.LimitReader(resp.Body, f.maxBodyBytes), resp.Request.URL)
+	return meta, nil
+}
+
+func parse(r io.Reader, base *url.URL) *Metadata {
+	var (
+		meta    Metadata
+		ogTitle string
+		ogDesc  string
+		inTitle bool
+		title   strings.Builder
+	)
+
+	z := html.NewTokenizer(r)
+loop:
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			// EOF или обрыв по лимиту размера — используем то, что успели разобрать
+			break loop
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := string(name)
+			attrs := map[string]string{}
+			for hasAttr {
+				var k, v []byte
+				k, v, hasAttr = z.TagAttr()
+				attrs[strings.ToLower(string(k))] = string(v)
+			}
+			switch tag {
+			case "title":
+				inTitle = tt == html.StartTagToken
+			case "meta":
+				key := strings.ToLower(attrs["property"])
+				if key == "" {
+					key = strings.ToLower(attrs["name"])
+				}
+				content := strings.TrimSpace(attrs["content"])
+				switch key {
+				case "og:title":
+					ogTitle = content
+				case "og:description":
+					ogDesc = content
+				case "description":
+					if meta.Description == "" {
+						meta.Description = content
+					}
+				case "og:image", "og:image:url", "og:image:secure_url":
+					if meta.Image == "" {
+						meta.Image = resolve(base, content)
+					}
+				}
+			case "link":
+				rel := strings.ToLower(attrs["rel"])
+				if meta.Favicon == "" && (rel == "icon" || rel == "shortcut icon" || rel == "apple-touch-icon") {
+					meta.Favicon = resolve(base, attrs["href"])
+				}
+			case "body":
+				// Всё нужное находится в <head>
+				break loop
+			}
+		case html.TextToken:
+			if inTitle {
+				title.Write(z.Text())
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); string(name) == "title" {
+				inTitle = false
+			}
+		}
+	}
+
+	meta.Title = strings.Join(strings.Fields(title.String()), " ")
+	if meta.Title == "" {
+		meta.Title = ogTitle
+	}
+	if ogDesc != "" {
+		meta.Description = ogDesc
+	}
+	if meta.Favicon == "" && base != nil {
+		meta.Favicon = resolve(base, "/favicon.ico")
+	}
+
+	meta.Title = truncate(meta.Title, maxTitleLength)
+	meta.Description = truncate(meta.Description, maxDescriptionLength)
+	if len(meta.Image) > maxURLLength {
+		meta.Image = ""
+	}
+	if len(meta.Favicon) > maxURLLength {
+		meta.Favicon = ""
+	}
+	return &meta
+}
+
+func resolve(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || base == nil {
+		return ""
+	}
+	u, err := base.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return u.String()
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	s = s[:max]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,147 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestFetcher(allowPrivate bool) *Fetcher {
+	return NewFetcher(Config{Timeout: 2 * time.Second, MaxBodyBytes: 64 << 10, AllowPrivate: allowPrivate})
+}
+
+func TestFetchParsesHeadAfterRedirect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/start", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/articles/page", http.StatusFound)
+	})
+	mux.HandleFunc("/articles/page", func(w http.ResponseWriter, r *http.Request) {
+		if ua := r.Header.Get("User-Agent"); !strings.HasPrefix(ua, "LinkVaultBot/") {
+			t.Errorf("unexpected User-Agent %q", ua)
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<!DOCTYPE html><html><head>
+<title>  Привет,
+  мир </title>
+<meta name="description" content="plain description">
+<meta property="og:description" content=" og description ">
+<meta property="og:image" content="/static/cover.png">
+<link rel="icon" href="icon.png">
+</head><body><title>ignored</title></body></html>`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	meta, err := newTestFetcher(true).Fetch(context.Background(), srv.URL+"/start")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	want := Metadata{
+		Title:       "Привет, мир",
+		Description: "og description",
+		Image:       srv.URL + "/static/cover.png",
+		Favicon:     srv.URL + "/articles/icon.png",
+	}
+	if *meta != want {
+		t.Fatalf("got %+v, want %+v", *meta, want)
+	}
+}
+
+func TestFetchFallsBackToOGTitleAndDefaultFavicon(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><meta property="og:title" content="OG title"></head></html>`)
+	}))
+	defer srv.Close()
+
+	meta, err := newTestFetcher(true).Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if meta.Title != "OG title" {
+		t.Errorf("Title = %q, want og:title", meta.Title)
+	}
+	if meta.Favicon != srv.URL+"/favicon.ico" {
+		t.Errorf("Favicon = %q, want default /favicon.ico", meta.Favicon)
+	}
+}
+
+func TestFetchRejectsNonHTML(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		fmt.Fprint(w, "%PDF-1.4")
+	}))
+	defer srv.Close()
+
+	if _, err := newTestFetcher(true).Fetch(context.Background(), srv.URL); !errors.Is(err, ErrNotHTML) {
+		t.Fatalf("err = %v, want ErrNotHTML", err)
+	}
+}
+
+func TestFetchRejectsErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	}))
+	defer srv.Close()
+
+	if _, err := newTestFetcher(true).Fetch(context.Background(), srv.URL); err == nil {
+		t.Fatal("expected error for 410 response")
+	}
+}
+
+func TestFetchStopsAfterMaxRedirects(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Path+"x", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	f := NewFetcher(Config{Timeout: 2 * time.Second, MaxRedirects: 2, AllowPrivate: true})
+	if _, err := f.Fetch(context.Background(), srv.URL+"/"); err == nil || !strings.Contains(err.Error(), "redirects") {
+		t.Fatalf("err = %v, want redirect limit error", err)
+	}
+}
+
+func TestFetchReadsAtMostMaxBodyBytes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html><head><!--"+strings.Repeat("x", 4096)+"--><title>late</title></head></html>")
+	}))
+	defer srv.Close()
+
+	f := NewFetcher(Config{Timeout: 2 * time.Second, MaxBodyBytes: 1024, AllowPrivate: true})
+	meta, err := f.Fetch(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if meta.Title != "" {
+		t.Fatalf("Title = %q, want empty: title is past the body limit", meta.Title)
+	}
+}
+
+func TestFetchBlocksPrivateAddresses(t *testing.T) {
+	var called bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	if _, err := newTestFetcher(false).Fetch(context.Background(), srv.URL); err == nil {
+		t.Fatal("expected loopback address to be rejected")
+	}
+	if called {
+		t.Fatal("request reached the loopback server")
+	}
+}
+
+func TestFetcherDoesNotUseProxy(t *testing.T) {
+	// Соединение через прокси из окружения обошло бы проверку адреса в DialControl
+	transport := newTestFetcher(false).client.Transport.(*http.Transport)
+	if transport.Proxy != nil {
+		t.Fatal("fetcher transport must not use a proxy")
+	}
+}
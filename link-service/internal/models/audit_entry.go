@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditEntry — запись журнала действий над ссылками
+type AuditEntry struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	ActorID     *uuid.UUID `gorm:"type:uuid;index"`
+	ActorIP     string     `gorm:"type:text"`
+	Action      string     `gorm:"type:text;index;not null"`
+	ShortLinkID *uuid.UUID `gorm:"type:uuid;index"`
+	ReportID    *uuid.UUID `gorm:"type:uuid"`
+	Details     string     `gorm:"type:text"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime;index"`
+}
+
+func (m *AuditEntry) BeforeCreate(tx *gorm.DB) (err error) {
+	m.ID = uuid.New()
+	return
+}
@@ -16,6 +16,7 @@ type Click struct {
 	UserAgent string    `gorm:"type:text;not null"`
 	Country   string    `gorm:"type:text;not null"`
 	Region    string    `gorm:"type:text;not null"`
+	Source    string    `gorm:"type:text;not null;default:'direct'"` // direct / qr
 	ClickedAt time.Time `gorm:"autoCreateTime"`
 }
 
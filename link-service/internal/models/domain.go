@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Domain — пользовательский (брендированный) домен для коротких ссылок.
+// Неподтверждённых заявок на один хост может быть несколько; уникален только подтверждённый домен,
+// иначе любой пользователь мог бы занять чужой хост, не подтверждая его.
+type Domain struct {
+	ID                uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID            uuid.UUID `gorm:"type:uuid;index;not null"`
+	Host              string    `gorm:"type:text;not null;uniqueIndex:idx_domains_verified_host,where:verified = true AND deleted_at IS NULL"`
+	VerificationToken string    `gorm:"type:text;not null"`
+	Verified          bool      `gorm:"not null;default:false"`
+	VerifiedAt        *time.Time
+	CreatedAt         time.Time `gorm:"autoCreateTime"`
+	// Удалённый домен остаётся в таблице: на него ссылаются деактивированные ссылки
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+func (m *Domain) BeforeCreate(tx *gorm.DB) (err error) {
+	m.ID = uuid.New()
+	return
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Folder struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_folder_user_name"`
+	Name      string    `gorm:"type:text;not null;uniqueIndex:idx_folder_user_name"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (m *Folder) BeforeCreate(tx *gorm.DB) (err error) {
+	m.ID = uuid.New()
+	return
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LinkHealthCheck — результат одной проверки доступности OriginalURL
+type LinkHealthCheck struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ShortLinkID uuid.UUID `gorm:"type:uuid;index;not null"`
+	StatusCode  int       `gorm:"not null"`
+	Error       string    `gorm:"type:text"`
+	Healthy     bool      `gorm:"not null"`
+	LatencyMs   int64     `gorm:"not null"`
+	CheckedAt   time.Time `gorm:"index;not null"`
+}
+
+func (m *LinkHealthCheck) BeforeCreate(tx *gorm.DB) (err error) {
+	m.ID = uuid.New()
+	return
+}
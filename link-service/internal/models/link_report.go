@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+)
+
+type LinkReport struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ShortLinkID uuid.UUID `gorm:"type:uuid;index;not null"`
+	ShortLink   ShortLink `gorm:"foreignKey:ShortLinkID"`
+
+	ReporterIP     string     `gorm:"type:text;not null"`
+	ReporterUserID *uuid.UUID `gorm:"type:uuid"`
+	Reason         string     `gorm:"type:text;not null"`
+	Details        string     `gorm:"type:text"`
+	Status         string     `gorm:"type:text;index;not null"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime"`
+
+	ResolvedAt *time.Time
+	ResolvedBy *uuid.UUID `gorm:"type:uuid"`
+}
+
+func (m *LinkReport) BeforeCreate(tx *gorm.DB) (err error) {
+	m.ID = uuid.New()
+	return
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxMessage — сообщение для Kafka, записанное в одной транзакции с изменением данных.
+// Числовой ID задаёт порядок отправки.
+type OutboxMessage struct {
+	ID            uint64     `gorm:"primaryKey;autoIncrement"`
+	Topic         string     `gorm:"type:text;not null"`
+	Key           string     `gorm:"type:text;not null"`
+	Payload       []byte     `gorm:"type:bytea;not null"`
+	Headers       string     `gorm:"type:text"` // JSON-объект заголовков
+	Attempts      int        `gorm:"not null;default:0"`
+	LastError     string     `gorm:"type:text"`
+	NextAttemptAt time.Time  `gorm:"not null"`
+	DeliveredAt   *time.Time `gorm:"index"`
+	DeadAt        *time.Time `gorm:"index"` // попытки исчерпаны, релей больше не отправляет сообщение
+	CreatedAt     time.Time  `gorm:"autoCreateTime"`
+}
+
+// NewOutboxMessage сериализует payload в JSON
+func NewOutboxMessage(topic, key string, payload any, headers map[string]string) (*OutboxMessage, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	m := &OutboxMessage{
+		Topic:         topic,
+		Key:           key,
+		Payload:       body,
+		NextAttemptAt: time.Now(),
+	}
+	if len(headers) > 0 {
+		h, err := json.Marshal(headers)
+		if err != nil {
+			return nil, err
+		}
+		m.Headers = string(h)
+	}
+	return m, nil
+}
+
+func (m *OutboxMessage) HeaderMap() map[string]string {
+	headers := map[string]string{}
+	if m.Headers != "" {
+		_ = json.Unmarshal([]byte(m.Headers), &headers)
+	}
+	return headers
+}
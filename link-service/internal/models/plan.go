@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserPlan — тарифный план пользователя; без записи действует план по умолчанию
+type UserPlan struct {
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Plan      string    `gorm:"type:text;not null"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// APIUsage — счётчик вызовов API пользователя за сутки (UTC)
+type APIUsage struct {
+	UserID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Day    time.Time `gorm:"type:date;primaryKey"`
+	Calls  int64     `gorm:"not null;default:0"`
+}
+
+func (APIUsage) TableName() string {
+	return "api_usage"
+}
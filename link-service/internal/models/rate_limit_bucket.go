@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// RateLimitBucket — состояние token bucket в общем хранилище лимитов
+type RateLimitBucket struct {
+	Key       string    `gorm:"type:text;primaryKey"`
+	Tokens    float64   `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null;index"`
+}
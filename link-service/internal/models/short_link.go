@@ -11,13 +11,54 @@ type ShortLink struct {
 	ID          uuid.UUID  `gorm:"type:uuid;primaryKey"`
 	UserID      *uuid.UUID `gorm:"type:uuid"`
 	OriginalURL string     `gorm:"type:text;not null"`
-	ShortCode   string     `gorm:"type:text;unique;not null"`
+	ShortCode   string     `gorm:"type:text;not null;uniqueIndex:idx_short_links_default_code,where:domain_id IS NULL;uniqueIndex:idx_short_links_domain_code,priority:2"`
 	IsActive    bool       `gorm:"not null"`
 	ExpireAt    *time.Time
 	CreatedAt   time.Time `gorm:"autoCreateTime"`
 
 	DeactivatedAt *time.Time
 
+	// Короткий код задан пользователем (считается в квоте MaxCustomAliases)
+	IsCustomAlias bool `gorm:"not null;default:false"`
+
+	// Брендированный домен; nil — основной домен сервиса (cfg.Domain)
+	DomainID *uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_short_links_domain_code,priority:1"`
+	Domain   *Domain    `gorm:"foreignKey:DomainID"`
+
+	// Мониторинг доступности OriginalURL
+	IsBroken            bool `gorm:"not null;default:false"`
+	HealthFailures      int  `gorm:"not null;default:0"` // подряд неудачных проверок
+	HealthLastCheckedAt *time.Time
+
+	// Пользовательские описания
+	Title string `gorm:"type:text"`
+	Notes string `gorm:"type:text"`
+
+	// Метаданные целевой страницы (заполняются фоновым загрузчиком)
+	MetaTitle       string `gorm:"type:text"`
+	MetaDescription string `gorm:"type:text"`
+	MetaImage       string `gorm:"type:text"`
+	MetaFavicon     string `gorm:"type:text"`
+	MetaFetchedAt   *time.Time
+
+	// Блокировка администратором (в отличие от деактивации владельцем)
+	IsBlocked   bool `gorm:"not null;default:false"`
+	BlockedAt   *time.Time
+	BlockReason string `gorm:"type:text"`
+
+	// UTM-метки, добавляемые к OriginalURL при редиректе
+	UTMSource   string `gorm:"type:text"`
+	UTMMedium   string `gorm:"type:text"`
+	UTMCampaign string `gorm:"type:text"`
+	UTMTerm     string `gorm:"type:text"`
+	UTMContent  string `gorm:"type:text"`
+	// Пробрасывать query-параметры короткой ссылки в OriginalURL
+	ForwardQuery bool `gorm:"not null;default:false"`
+
+	FolderID *uuid.UUID `gorm:"type:uuid;index"`
+	Folder   *Folder    `gorm:"foreignKey:FolderID"`
+	Tags     []Tag      `gorm:"many2many:short_link_tags;"`
+
 	Clicks []Click `gorm:"foreignKey:ShortLinkID"`
 }
 
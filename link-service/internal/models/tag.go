@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Tag struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_tag_user_name"`
+	Name      string    `gorm:"type:text;not null;uniqueIndex:idx_tag_user_name"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+
+	ShortLinks []ShortLink `gorm:"many2many:short_link_tags;"`
+}
+
+func (m *Tag) BeforeCreate(tx *gorm.DB) (err error) {
+	m.ID = uuid.New()
+	return
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebhookEndpoint — URL пользователя, на который отправляются события его ссылок
+type WebhookEndpoint struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey"`
+	UserID     uuid.UUID `gorm:"type:uuid;index;not null"`
+	URL        string    `gorm:"type:text;not null"`
+	Secret     string    `gorm:"type:text;not null"`
+	EventTypes string    `gorm:"type:text;not null"` // через запятую
+	Active     bool      `gorm:"not null;default:true"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+}
+
+func (m *WebhookEndpoint) BeforeCreate(tx *gorm.DB) (err error) {
+	m.ID = uuid.New()
+	return
+}
+
+func (m *WebhookEndpoint) Subscribed(eventType string) bool {
+	for _, t := range strings.Split(m.EventTypes, ",") {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Статусы доставки вебхука
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookDelivery — одна доставка события на endpoint, она же запись журнала доставок
+type WebhookDelivery struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey"`
+	EndpointID    uuid.UUID `gorm:"type:uuid;index;not null"`
+	EventID       string    `gorm:"type:text;not null"`
+	EventType     string    `gorm:"type:text;not null"`
+	Payload       []byte    `gorm:"type:bytea;not null"`
+	Status        string    `gorm:"type:text;not null;index"`
+	Attempts      int       `gorm:"not null;default:0"`
+	NextAttemptAt time.Time `gorm:"index;not null"`
+	ResponseCode  int
+	ResponseBody  string `gorm:"type:text"`
+	Error         string `gorm:"type:text"`
+	DeliveredAt   *time.Time
+	// Повторная отправка вручную ссылается на исходную доставку
+	ReplayOf  *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt time.Time  `gorm:"autoCreateTime;index"`
+}
+
+func (m *WebhookDelivery) BeforeCreate(tx *gorm.DB) (err error) {
+	m.ID = uuid.New()
+	return
+}
@@ -0,0 +1,75 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"link-service/internal/models"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+// KafkaSender отправляет сообщения outbox в Kafka; топик берётся из сообщения
+type KafkaSender struct {
+	writer *kafka.Writer
+}
+
+func NewKafkaSender(brokers []string) *KafkaSender {
+	return &KafkaSender{
+		writer: &kafka.Writer{
+			Addr: kafka.TCP(brokers...),
+			// Hash по ключу сохраняет порядок сообщений одного ключа внутри партиции
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			BatchTimeout: 10 * time.Millisecond,
+		},
+	}
+}
+
+func (s *KafkaSender) Send(ctx context.Context, msgs []models.OutboxMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	batch := make([]kafka.Message, 0, len(msgs))
+	for i := range msgs {
+		msg := &msgs[i]
+		// outbox-id позволяет потребителям отбрасывать повторы
+		headers := []kafka.Header{{Key: "outbox-id", Value: []byte(strconv.FormatUint(msg.ID, 10))}}
+		for k, v := range msg.HeaderMap() {
+			headers = append(headers, kafka.Header{Key: k, Value: []byte(v)})
+		}
+		batch = append(batch, kafka.Message{
+			Topic:   msg.Topic,
+			Key:     []byte(msg.Key),
+			Value:   msg.Payload,
+			Headers: headers,
+		})
+	}
+	err := s.writer.WriteMessages(ctx, batch...)
+	var writeErrs kafka.WriteErrors
+	if errors.As(err, &writeErrs) {
+		return BatchError(writeErrs)
+	}
+	return err
+}
+
+func (s *KafkaSender) Close() error {
+	return s.writer.Close()
+}
+
+// LogSender пишет сообщения в лог, когда брокер не настроен
+type LogSender struct {
+	log *zap.Logger
+}
+
+func NewLogSender(log *zap.Logger) *LogSender {
+	return &LogSender{log: log}
+}
+
+func (s *LogSender) Send(_ context.Context, msgs []models.OutboxMessage) error {
+	for _, msg := range msgs {
+		s.log.Info("outbox message", zap.String("topic", msg.Topic), zap.String("key", msg.Key), zap.ByteString("payload", msg.Payload))
+	}
+	return nil
+}
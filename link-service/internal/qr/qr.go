@@ -0,0 +1,209 @@
+package qr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"strconv"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+var (
+	ErrInvalidSize  = errors.New("invalid qr size")
+	ErrInvalidLevel = errors.New("invalid error correction level")
+	ErrInvalidColor = errors.New("invalid color")
+	ErrInvalidLogo  = errors.New("invalid logo image")
+)
+
+const (
+	MinSize     = 64
+	MaxSize     = 2048
+	DefaultSize = 256
+
+	// Доля стороны QR-кода, которую занимает логотип (уровень H выдерживает до ~30% потерь)
+	logoScale = 0.22
+)
+
+// Options — параметры отрисовки QR-кода
+type Options struct {
+	Size       int    // сторона в пикселях (PNG) или в единицах viewBox (SVG)
+	Level      string // L, M, Q, H
+	Foreground string // #RRGGBB
+	Background string // #RRGGBB
+	Logo       []byte // PNG/JPEG для центра; принудительно включает уровень H
+	NoBorder   bool
+}
+
+func (o Options) build(content string) (*qrcode.QRCode, int, error) {
+	size := o.Size
+	if size == 0 {
+		size = DefaultSize
+	}
+	if size < MinSize || size > MaxSize {
+		return nil, 0, ErrInvalidSize
+	}
+
+	level, err := parseLevel(o.Level)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(o.Logo) > 0 {
+		level = qrcode.Highest
+	}
+
+	fg, err := parseColor(o.Foreground, color.Black)
+	if err != nil {
+		return nil, 0, err
+	}
+	bg, err := parseColor(o.Background, color.White)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	q, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, 0, err
+	}
+	q.ForegroundColor = fg
+	q.BackgroundColor = bg
+	q.DisableBorder = o.NoBorder
+	return q, size, nil
+}
+
+// PNG рисует QR-код в PNG
+func PNG(content string, opts Options) ([]byte, error) {
+	q, size, err := opts.build(content)
+	if err != nil {
+		return nil, err
+	}
+	img := q.Image(size)
+
+	if len(opts.Logo) > 0 {
+		logo, _, err := image.Decode(bytes.NewReader(opts.Logo))
+		if err != nil {
+			return nil, ErrInvalidLogo
+		}
+		img = overlayLogo(img, logo, q.BackgroundColor)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SVG рисует QR-код в SVG: модули одного ряда объединяются в один path
+func SVG(content string, opts Options) ([]byte, error) {
+	q, size, err := opts.build(content)
+	if err != nil {
+		return nil, err
+	}
+	bitmap := q.Bitmap()
+	n := len(bitmap)
+
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x := 0; x < n; {
+			if !row[x] {
+				x++
+				continue
+			}
+			start := x
+			for x < n && row[x] {
+				x++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, n, n)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"/>`, n, n, hexColor(q.BackgroundColor))
+	fmt.Fprintf(&buf, `<path fill="%s" d="%s"/>`, hexColor(q.ForegroundColor), path.String())
+
+	if len(opts.Logo) > 0 {
+		logo, format, err := image.DecodeConfig(bytes.NewReader(opts.Logo))
+		if err != nil || logo.Width == 0 || logo.Height == 0 {
+			return nil, ErrInvalidLogo
+		}
+		side := float64(n) * logoScale
+		offset := (float64(n) - side) / 2
+		fmt.Fprintf(&buf, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="%s"/>`, offset, offset, side, side, hexColor(q.BackgroundColor))
+		fmt.Fprintf(&buf, `<image x="%.2f" y="%.2f" width="%.2f" height="%.2f" href="data:image/%s;base64,%s"/>`,
+			offset, offset, side, side, format, base64.StdEncoding.EncodeToString(opts.Logo))
+	}
+	buf.WriteString(`</svg>`)
+	return buf.Bytes(), nil
+}
+
+// overlayLogo масштабирует логотип (nearest neighbour) и кладёт его в центр на подложку цвета фона
+func overlayLogo(qrImg image.Image, logo image.Image, bg color.Color) image.Image {
+	bounds := qrImg.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, qrImg, bounds.Min, draw.Src)
+
+	side := int(float64(bounds.Dx()) * logoScale)
+	if side <= 0 {
+		return dst
+	}
+	pad := side / 10
+	x0 := bounds.Min.X + (bounds.Dx()-side)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-side)/2
+	draw.Draw(dst, image.Rect(x0-pad, y0-pad, x0+side+pad, y0+side+pad), &image.Uniform{C: bg}, image.Point{}, draw.Src)
+
+	lb := logo.Bounds()
+	for y := 0; y < side; y++ {
+		for x := 0; x < side; x++ {
+			src := logo.At(lb.Min.X+x*lb.Dx()/side, lb.Min.Y+y*lb.Dy()/side)
+			if _, _, _, a := src.RGBA(); a == 0 {
+				continue
+			}
+			dst.Set(x0+x, y0+y, src)
+		}
+	}
+	return dst
+}
+
+func parseLevel(level string) (qrcode.RecoveryLevel, error) {
+	switch strings.ToUpper(strings.TrimSpace(level)) {
+	case "L":
+		return qrcode.Low, nil
+	case "", "M":
+		return qrcode.Medium, nil
+	case "Q":
+		return qrcode.High, nil
+	case "H":
+		return qrcode.Highest, nil
+	default:
+		return 0, ErrInvalidLevel
+	}
+}
+
+func parseColor(s string, def color.Color) (color.Color, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if s == "" {
+		return def, nil
+	}
+	if len(s) != 6 {
+		return nil, ErrInvalidColor
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return nil, ErrInvalidColor
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+func hexColor(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
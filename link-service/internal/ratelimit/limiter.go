@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit — параметры token bucket: Rate токенов в секунду, ёмкость Burst
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute — n запросов в минуту с запасом на всплеск в ту же минуту
+func PerMinute(n int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: n}
+}
+
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Policy — лимиты одного метода: по IP для анонимных вызовов и по пользователю для авторизованных
+type Policy struct {
+	IP   Limit
+	User Limit
+}
+
+// Store хранит состояние бакетов. Take списывает токен и при отказе возвращает время до появления следующего.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// refill пополняет бакет на момент now и пытается списать один токен
+func refill(tokens float64, last, now time.Time, limit Limit) (float64, bool, time.Duration) {
+	if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens = math.Min(float64(limit.Burst), tokens+elapsed*limit.Rate)
+	}
+	if tokens >= 1 {
+		return tokens - 1, true, 0
+	}
+	wait := time.Duration((1 - tokens) / limit.Rate * float64(time.Second))
+	return tokens, false, wait
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// Момент, когда бакет наполнится и его можно забыть
+	fullAt time.Time
+}
+
+// Порог числа ключей, после которого из памяти вычищаются полные бакеты
+const memoryPruneThreshold = 10000
+
+// MemoryStore — хранилище бакетов в памяти процесса
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		if len(s.buckets) >= memoryPruneThreshold {
+			s.prune(now)
+		}
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+	tokens, allowed, wait := refill(b.tokens, b.last, now, limit)
+	b.tokens, b.last = tokens, now
+	b.fullAt = now.Add(time.Duration((float64(limit.Burst) - tokens) / limit.Rate * float64(time.Second)))
+	return allowed, wait, nil
+}
+
+func (s *MemoryStore) prune(now time.Time) {
+	for key, b := range s.buckets {
+		if !now.Before(b.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
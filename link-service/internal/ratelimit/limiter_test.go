@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRefill(t *testing.T) {
+	limit := PerMinute(60) // 1 токен в секунду, ёмкость 60
+	last := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name        string
+		tokens      float64
+		elapsed     time.Duration
+		wantTokens  float64
+		wantAllowed bool
+		wantWait    time.Duration
+	}{
+		{"full bucket", 60, 0, 59, true, 0},
+		{"last token", 1, 0, 0, true, 0},
+		{"empty bucket", 0, 0, 0, false, time.Second},
+		{"half token", 0.5, 0, 0.5, false, 500 * time.Millisecond},
+		{"refilled after wait", 0, time.Second, 0, true, 0},
+		{"partial refill", 0, 250 * time.Millisecond, 0.25, false, 750 * time.Millisecond},
+		{"refill capped at burst", 10, time.Hour, 59, true, 0},
+		{"clock went back", 0, -time.Minute, 0, false, time.Second},
+	}
+	for _, tc := range cases {
+		tokens, allowed, wait := refill(tc.tokens, last, last.Add(tc.elapsed), limit)
+		if allowed != tc.wantAllowed || wait != tc.wantWait || tokens < tc.wantTokens-1e-9 || tokens > tc.wantTokens+1e-9 {
+			t.Errorf("%s: refill = (%v, %v, %v), want (%v, %v, %v)", tc.name, tokens, allowed, wait, tc.wantTokens, tc.wantAllowed, tc.wantWait)
+		}
+	}
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore()
+	limit := PerMinute(3)
+
+	for i := 0; i < limit.Burst; i++ {
+		if allowed, _, err := s.Take(ctx, "ip:203.0.113.7", limit); err != nil || !allowed {
+			t.Fatalf("take %d within burst = %v, %v", i+1, allowed, err)
+		}
+	}
+	allowed, wait, err := s.Take(ctx, "ip:203.0.113.7", limit)
+	if err != nil || allowed {
+		t.Fatalf("take over burst = %v, %v", allowed, err)
+	}
+	// Один токен в 20 секунд
+	if wait <= 0 || wait > 20*time.Second {
+		t.Errorf("retry after = %v, want (0, 20s]", wait)
+	}
+	// Отказ не списывает токен и не сдвигает ожидание
+	if _, again, _ := s.Take(ctx, "ip:203.0.113.7", limit); again > wait {
+		t.Errorf("retry after second refusal = %v, want <= %v", again, wait)
+	}
+
+	if allowed, _, err := s.Take(ctx, "ip:198.51.100.1", limit); err != nil || !allowed {
+		t.Errorf("other key = %v, %v, want own bucket", allowed, err)
+	}
+}
+
+func TestMemoryStorePrune(t *testing.T) {
+	s := NewMemoryStore()
+	limit := PerMinute(60)
+	now := time.Now()
+	s.buckets["full"] = &bucket{tokens: 60, last: now.Add(-time.Minute), fullAt: now.Add(-time.Second)}
+	s.buckets["draining"] = &bucket{tokens: 0, last: now, fullAt: now.Add(time.Minute)}
+
+	s.prune(now)
+	if _, ok := s.buckets["full"]; ok {
+		t.Error("full bucket was not pruned")
+	}
+	if _, ok := s.buckets["draining"]; !ok {
+		t.Fatal("bucket with spent tokens was pruned: its limit would reset")
+	}
+	if allowed, _, _ := s.Take(context.Background(), "draining", limit); allowed {
+		t.Error("draining bucket allowed a request right after prune")
+	}
+}
@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"context"
+	"link-service/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SharedStore хранит бакеты в PostgreSQL — лимиты общие для всех экземпляров сервиса
+type SharedStore struct {
+	db *gorm.DB
+}
+
+func NewSharedStore(db *gorm.DB) *SharedStore {
+	return &SharedStore{db: db}
+}
+
+func (s *SharedStore) Take(ctx context.Context, key string, limit Limit) (bool, time.Duration, error) {
+	var (
+		allowed bool
+		wait    time.Duration
+	)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		b := models.RateLimitBucket{Key: key, Tokens: float64(limit.Burst), UpdatedAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&b).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(&b).Error; err != nil {
+			return err
+		}
+		var tokens float64
+		tokens, allowed, wait = refill(b.Tokens, b.UpdatedAt, now, limit)
+		return tx.Model(&models.RateLimitBucket{}).Where("key = ?", key).
+			Updates(map[string]interface{}{"tokens": tokens, "updated_at": now}).Error
+	})
+	return allowed, wait, err
+}
+
+// DeleteIdleBefore удаляет бакеты, не использовавшиеся с t
+func (s *SharedStore) DeleteIdleBefore(t time.Time) (int64, error) {
+	res := s.db.Where("updated_at < ?", t).Delete(&models.RateLimitBucket{})
+	return res.RowsAffected, res.Error
+}
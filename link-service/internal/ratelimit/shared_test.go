@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"context"
+	"link-service/internal/models"
+	"link-service/internal/storage/testdb"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSharedStoreTake(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	limit := PerMinute(2)
+	// Два экземпляра сервиса с общей базой
+	a, b := NewSharedStore(db), NewSharedStore(db)
+
+	if allowed, _, err := a.Take(ctx, "ip:203.0.113.7", limit); err != nil || !allowed {
+		t.Fatalf("first take = %v, %v", allowed, err)
+	}
+	if allowed, _, err := b.Take(ctx, "ip:203.0.113.7", limit); err != nil || !allowed {
+		t.Fatalf("second take on other instance = %v, %v", allowed, err)
+	}
+	allowed, wait, err := a.Take(ctx, "ip:203.0.113.7", limit)
+	if err != nil || allowed {
+		t.Fatalf("take over burst = %v, %v", allowed, err)
+	}
+	if wait <= 0 || wait > 30*time.Second {
+		t.Errorf("retry after = %v, want (0, 30s]", wait)
+	}
+	if allowed, _, err := b.Take(ctx, "ip:198.51.100.1", limit); err != nil || !allowed {
+		t.Errorf("other key = %v, %v, want own bucket", allowed, err)
+	}
+}
+
+// Параллельные запросы не должны получить больше токенов, чем вмещает бакет
+func TestSharedStoreTakeConcurrent(t *testing.T) {
+	db := testdb.Open(t)
+	limit := Limit{Rate: 0.001, Burst: 5}
+	const workers = 20
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+		errs    []error
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, _, err := NewSharedStore(db).Take(context.Background(), "user:concurrent", limit)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+			} else if ok {
+				allowed++
+			}
+		}()
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		t.Fatalf("Take errors: %v", errs)
+	}
+	if allowed != limit.Burst {
+		t.Errorf("allowed %d of %d concurrent requests, want %d", allowed, workers, limit.Burst)
+	}
+}
+
+func TestSharedStoreDeleteIdleBefore(t *testing.T) {
+	db := testdb.Open(t)
+	s := NewSharedStore(db)
+	now := time.Now()
+	buckets := []models.RateLimitBucket{
+		{Key: "idle", Tokens: 1, UpdatedAt: now.Add(-2 * time.Hour)},
+		{Key: "recent", Tokens: 1, UpdatedAt: now},
+	}
+	if err := db.Create(&buckets).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := s.DeleteIdleBefore(now.Add(-time.Hour))
+	if err != nil || n != 1 {
+		t.Fatalf("DeleteIdleBefore = %d, %v, want 1", n, err)
+	}
+	var left []string
+	if err := db.Model(&models.RateLimitBucket{}).Pluck("key", &left).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 1 || left[0] != "recent" {
+		t.Errorf("buckets left = %v, want [recent]", left)
+	}
+}
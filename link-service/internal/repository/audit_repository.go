@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"link-service/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+func (r *AuditRepository) Create(entry *models.AuditEntry) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *AuditRepository) ListByShortLinkID(shortLinkID uuid.UUID) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+	err := r.db.Where("short_link_id = ?", shortLinkID).Order("created_at desc").Find(&entries).Error
+	return entries, err
+}
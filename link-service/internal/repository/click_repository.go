@@ -2,6 +2,7 @@ package repository
 
 import (
 	"link-service/internal/models"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -17,6 +18,16 @@ func NewClickRepository(db *gorm.DB) *ClickRepository {
 	}
 }
 
+// WithTx возвращает репозиторий, работающий в транзакции tx
+func (r *ClickRepository) WithTx(tx *gorm.DB) *ClickRepository {
+	return &ClickRepository{db: tx}
+}
+
+// Since возвращает репозиторий, учитывающий только клики начиная с t
+func (r *ClickRepository) Since(t time.Time) *ClickRepository {
+	return &ClickRepository{db: r.db.Where("clicks.clicked_at >= ?", t).Session(&gorm.Session{})}
+}
+
 func (r *ClickRepository) Create(click *models.Click) error {
 	return r.db.Create(click).Error
 }
@@ -69,6 +80,30 @@ func (c *ClickRepository) GetCountryStats(shortLinkID string) (map[string]int64,
 	return stats, nil
 }
 
+// Источники: количество переходов по source (direct / qr)
+func (c *ClickRepository) GetSourceStats(shortLinkID string) (map[string]int64, error) {
+	rows, err := c.db.Model(&models.Click{}).
+		Select("source, COUNT(*) as cnt").
+		Where("short_link_id = ?", shortLinkID).
+		Group("source").
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[string]int64)
+	var source string
+	var cnt int64
+	for rows.Next() {
+		if err := rows.Scan(&source, &cnt); err != nil {
+			return nil, err
+		}
+		stats[source] = cnt
+	}
+	return stats, nil
+}
+
 // График по дням: количество переходов по датам
 func (c *ClickRepository) GetDailyStats(shortLinkID string) (map[string]int64, error) {
 	rows, err := c.db.Model(&models.Click{}).
@@ -117,3 +152,61 @@ func (c *ClickRepository) GetUniqueCountries(shortLinkID string) ([]string, erro
 func (r *ClickRepository) DeleteClicksByShortLinkID(id uuid.UUID) error {
 	return r.db.Where("short_link_id = ?", id).Delete(&models.Click{}).Error
 }
+
+// TagLinkStats — клики одной ссылки в составе тега
+type TagLinkStats struct {
+	ShortLinkID uuid.UUID
+	ShortCode   string
+	Clicks      int64
+}
+
+// Общее количество переходов и уникальных IP по всем ссылкам тега
+func (c *ClickRepository) GetTagTotals(tagID uuid.UUID) (total int64, uniqueIPs int64, err error) {
+	row := c.db.Model(&models.Click{}).
+		Select("COUNT(*), COUNT(DISTINCT clicks.ip)").
+		Joins("JOIN short_link_tags slt ON slt.short_link_id = clicks.short_link_id").
+		Where("slt.tag_id = ?", tagID).
+		Row()
+	err = row.Scan(&total, &uniqueIPs)
+	return total, uniqueIPs, err
+}
+
+// Переходы по каждой ссылке тега начиная с since (включая ссылки без кликов).
+// Окно задаётся в условии соединения, а не через Since: иначе ссылки без кликов выпали бы из результата.
+func (c *ClickRepository) GetTagLinkStats(tagID uuid.UUID, since time.Time) ([]TagLinkStats, error) {
+	var stats []TagLinkStats
+	err := c.db.Table("short_link_tags slt").
+		Select("sl.id as short_link_id, sl.short_code, COUNT(cl.id) as clicks").
+		Joins("JOIN short_links sl ON sl.id = slt.short_link_id").
+		Joins("LEFT JOIN clicks cl ON cl.short_link_id = sl.id AND cl.clicked_at >= ?", since).
+		Where("slt.tag_id = ?", tagID).
+		Group("sl.id, sl.short_code").
+		Order("clicks desc").
+		Scan(&stats).Error
+	return stats, err
+}
+
+// График по дням для всех ссылок тега
+func (c *ClickRepository) GetTagDailyStats(tagID uuid.UUID) (map[string]int64, error) {
+	rows, err := c.db.Model(&models.Click{}).
+		Select("DATE(clicks.clicked_at) as day, COUNT(*) as cnt").
+		Joins("JOIN short_link_tags slt ON slt.short_link_id = clicks.short_link_id").
+		Where("slt.tag_id = ?", tagID).
+		Group("day").
+		Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[string]int64)
+	var day string
+	var cnt int64
+	for rows.Next() {
+		if err := rows.Scan(&day, &cnt); err != nil {
+			return nil, err
+		}
+		stats[day] = cnt
+	}
+	return stats, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"link-service/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type DomainRepository struct {
+	db *gorm.DB
+}
+
+func NewDomainRepository(db *gorm.DB) *DomainRepository {
+	return &DomainRepository{db: db}
+}
+
+func (r *DomainRepository) Create(domain *models.Domain) error {
+	return r.db.Create(domain).Error
+}
+
+func (r *DomainRepository) GetByID(id, userID uuid.UUID) (*models.Domain, error) {
+	var domain models.Domain
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&domain).Error; err != nil {
+		return nil, err
+	}
+	return &domain, nil
+}
+
+func (r *DomainRepository) GetByUserID(userID uuid.UUID) ([]models.Domain, error) {
+	var domains []models.Domain
+	err := r.db.Where("user_id = ?", userID).Order("host").Find(&domains).Error
+	return domains, err
+}
+
+// GetByUserAndHost — заявка пользователя на хост (подтверждённая или нет)
+func (r *DomainRepository) GetByUserAndHost(userID uuid.UUID, host string) (*models.Domain, error) {
+	var domain models.Domain
+	if err := r.db.Where("user_id = ? AND host = ?", userID, host).First(&domain).Error; err != nil {
+		return nil, err
+	}
+	return &domain, nil
+}
+
+func (r *DomainRepository) GetVerifiedByHost(host string) (*models.Domain, error) {
+	var domain models.Domain
+	if err := r.db.Where("host = ? AND verified = true", host).First(&domain).Error; err != nil {
+		return nil, err
+	}
+	return &domain, nil
+}
+
+func (r *DomainRepository) MarkVerified(id uuid.UUID) error {
+	return r.db.Model(&models.Domain{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"verified": true, "verified_at": time.Now()}).Error
+}
+
+// Delete удаляет домен; ссылки на нём деактивируются, так как их короткий URL перестаёт работать.
+// Удаление мягкое: ссылки сохраняют domain_id (и внешний ключ на домен). Обнулить domain_id нельзя —
+// код ссылки мог бы совпасть с кодом на основном домене и нарушить idx_short_links_default_code.
+func (r *DomainRepository) Delete(domain *models.Domain) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ShortLink{}).
+			Where("domain_id = ? AND is_active = ?", domain.ID, true).
+			Updates(map[string]interface{}{"is_active": false, "deactivated_at": time.Now()}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(domain).Error
+	})
+}
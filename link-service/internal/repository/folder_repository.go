@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"link-service/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type FolderRepository struct {
+	db *gorm.DB
+}
+
+func NewFolderRepository(db *gorm.DB) *FolderRepository {
+	return &FolderRepository{db: db}
+}
+
+func (r *FolderRepository) Create(folder *models.Folder) error {
+	return r.db.Create(folder).Error
+}
+
+func (r *FolderRepository) GetByID(id, userID uuid.UUID) (*models.Folder, error) {
+	var folder models.Folder
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&folder).Error; err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+func (r *FolderRepository) GetByUserID(userID uuid.UUID) ([]models.Folder, error) {
+	var folders []models.Folder
+	err := r.db.Where("user_id = ?", userID).Order("name").Find(&folders).Error
+	return folders, err
+}
+
+func (r *FolderRepository) Rename(id, userID uuid.UUID, name string) error {
+	return r.db.Model(&models.Folder{}).Where("id = ? AND user_id = ?", id, userID).Update("name", name).Error
+}
+
+// Delete удаляет папку; ссылки из неё остаются без папки
+func (r *FolderRepository) Delete(folder *models.Folder) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ShortLink{}).Where("folder_id = ?", folder.ID).Update("folder_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(folder).Error
+	})
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"link-service/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type LinkHealthRepository struct {
+	db *gorm.DB
+}
+
+func NewLinkHealthRepository(db *gorm.DB) *LinkHealthRepository {
+	return &LinkHealthRepository{db: db}
+}
+
+func (r *LinkHealthRepository) Create(check *models.LinkHealthCheck) error {
+	return r.db.Create(check).Error
+}
+
+// ListByShortLinkID — история проверок ссылки, новые первыми
+func (r *LinkHealthRepository) ListByShortLinkID(shortLinkID uuid.UUID, limit int) ([]models.LinkHealthCheck, error) {
+	var checks []models.LinkHealthCheck
+	err := r.db.Where("short_link_id = ?", shortLinkID).Order("checked_at desc").Limit(limit).Find(&checks).Error
+	return checks, err
+}
+
+func (r *LinkHealthRepository) DeleteOlderThan(t time.Time) (int64, error) {
+	res := r.db.Where("checked_at < ?", t).Delete(&models.LinkHealthCheck{})
+	return res.RowsAffected, res.Error
+}
+
+func (r *LinkHealthRepository) DeleteByShortLinkID(id uuid.UUID) error {
+	return r.db.Where("short_link_id = ?", id).Delete(&models.LinkHealthCheck{}).Error
+}
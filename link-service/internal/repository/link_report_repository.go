@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"link-service/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type LinkReportRepository struct {
+	db *gorm.DB
+}
+
+func NewLinkReportRepository(db *gorm.DB) *LinkReportRepository {
+	return &LinkReportRepository{db: db}
+}
+
+func (r *LinkReportRepository) Create(report *models.LinkReport) error {
+	return r.db.Create(report).Error
+}
+
+func (r *LinkReportRepository) GetByID(id uuid.UUID) (*models.LinkReport, error) {
+	var report models.LinkReport
+	if err := r.db.Where("id = ?", id).First(&report).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// HasOpenReport — есть ли уже открытая жалоба на ссылку с этого IP
+func (r *LinkReportRepository) HasOpenReport(shortLinkID uuid.UUID, reporterIP string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.LinkReport{}).
+		Where("short_link_id = ? AND reporter_ip = ? AND status = ?", shortLinkID, reporterIP, models.ReportStatusOpen).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// List возвращает жалобы (новые первыми); пустой status — все статусы
+func (r *LinkReportRepository) List(status string, limit, offset int) ([]models.LinkReport, error) {
+	var reports []models.LinkReport
+	q := r.db.Preload("ShortLink").Order("created_at desc")
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if limit > 0 {
+		q = q.Limit(limit).Offset(offset)
+	}
+	err := q.Find(&reports).Error
+	return reports, err
+}
+
+func (r *LinkReportRepository) SetStatus(id uuid.UUID, status string, resolvedBy uuid.UUID) error {
+	return r.db.Model(&models.LinkReport{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"status": status, "resolved_at": time.Now(), "resolved_by": resolvedBy}).Error
+}
+
+// ResolveOpenForLink закрывает все открытые жалобы на ссылку (после блокировки)
+func (r *LinkReportRepository) ResolveOpenForLink(shortLinkID, resolvedBy uuid.UUID) error {
+	return r.db.Model(&models.LinkReport{}).
+		Where("short_link_id = ? AND status = ?", shortLinkID, models.ReportStatusOpen).
+		Updates(map[string]interface{}{"status": models.ReportStatusResolved, "resolved_at": time.Now(), "resolved_by": resolvedBy}).Error
+}
+
+func (r *LinkReportRepository) DeleteByShortLinkID(id uuid.UUID) error {
+	return r.db.Where("short_link_id = ?", id).Delete(&models.LinkReport{}).Error
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"errors"
+	"link-service/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PlanRepository struct {
+	db *gorm.DB
+}
+
+func NewPlanRepository(db *gorm.DB) *PlanRepository {
+	return &PlanRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий в транзакции tx
+func (r *PlanRepository) WithTx(tx *gorm.DB) *PlanRepository {
+	return &PlanRepository{db: tx}
+}
+
+// GetPlan возвращает имя плана пользователя или "" если план не назначен
+func (r *PlanRepository) GetPlan(userID uuid.UUID) (string, error) {
+	var plan models.UserPlan
+	err := r.db.Where("user_id = ?", userID).First(&plan).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	return plan.Plan, err
+}
+
+func (r *PlanRepository) SetPlan(userID uuid.UUID, plan string) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"plan", "updated_at"}),
+	}).Create(&models.UserPlan{UserID: userID, Plan: plan}).Error
+}
+
+// LockUser сериализует проверки квот одного пользователя до конца транзакции
+func (r *PlanRepository) LockUser(userID uuid.UUID) error {
+	return r.db.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "quota:"+userID.String()).Error
+}
+
+// IncrementAPICalls атомарно увеличивает счётчик за день и возвращает новое значение
+func (r *PlanRepository) IncrementAPICalls(userID uuid.UUID, day time.Time) (int64, error) {
+	var calls int64
+	err := r.db.Raw(`INSERT INTO api_usage (user_id, day, calls) VALUES (?, ?, 1)
+		ON CONFLICT (user_id, day) DO UPDATE SET calls = api_usage.calls + 1
+		RETURNING calls`, userID, day).Scan(&calls).Error
+	return calls, err
+}
+
+func (r *PlanRepository) GetAPICalls(userID uuid.UUID, day time.Time) (int64, error) {
+	var usage models.APIUsage
+	err := r.db.Where("user_id = ? AND day = ?", userID, day).First(&usage).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	return usage.Calls, err
+}
+
+func (r *PlanRepository) DeleteAPIUsageBefore(day time.Time) (int64, error) {
+	res := r.db.Where("day < ?", day).Delete(&models.APIUsage{})
+	return res.RowsAffected, res.Error
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ShortLinkRepository struct {
@@ -18,22 +19,67 @@ func NewShortLinkRepository(db *gorm.DB) *ShortLinkRepository {
 	}
 }
 
+// WithTx возвращает репозиторий, работающий в транзакции tx
+func (r *ShortLinkRepository) WithTx(tx *gorm.DB) *ShortLinkRepository {
+	return &ShortLinkRepository{db: tx}
+}
+
 func (r *ShortLinkRepository) Create(shortLink *models.ShortLink) error {
 	return r.db.Create(shortLink).Error
 }
 
+// GetByShortCode ищет активную ссылку основного домена
 func (r *ShortLinkRepository) GetByShortCode(shortLink *models.ShortLink, shortCode string) error {
-	return r.db.Where("short_code = ? AND is_active = ? AND (expire_at IS NULL OR expire_at > ?)", shortCode, true, time.Now()).First(shortLink).Error
+	return r.db.Where("domain_id IS NULL AND short_code = ? AND is_active = ? AND (expire_at IS NULL OR expire_at > ?)", shortCode, true, time.Now()).First(shortLink).Error
+}
+
+// GetByDomainAndCode ищет активную ссылку брендированного домена
+func (r *ShortLinkRepository) GetByDomainAndCode(shortLink *models.ShortLink, domainID uuid.UUID, shortCode string) error {
+	return r.db.Preload("Domain").Where("domain_id = ? AND short_code = ? AND is_active = ? AND (expire_at IS NULL OR expire_at > ?)", domainID, shortCode, true, time.Now()).First(shortLink).Error
 }
 
 func (r *ShortLinkRepository) GetByUserID(userID uuid.UUID) ([]*models.ShortLink, error) {
 	var shortLinks []*models.ShortLink
-	if err := r.db.Where("user_id = ? AND is_active = ? AND (expire_at IS NULL OR expire_at > ?)", userID, true, time.Now()).Find(&shortLinks).Error; err != nil {
+	if err := r.db.Preload("Domain").Where("user_id = ? AND is_active = ? AND (expire_at IS NULL OR expire_at > ?)", userID, true, time.Now()).Find(&shortLinks).Error; err != nil {
 		return nil, err
 	}
 	return shortLinks, nil
 }
 
+// LinkFilter — фильтр списка ссылок пользователя
+type LinkFilter struct {
+	TagID    *uuid.UUID
+	FolderID *uuid.UUID
+}
+
+func (r *ShortLinkRepository) GetByUserIDFiltered(userID uuid.UUID, filter LinkFilter) ([]*models.ShortLink, error) {
+	var shortLinks []*models.ShortLink
+	q := r.db.Preload("Tags").Preload("Folder").Preload("Domain").
+		Where("short_links.user_id = ? AND short_links.is_active = ? AND (short_links.expire_at IS NULL OR short_links.expire_at > ?)", userID, true, time.Now())
+	if filter.FolderID != nil {
+		q = q.Where("short_links.folder_id = ?", *filter.FolderID)
+	}
+	if filter.TagID != nil {
+		q = q.Joins("JOIN short_link_tags slt ON slt.short_link_id = short_links.id").
+			Where("slt.tag_id = ?", *filter.TagID)
+	}
+	if err := q.Find(&shortLinks).Error; err != nil {
+		return nil, err
+	}
+	return shortLinks, nil
+}
+
+func (r *ShortLinkRepository) SetFolder(id, userID uuid.UUID, folderID *uuid.UUID) error {
+	return r.db.Model(&models.ShortLink{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("folder_id", folderID).Error
+}
+
+// ReplaceTags полностью заменяет набор тегов ссылки
+func (r *ShortLinkRepository) ReplaceTags(link *models.ShortLink, tags []models.Tag) error {
+	return r.db.Model(link).Association("Tags").Replace(tags)
+}
+
 func (r *ShortLinkRepository) DeactivateByID(id, userID uuid.UUID) error {
 	return r.db.Model(&models.ShortLink{}).
 		Where("id = ? AND user_id = ? AND is_active = ?", id, userID, true).
@@ -42,7 +88,7 @@ func (r *ShortLinkRepository) DeactivateByID(id, userID uuid.UUID) error {
 
 func (r *ShortLinkRepository) GetShortLinkByID(id string, userID uuid.UUID) (*models.ShortLink, error) {
 	var shortLink models.ShortLink
-	if err := r.db.Where("id = ? AND user_id = ? AND is_active = ? AND (expire_at IS NULL OR expire_at > ?)", id, userID, true, time.Now()).First(&shortLink).Error; err != nil {
+	if err := r.db.Preload("Domain").Where("id = ? AND user_id = ? AND is_active = ? AND (expire_at IS NULL OR expire_at > ?)", id, userID, true, time.Now()).First(&shortLink).Error; err != nil {
 		return nil, err
 	}
 	return &shortLink, nil
@@ -68,17 +114,108 @@ func (r *ShortLinkRepository) FindExpiredInactiveUserLinks() ([]*models.ShortLin
 }
 
 func (r *ShortLinkRepository) DeleteLink(link *models.ShortLink) error {
-	return r.db.Delete(link).Error
+	// Select("Tags") удаляет и связи в short_link_tags
+	return r.db.Select("Tags").Delete(link).Error
 }
 
-func (r *ShortLinkRepository) DeactivateExpiredAnonLinks() error {
-	return r.db.Model(&models.ShortLink{}).
+// DeactivateExpiredAnonLinks деактивирует истёкшие анонимные ссылки и возвращает их
+func (r *ShortLinkRepository) DeactivateExpiredAnonLinks() ([]*models.ShortLink, error) {
+	var links []*models.ShortLink
+	err := r.db.Model(&links).
+		Clauses(clause.Returning{}).
 		Where("user_id IS NULL AND is_active = true AND expire_at IS NOT NULL AND expire_at < ?", time.Now()).
 		Update("is_active", false).Error
+	return links, err
 }
 
-func (r *ShortLinkRepository) DeactivateExpiredUserLinks() error {
-	return r.db.Model(&models.ShortLink{}).
+// DeactivateExpiredUserLinks деактивирует истёкшие пользовательские ссылки и возвращает их
+func (r *ShortLinkRepository) DeactivateExpiredUserLinks() ([]*models.ShortLink, error) {
+	var links []*models.ShortLink
+	err := r.db.Model(&links).
+		Clauses(clause.Returning{}).
 		Where("user_id IS NOT NULL AND is_active = true AND expire_at IS NOT NULL AND expire_at < ?", time.Now()).
 		Update("is_active", false).Error
+	return links, err
+}
+
+func (r *ShortLinkRepository) GetByID(id uuid.UUID) (*models.ShortLink, error) {
+	var shortLink models.ShortLink
+	if err := r.db.Where("id = ?", id).First(&shortLink).Error; err != nil {
+		return nil, err
+	}
+	return &shortLink, nil
+}
+
+func (r *ShortLinkRepository) SetBlocked(id uuid.UUID, reason string) error {
+	return r.db.Model(&models.ShortLink{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"is_blocked": true, "blocked_at": time.Now(), "block_reason": reason}).Error
+}
+
+func (r *ShortLinkRepository) ClearBlocked(id uuid.UUID) error {
+	return r.db.Model(&models.ShortLink{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"is_blocked": false, "blocked_at": nil, "block_reason": ""}).Error
+}
+
+func (r *ShortLinkRepository) UpdateDetails(id, userID uuid.UUID, fields map[string]interface{}) error {
+	return r.db.Model(&models.ShortLink{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Updates(fields).Error
+}
+
+func (r *ShortLinkRepository) UpdateMetadata(id uuid.UUID, title, description, image, favicon string) error {
+	return r.db.Model(&models.ShortLink{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"meta_title":       title,
+			"meta_description": description,
+			"meta_image":       image,
+			"meta_favicon":     favicon,
+			"meta_fetched_at":  time.Now(),
+		}).Error
+}
+
+// FindWithoutMetadata — активные ссылки, метаданные которых ещё не загружались или устарели
+func (r *ShortLinkRepository) FindWithoutMetadata(staleBefore time.Time, limit int) ([]*models.ShortLink, error) {
+	var links []*models.ShortLink
+	err := r.db.Where("is_active = true AND is_blocked = false AND (meta_fetched_at IS NULL OR meta_fetched_at < ?)", staleBefore).
+		Order("meta_fetched_at NULLS FIRST").
+		Limit(limit).
+		Find(&links).Error
+	return links, err
+}
+
+// FindDueForHealthCheck — пользовательские активные ссылки, не проверявшиеся с checkedBefore
+func (r *ShortLinkRepository) FindDueForHealthCheck(checkedBefore time.Time, limit int) ([]*models.ShortLink, error) {
+	var links []*models.ShortLink
+	err := r.db.Where("user_id IS NOT NULL AND is_active = true AND is_blocked = false AND (expire_at IS NULL OR expire_at > ?) AND (health_last_checked_at IS NULL OR health_last_checked_at < ?)", time.Now(), checkedBefore).
+		Order("health_last_checked_at NULLS FIRST").
+		Limit(limit).
+		Find(&links).Error
+	return links, err
+}
+
+func (r *ShortLinkRepository) UpdateHealth(id uuid.UUID, broken bool, failures int, checkedAt time.Time) error {
+	return r.db.Model(&models.ShortLink{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"is_broken": broken, "health_failures": failures, "health_last_checked_at": checkedAt}).Error
+}
+
+// CountActiveByUser — число активных неистёкших ссылок пользователя
+func (r *ShortLinkRepository) CountActiveByUser(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.ShortLink{}).
+		Where("user_id = ? AND is_active = true AND (expire_at IS NULL OR expire_at > ?)", userID, time.Now()).
+		Count(&count).Error
+	return count, err
+}
+
+// CountAliasesByUser — число активных ссылок пользователя с собственным коротким кодом
+func (r *ShortLinkRepository) CountAliasesByUser(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.ShortLink{}).
+		Where("user_id = ? AND is_custom_alias = true AND is_active = true", userID).
+		Count(&count).Error
+	return count, err
 }
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"link-service/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TagRepository struct {
+	db *gorm.DB
+}
+
+func NewTagRepository(db *gorm.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+func (r *TagRepository) Create(tag *models.Tag) error {
+	return r.db.Create(tag).Error
+}
+
+func (r *TagRepository) GetByID(id, userID uuid.UUID) (*models.Tag, error) {
+	var tag models.Tag
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *TagRepository) GetByUserID(userID uuid.UUID) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := r.db.Where("user_id = ?", userID).Order("name").Find(&tags).Error
+	return tags, err
+}
+
+// FindOrCreateByNames возвращает теги пользователя с указанными именами, создавая недостающие
+func (r *TagRepository) FindOrCreateByNames(userID uuid.UUID, names []string) ([]models.Tag, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	var tags []models.Tag
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, name := range names {
+			tag := models.Tag{UserID: userID, Name: name}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tag).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("user_id = ? AND name IN ?", userID, names).Find(&tags).Error
+	})
+	return tags, err
+}
+
+func (r *TagRepository) Rename(id, userID uuid.UUID, name string) error {
+	return r.db.Model(&models.Tag{}).Where("id = ? AND user_id = ?", id, userID).Update("name", name).Error
+}
+
+// Delete удаляет тег и его связи со ссылками
+func (r *TagRepository) Delete(tag *models.Tag) error {
+	return r.db.Select("ShortLinks").Delete(tag).Error
+}
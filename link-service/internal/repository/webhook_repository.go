@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"link-service/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// WithTx возвращает репозиторий, работающий в транзакции tx
+func (r *WebhookRepository) WithTx(tx *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: tx}
+}
+
+func (r *WebhookRepository) CreateEndpoint(endpoint *models.WebhookEndpoint) error {
+	return r.db.Create(endpoint).Error
+}
+
+func (r *WebhookRepository) GetEndpoint(id, userID uuid.UUID) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	if err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&endpoint).Error; err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+func (r *WebhookRepository) GetEndpointByID(id uuid.UUID) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	if err := r.db.Where("id = ?", id).First(&endpoint).Error; err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+func (r *WebhookRepository) ListEndpoints(userID uuid.UUID) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (r *WebhookRepository) CountEndpoints(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.WebhookEndpoint{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// ActiveEndpointsForLink — активные endpoint'ы владельца ссылки
+func (r *WebhookRepository) ActiveEndpointsForLink(linkID uuid.UUID) ([]models.WebhookEndpoint, error) {
+	var endpoints []models.WebhookEndpoint
+	err := r.db.Where("active = true AND user_id = (SELECT user_id FROM short_links WHERE id = ?)", linkID).Find(&endpoints).Error
+	return endpoints, err
+}
+
+func (r *WebhookRepository) UpdateEndpoint(id, userID uuid.UUID, fields map[string]interface{}) error {
+	return r.db.Model(&models.WebhookEndpoint{}).Where("id = ? AND user_id = ?", id, userID).Updates(fields).Error
+}
+
+// DeleteEndpoint удаляет endpoint вместе с журналом его доставок
+func (r *WebhookRepository) DeleteEndpoint(endpoint *models.WebhookEndpoint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("endpoint_id = ?", endpoint.ID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(endpoint).Error
+	})
+}
+
+func (r *WebhookRepository) CreateDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Create(delivery).Error
+}
+
+func (r *WebhookRepository) GetDelivery(id uuid.UUID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := r.db.Where("id = ?", id).First(&delivery).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *WebhookRepository) ListDeliveries(endpointID uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.Where("endpoint_id = ?", endpointID).Order("created_at desc").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ClaimDue забирает готовые к отправке доставки и откладывает их на lease,
+// чтобы другой экземпляр сервиса не отправил их параллельно
+func (r *WebhookRepository) ClaimDue(limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+		ids := make([]uuid.UUID, len(deliveries))
+		for i, d := range deliveries {
+			ids[i] = d.ID
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	return deliveries, err
+}
+
+func (r *WebhookRepository) UpdateDelivery(id uuid.UUID, fields map[string]interface{}) error {
+	return r.db.Model(&models.WebhookDelivery{}).Where("id = ?", id).Updates(fields).Error
+}
+
+func (r *WebhookRepository) DeleteDeliveriesBefore(t time.Time) (int64, error) {
+	res := r.db.Where("created_at < ? AND status <> ?", t, models.WebhookDeliveryPending).Delete(&models.WebhookDelivery{})
+	return res.RowsAffected, res.Error
+}
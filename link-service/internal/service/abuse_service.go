@@ -0,0 +1,198 @@
+package service
+
+import (
+	"errors"
+	"link-service/internal/models"
+	"link-service/internal/repository"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var ErrForbidden = errors.New("forbidden")
+var ErrInvalidReportReason = errors.New("invalid report reason")
+var ErrReportAlreadyExists = errors.New("report already exists")
+var ErrReportNotOpen = errors.New("report is not open")
+
+const (
+	ReportReasonPhishing = "phishing"
+	ReportReasonMalware  = "malware"
+	ReportReasonSpam     = "spam"
+	ReportReasonIllegal  = "illegal"
+	ReportReasonOther    = "other"
+)
+
+var reportReasons = map[string]bool{
+	ReportReasonPhishing: true,
+	ReportReasonMalware:  true,
+	ReportReasonSpam:     true,
+	ReportReasonIllegal:  true,
+	ReportReasonOther:    true,
+}
+
+const maxReportDetailsLength = 1024
+
+// Действия, фиксируемые в журнале аудита
+const (
+	AuditActionReportCreated   = "report.created"
+	AuditActionReportDismissed = "report.dismissed"
+	AuditActionLinkBlocked     = "link.blocked"
+	AuditActionLinkUnblocked   = "link.unblocked"
+)
+
+type AbuseService struct {
+	links      *ShortLinkService
+	linkRepo   *repository.ShortLinkRepository
+	reportRepo *repository.LinkReportRepository
+	auditRepo  *repository.AuditRepository
+	admins     map[uuid.UUID]bool
+	log        *zap.Logger
+}
+
+func NewAbuseService(
+	links *ShortLinkService,
+	linkRepo *repository.ShortLinkRepository,
+	reportRepo *repository.LinkReportRepository,
+	auditRepo *repository.AuditRepository,
+	adminIDs []string,
+	log *zap.Logger,
+) *AbuseService {
+	admins := make(map[uuid.UUID]bool, len(adminIDs))
+	for _, id := range adminIDs {
+		if parsed, err := uuid.Parse(id); err == nil {
+			admins[parsed] = true
+		} else {
+			log.Warn("Invalid admin user id in config", zap.String("id", id))
+		}
+	}
+	return &AbuseService{
+		links:      links,
+		linkRepo:   linkRepo,
+		reportRepo: reportRepo,
+		auditRepo:  auditRepo,
+		admins:     admins,
+		log:        log,
+	}
+}
+
+func (s *AbuseService) IsAdmin(userID uuid.UUID) bool {
+	return s.admins[userID]
+}
+
+// ReportLink — публичная жалоба на короткую ссылку (доступна анонимно).
+// host — хост короткой ссылки: основной или брендированный домен.
+func (s *AbuseService) ReportLink(host, shortCode, reason, details, reporterIP string, reporterUserID *uuid.UUID) (*models.LinkReport, error) {
+	reason = strings.ToLower(strings.TrimSpace(reason))
+	if !reportReasons[reason] {
+		return nil, ErrInvalidReportReason
+	}
+	details = strings.TrimSpace(details)
+	if len(details) > maxReportDetailsLength {
+		details = details[:maxReportDetailsLength]
+	}
+
+	link, err := s.links.GetLinkByHostAndCode(host, shortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := s.reportRepo.HasOpenReport(link.ID, reporterIP)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrReportAlreadyExists
+	}
+
+	report := &models.LinkReport{
+		ShortLinkID:    link.ID,
+		ReporterIP:     reporterIP,
+		ReporterUserID: reporterUserID,
+		Reason:         reason,
+		Details:        details,
+		Status:         models.ReportStatusOpen,
+	}
+	if err := s.reportRepo.Create(report); err != nil {
+		s.log.Error("Failed to create link report", zap.Error(err))
+		return nil, err
+	}
+
+	s.audit(reporterUserID, reporterIP, AuditActionReportCreated, &link.ID, &report.ID, reason)
+	return report, nil
+}
+
+func (s *AbuseService) ListReports(adminID uuid.UUID, status string, limit, offset int) ([]models.LinkReport, error) {
+	if !s.IsAdmin(adminID) {
+		return nil, ErrForbidden
+	}
+	return s.reportRepo.List(status, limit, offset)
+}
+
+// BlockLink блокирует ссылку и закрывает все открытые жалобы на неё
+func (s *AbuseService) BlockLink(adminID, linkID uuid.UUID, reason string) error {
+	if !s.IsAdmin(adminID) {
+		return ErrForbidden
+	}
+	if _, err := s.linkRepo.GetByID(linkID); err != nil {
+		return err
+	}
+	reason = strings.TrimSpace(reason)
+	if err := s.linkRepo.SetBlocked(linkID, reason); err != nil {
+		s.log.Error("Failed to block link", zap.String("id", linkID.String()), zap.Error(err))
+		return err
+	}
+	if err := s.reportRepo.ResolveOpenForLink(linkID, adminID); err != nil {
+		s.log.Warn("Failed to resolve reports for blocked link", zap.String("id", linkID.String()), zap.Error(err))
+	}
+	s.audit(&adminID, "", AuditActionLinkBlocked, &linkID, nil, reason)
+	return nil
+}
+
+func (s *AbuseService) UnblockLink(adminID, linkID uuid.UUID) error {
+	if !s.IsAdmin(adminID) {
+		return ErrForbidden
+	}
+	if _, err := s.linkRepo.GetByID(linkID); err != nil {
+		return err
+	}
+	if err := s.linkRepo.ClearBlocked(linkID); err != nil {
+		s.log.Error("Failed to unblock link", zap.String("id", linkID.String()), zap.Error(err))
+		return err
+	}
+	s.audit(&adminID, "", AuditActionLinkUnblocked, &linkID, nil, "")
+	return nil
+}
+
+func (s *AbuseService) DismissReport(adminID, reportID uuid.UUID) error {
+	if !s.IsAdmin(adminID) {
+		return ErrForbidden
+	}
+	report, err := s.reportRepo.GetByID(reportID)
+	if err != nil {
+		return err
+	}
+	if report.Status != models.ReportStatusOpen {
+		return ErrReportNotOpen
+	}
+	if err := s.reportRepo.SetStatus(reportID, models.ReportStatusDismissed, adminID); err != nil {
+		return err
+	}
+	s.audit(&adminID, "", AuditActionReportDismissed, &report.ShortLinkID, &reportID, "")
+	return nil
+}
+
+// audit пишет запись в журнал; ошибка записи не прерывает основное действие
+func (s *AbuseService) audit(actorID *uuid.UUID, actorIP, action string, linkID, reportID *uuid.UUID, details string) {
+	entry := &models.AuditEntry{
+		ActorID:     actorID,
+		ActorIP:     actorIP,
+		Action:      action,
+		ShortLinkID: linkID,
+		ReportID:    reportID,
+		Details:     details,
+	}
+	if err := s.auditRepo.Create(entry); err != nil {
+		s.log.Error("Failed to write audit entry", zap.String("action", action), zap.Error(err))
+	}
+}
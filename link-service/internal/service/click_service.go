@@ -2,6 +2,7 @@ package service
 
 import (
 	"encoding/json"
+	"link-service/internal/events"
 	"link-service/internal/models"
 	"link-service/internal/repository"
 	"net/http"
@@ -10,25 +11,41 @@ import (
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type ClickService struct {
-	repo *repository.ClickRepository
-	log  *zap.Logger
+	repo   *repository.ClickRepository
+	tags   *TagService
+	tx     *repository.Transactor
+	outbox *events.Outbox
+	log    *zap.Logger
 }
 
-func NewClickService(repo *repository.ClickRepository, log *zap.Logger) *ClickService {
+func NewClickService(repo *repository.ClickRepository, tags *TagService, tx *repository.Transactor, outbox *events.Outbox, log *zap.Logger) *ClickService {
 	return &ClickService{
-		repo: repo,
-		log:  log,
+		repo:   repo,
+		tags:   tags,
+		tx:     tx,
+		outbox: outbox,
+		log:    log,
 	}
 }
 
-func (s *ClickService) CreateClick(shortLinkID uuid.UUID, ip, userAgent string) error {
+const (
+	ClickSourceDirect = "direct"
+	ClickSourceQR     = "qr"
+)
+
+func (s *ClickService) CreateClick(shortLinkID uuid.UUID, ip, userAgent, source string) error {
+	if source == "" {
+		source = ClickSourceDirect
+	}
 	click := &models.Click{
 		ShortLinkID: shortLinkID,
 		IP:          ip,
 		UserAgent:   userAgent,
+		Source:      source,
 		ClickedAt:   time.Now(),
 	}
 	resp, err := http.Get("http://ip-api.com/json/" + ip)
@@ -44,7 +61,22 @@ func (s *ClickService) CreateClick(shortLinkID uuid.UUID, ip, userAgent string)
 		}
 	}
 
-	return s.repo.Create(click)
+	return s.tx.Do(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).Create(click); err != nil {
+			return err
+		}
+		if s.outbox == nil {
+			return nil
+		}
+		// IP и User-Agent в событие не попадают: наружу уходят только агрегируемые поля
+		return s.outbox.Enqueue(tx, events.New(events.TypeClickRecorded, shortLinkID, nil, map[string]any{
+			"click_id":   click.ID.String(),
+			"source":     click.Source,
+			"country":    click.Country,
+			"region":     click.Region,
+			"clicked_at": click.ClickedAt,
+		}))
+	})
 }
 
 type Stats struct {
@@ -55,59 +87,114 @@ type Stats struct {
 	Countries      []string
 	CountriesStats map[string]int64
 	DailyStats     map[string]int64
+	SourceStats    map[string]int64
 }
 
-func (s *ClickService) GetStats(shortLinkID string) (Stats, error) {
+// GetStats считает статистику по кликам начиная с since; нулевое since — за всё время
+func (s *ClickService) GetStats(shortLinkID string, since time.Time) (Stats, error) {
 	var stats Stats
+	repo := s.clicksSince(since)
 	// Общее количество переходов
-	total, err := s.repo.GetCount(shortLinkID)
+	total, err := repo.GetCount(shortLinkID)
 	if err != nil {
 		return stats, err
 	}
 	stats.Total = total
 
 	// Уникальные IP
-	uniqueIPCount, err := s.repo.GetUniqueIPCount(shortLinkID)
+	uniqueIPCount, err := repo.GetUniqueIPCount(shortLinkID)
 	if err != nil {
 		return stats, err
 	}
 	stats.UniqueIPCount = uniqueIPCount
 
-	uniqueIPs, err := s.repo.GetUniqueIPs(shortLinkID)
+	uniqueIPs, err := repo.GetUniqueIPs(shortLinkID)
 	if err != nil {
 		return stats, err
 	}
 	stats.UniqueIPs = uniqueIPs
 
 	// География по странам
-	countries, err := s.repo.GetUniqueCountries(shortLinkID)
+	countries, err := repo.GetUniqueCountries(shortLinkID)
 	if err != nil {
 		return stats, err
 	}
 	stats.CountriesCount = len(countries)
 	stats.Countries = countries
 
-	countryStats, err := s.repo.GetCountryStats(shortLinkID)
+	countryStats, err := repo.GetCountryStats(shortLinkID)
 	if err != nil {
 		return stats, err
 	}
 	stats.CountriesStats = countryStats
 
 	// График по дням
-	dailyStats, err := s.repo.GetDailyStats(shortLinkID)
+	dailyStats, err := repo.GetDailyStats(shortLinkID)
 	if err != nil {
 		return stats, err
 	}
 	stats.DailyStats = dailyStats
 
+	// Источники переходов (прямые / QR)
+	sourceStats, err := repo.GetSourceStats(shortLinkID)
+	if err != nil {
+		return stats, err
+	}
+	stats.SourceStats = sourceStats
+
 	return stats, nil
 }
 
-func (s *ClickService) GetClicks(shortLinkID string) ([]models.Click, error) {
-	clicks, err := s.repo.GetClicksByShortLinkID(shortLinkID)
+func (s *ClickService) GetClicks(shortLinkID string, since time.Time) ([]models.Click, error) {
+	clicks, err := s.clicksSince(since).GetClicksByShortLinkID(shortLinkID)
 	if err != nil {
 		return nil, err
 	}
 	sort.Slice(clicks, func(i, j int) bool { return clicks[i].ClickedAt.After(clicks[j].ClickedAt) })
 	return clicks, nil
 }
+
+func (s *ClickService) clicksSince(since time.Time) *repository.ClickRepository {
+	if since.IsZero() {
+		return s.repo
+	}
+	return s.repo.Since(since)
+}
+
+type TagStats struct {
+	Total         int64
+	UniqueIPCount int64
+	Links         []repository.TagLinkStats
+	DailyStats    map[string]int64
+}
+
+// GetTagStats — агрегированная статистика по всем ссылкам тега (кампании) пользователя
+// с учётом окна статистики плана (since)
+func (s *ClickService) GetTagStats(userID, tagID uuid.UUID, since time.Time) (TagStats, error) {
+	var stats TagStats
+	if _, err := s.tags.GetTag(userID, tagID); err != nil {
+		return stats, err
+	}
+
+	repo := s.clicksSince(since)
+	total, uniqueIPs, err := repo.GetTagTotals(tagID)
+	if err != nil {
+		return stats, err
+	}
+	stats.Total = total
+	stats.UniqueIPCount = uniqueIPs
+
+	links, err := s.repo.GetTagLinkStats(tagID, since)
+	if err != nil {
+		return stats, err
+	}
+	stats.Links = links
+
+	daily, err := repo.GetTagDailyStats(tagID)
+	if err != nil {
+		return stats, err
+	}
+	stats.DailyStats = daily
+
+	return stats, nil
+}
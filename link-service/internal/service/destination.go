@@ -0,0 +1,176 @@
+package service
+
+import (
+	"errors"
+	"link-service/internal/models"
+	"net/url"
+	"strings"
+)
+
+var ErrInvalidUTM = errors.New("invalid utm parameter")
+var ErrInvalidDestination = errors.New("invalid destination url")
+
+const maxUTMLength = 256
+
+// UTMParams — структурированные UTM-метки ссылки
+type UTMParams struct {
+	Source   string
+	Medium   string
+	Campaign string
+	Term     string
+	Content  string
+}
+
+func (p UTMParams) normalize() (UTMParams, error) {
+	n := UTMParams{
+		Source:   strings.TrimSpace(p.Source),
+		Medium:   strings.TrimSpace(p.Medium),
+		Campaign: strings.TrimSpace(p.Campaign),
+		Term:     strings.TrimSpace(p.Term),
+		Content:  strings.TrimSpace(p.Content),
+	}
+	for _, v := range []string{n.Source, n.Medium, n.Campaign, n.Term, n.Content} {
+		if len(v) > maxUTMLength {
+			return UTMParams{}, ErrInvalidUTM
+		}
+	}
+	return n, nil
+}
+
+type queryPair struct {
+	key   string
+	value string
+}
+
+func utmPairs(link *models.ShortLink) []queryPair {
+	candidates := []queryPair{
+		{"utm_source", link.UTMSource},
+		{"utm_medium", link.UTMMedium},
+		{"utm_campaign", link.UTMCampaign},
+		{"utm_term", link.UTMTerm},
+		{"utm_content", link.UTMContent},
+	}
+	pairs := make([]queryPair, 0, len(candidates))
+	for _, p := range candidates {
+		if p.value != "" {
+			pairs = append(pairs, p)
+		}
+	}
+	return pairs
+}
+
+// BuildDestinationURL собирает итоговый URL редиректа.
+// Приоритет параметров: UTM-метки ссылки > query OriginalURL > проброшенный query короткой ссылки.
+// Порядок и кодирование исходных параметров OriginalURL сохраняются.
+func BuildDestinationURL(link *models.ShortLink, incomingQuery string) (string, error) {
+	overrides := utmPairs(link)
+
+	var extras []queryPair
+	if link.ForwardQuery && incomingQuery != "" {
+		values, err := url.ParseQuery(strings.TrimPrefix(incomingQuery, "?"))
+		if err != nil {
+			return "", ErrInvalidDestination
+		}
+		for _, part := range strings.Split(strings.TrimPrefix(incomingQuery, "?"), "&") {
+			key := partKey(part)
+			if key == "" {
+				continue
+			}
+			if vals, ok := values[key]; ok {
+				for _, v := range vals {
+					extras = append(extras, queryPair{key, v})
+				}
+				delete(values, key)
+			}
+		}
+	}
+
+	if len(overrides) == 0 && len(extras) == 0 {
+		return link.OriginalURL, nil
+	}
+
+	u, err := url.Parse(link.OriginalURL)
+	if err != nil {
+		return "", ErrInvalidDestination
+	}
+	u.RawQuery = mergeQuery(u.RawQuery, overrides, extras)
+	return u.String(), nil
+}
+
+func mergeQuery(rawQuery string, overrides, extras []queryPair) string {
+	overrideIdx := make(map[string]int, len(overrides))
+	for i, p := range overrides {
+		overrideIdx[p.key] = i
+	}
+	applied := make(map[string]bool, len(overrides))
+	present := make(map[string]bool)
+
+	var parts []string
+	if rawQuery != "" {
+		for _, part := range strings.Split(rawQuery, "&") {
+			if part == "" {
+				continue
+			}
+			key := partKey(part)
+			present[key] = true
+			if i, ok := overrideIdx[key]; ok {
+				// Конфликт с UTM-меткой ссылки: оставляем одно значение из ссылки
+				if !applied[key] {
+					parts = append(parts, encodePair(overrides[i]))
+					applied[key] = true
+				}
+				continue
+			}
+			parts = append(parts, part)
+		}
+	}
+
+	for _, p := range overrides {
+		if !applied[p.key] {
+			parts = append(parts, encodePair(p))
+			present[p.key] = true
+		}
+	}
+	for _, p := range extras {
+		if present[p.key] || applied[p.key] {
+			continue
+		}
+		parts = append(parts, encodePair(p))
+	}
+	return strings.Join(parts, "&")
+}
+
+func partKey(part string) string {
+	key, _, _ := strings.Cut(part, "=")
+	if unescaped, err := url.QueryUnescape(key); err == nil {
+		return unescaped
+	}
+	return key
+}
+
+func encodePair(p queryPair) string {
+	return url.QueryEscape(p.key) + "=" + url.QueryEscape(p.value)
+}
+
+// QRMarkerParam — параметр, которым помечается URL, закодированный в QR-код
+const QRMarkerParam = "lv_qr"
+
+// ExtractQRMarker убирает из query маркер QR-кода и сообщает, был ли он
+func ExtractQRMarker(query string) (string, bool) {
+	query = strings.TrimPrefix(query, "?")
+	if query == "" {
+		return "", false
+	}
+	found := false
+	parts := make([]string, 0)
+	for _, part := range strings.Split(query, "&") {
+		if partKey(part) == QRMarkerParam {
+			found = true
+			continue
+		}
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "&"), found
+}
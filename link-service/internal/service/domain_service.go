@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"link-service/internal/models"
+	"link-service/internal/repository"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidDomain = errors.New("invalid domain")
+var ErrDomainNotVerified = errors.New("domain is not verified")
+var ErrVerificationFailed = errors.New("domain verification record not found")
+var ErrDomainTaken = errors.New("domain is already verified by another user")
+
+const (
+	// TXT-запись подтверждения: _linkvault.<host> TXT "linkvault-verify=<token>"
+	verificationRecordPrefix = "_linkvault."
+	verificationValuePrefix  = "linkvault-verify="
+)
+
+var hostLabelRe = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// TXTResolver — источник TXT-записей (net.DefaultResolver или заглушка в тестах)
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// DomainStore — хранилище доменов (repository.DomainRepository или заглушка в тестах)
+type DomainStore interface {
+	Create(domain *models.Domain) error
+	GetByID(id, userID uuid.UUID) (*models.Domain, error)
+	GetByUserID(userID uuid.UUID) ([]models.Domain, error)
+	GetByUserAndHost(userID uuid.UUID, host string) (*models.Domain, error)
+	GetVerifiedByHost(host string) (*models.Domain, error)
+	MarkVerified(id uuid.UUID) error
+	Delete(domain *models.Domain) error
+}
+
+var _ DomainStore = (*repository.DomainRepository)(nil)
+
+type DomainService struct {
+	repo          DomainStore
+	resolver      TXTResolver
+	defaultDomain string
+	log           *zap.Logger
+}
+
+func NewDomainService(repo DomainStore, resolver TXTResolver, defaultDomain string, log *zap.Logger) *DomainService {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return &DomainService{
+		repo:          repo,
+		resolver:      resolver,
+		defaultDomain: defaultDomain,
+		log:           log,
+	}
+}
+
+// NormalizeHost приводит имя домена к виду для хранения и поиска (нижний регистр, без порта и точки в конце)
+func NormalizeHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
+
+func validHost(host string) bool {
+	if len(host) > 253 || net.ParseIP(host) != nil {
+		return false
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, l := range labels {
+		if !hostLabelRe.MatchString(l) {
+			return false
+		}
+	}
+	return true
+}
+
+// AddDomain создаёт заявку на домен. Повторная заявка пользователя на тот же хост возвращает существующую;
+// хост, уже подтверждённый другим пользователем, занят.
+func (s *DomainService) AddDomain(userID uuid.UUID, host string) (*models.Domain, error) {
+	host = NormalizeHost(host)
+	if !validHost(host) {
+		return nil, ErrInvalidDomain
+	}
+	if u, err := url.Parse(s.defaultDomain); err == nil && NormalizeHost(u.Host) == host {
+		return nil, ErrInvalidDomain
+	}
+	if existing, err := s.repo.GetByUserAndHost(userID, host); err == nil {
+		return existing, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err := s.checkHostFree(host, userID); err != nil {
+		return nil, err
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+	domain := &models.Domain{
+		UserID:            userID,
+		Host:              host,
+		VerificationToken: hex.EncodeToString(token),
+	}
+	if err := s.repo.Create(domain); err != nil {
+		s.log.Warn("Failed to create domain", zap.String("host", host), zap.Error(err))
+		return nil, err
+	}
+	return domain, nil
+}
+
+// VerificationRecord — имя и значение TXT-записи, которую пользователь должен создать
+func VerificationRecord(domain *models.Domain) (name, value string) {
+	return verificationRecordPrefix + domain.Host, verificationValuePrefix + domain.VerificationToken
+}
+
+func (s *DomainService) ListDomains(userID uuid.UUID) ([]models.Domain, error) {
+	return s.repo.GetByUserID(userID)
+}
+
+// VerifyDomain проверяет TXT-запись и помечает домен подтверждённым
+func (s *DomainService) VerifyDomain(ctx context.Context, userID, domainID uuid.UUID) (*models.Domain, error) {
+	domain, err := s.repo.GetByID(domainID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if domain.Verified {
+		return domain, nil
+	}
+
+	name, expected := VerificationRecord(domain)
+	lookupCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	records, err := s.resolver.LookupTXT(lookupCtx, name)
+	if err != nil {
+		s.log.Info("Domain verification lookup failed", zap.String("host", domain.Host), zap.Error(err))
+		return nil, fmt.Errorf("%w: %v", ErrVerificationFailed, err)
+	}
+	for _, r := range records {
+		if strings.TrimSpace(r) == expected {
+			if err := s.checkHostFree(domain.Host, userID); err != nil {
+				return nil, err
+			}
+			if err := s.repo.MarkVerified(domain.ID); err != nil {
+				return nil, err
+			}
+			return s.repo.GetByID(domainID, userID)
+		}
+	}
+	return nil, ErrVerificationFailed
+}
+
+// checkHostFree возвращает ErrDomainTaken, если хост подтверждён другим пользователем
+func (s *DomainService) checkHostFree(host string, userID uuid.UUID) error {
+	verified, err := s.repo.GetVerifiedByHost(host)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if verified.UserID != userID {
+		return ErrDomainTaken
+	}
+	return nil
+}
+
+func (s *DomainService) DeleteDomain(userID, domainID uuid.UUID) error {
+	domain, err := s.repo.GetByID(domainID, userID)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(domain)
+}
+
+// ShortURL собирает короткий URL ссылки с учётом её домена
+func ShortURL(defaultDomain string, link *models.ShortLink) string {
+	if link.Domain != nil {
+		return fmt.Sprintf("https://%s/%s", link.Domain.Host, link.ShortCode)
+	}
+	return fmt.Sprintf("%s/%s", defaultDomain, link.ShortCode)
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"link-service/internal/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// stubResolver отдаёт заранее заданные TXT-записи
+type stubResolver struct {
+	records map[string][]string
+	err     error
+	lookups []string
+}
+
+func (r *stubResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	r.lookups = append(r.lookups, name)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.records[name], nil
+}
+
+// memoryDomainStore — DomainStore в памяти
+type memoryDomainStore struct {
+	domains map[uuid.UUID]*models.Domain
+}
+
+func newMemoryDomainStore() *memoryDomainStore {
+	return &memoryDomainStore{domains: map[uuid.UUID]*models.Domain{}}
+}
+
+func (s *memoryDomainStore) Create(d *models.Domain) error {
+	d.ID = uuid.New()
+	d.CreatedAt = time.Now()
+	copied := *d
+	s.domains[d.ID] = &copied
+	return nil
+}
+
+func (s *memoryDomainStore) GetByID(id, userID uuid.UUID) (*models.Domain, error) {
+	if d, ok := s.domains[id]; ok && d.UserID == userID {
+		copied := *d
+		return &copied, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *memoryDomainStore) GetByUserID(userID uuid.UUID) ([]models.Domain, error) {
+	var res []models.Domain
+	for _, d := range s.domains {
+		if d.UserID == userID {
+			res = append(res, *d)
+		}
+	}
+	return res, nil
+}
+
+func (s *memoryDomainStore) GetByUserAndHost(userID uuid.UUID, host string) (*models.Domain, error) {
+	for _, d := range s.domains {
+		if d.UserID == userID && d.Host == host {
+			copied := *d
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *memoryDomainStore) GetVerifiedByHost(host string) (*models.Domain, error) {
+	for _, d := range s.domains {
+		if d.Host == host && d.Verified {
+			copied := *d
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *memoryDomainStore) MarkVerified(id uuid.UUID) error {
+	now := time.Now()
+	s.domains[id].Verified = true
+	s.domains[id].VerifiedAt = &now
+	return nil
+}
+
+func (s *memoryDomainStore) Delete(d *models.Domain) error {
+	delete(s.domains, d.ID)
+	return nil
+}
+
+func newTestDomainService(resolver TXTResolver) (*DomainService, *memoryDomainStore) {
+	store := newMemoryDomainStore()
+	return NewDomainService(store, resolver, "https://lnkv.io", zap.NewNop()), store
+}
+
+func TestAddDomainNormalizesAndValidatesHost(t *testing.T) {
+	svc, _ := newTestDomainService(&stubResolver{})
+	userID := uuid.New()
+
+	domain, err := svc.AddDomain(userID, "  Go.Example.COM.  ")
+	if err != nil {
+		t.Fatalf("AddDomain: %v", err)
+	}
+	if domain.Host != "go.example.com" {
+		t.Errorf("Host = %q, want normalized go.example.com", domain.Host)
+	}
+	if len(domain.VerificationToken) != 32 {
+		t.Errorf("VerificationToken = %q, want 32 hex chars", domain.VerificationToken)
+	}
+
+	for _, host := range []string{"localhost", "127.0.0.1", "bad_label.com", "-x.com", "lnkv.io"} {
+		if _, err := svc.AddDomain(userID, host); !errors.Is(err, ErrInvalidDomain) {
+			t.Errorf("AddDomain(%q) err = %v, want ErrInvalidDomain", host, err)
+		}
+	}
+}
+
+func TestAddDomainTwiceReturnsExistingClaim(t *testing.T) {
+	svc, store := newTestDomainService(&stubResolver{})
+	userID := uuid.New()
+
+	first, err := svc.AddDomain(userID, "go.example.com")
+	if err != nil {
+		t.Fatalf("AddDomain: %v", err)
+	}
+	second, err := svc.AddDomain(userID, "GO.example.com")
+	if err != nil {
+		t.Fatalf("AddDomain again: %v", err)
+	}
+	if first.ID != second.ID || len(store.domains) != 1 {
+		t.Fatalf("expected the existing claim to be returned, have %d domains", len(store.domains))
+	}
+}
+
+func TestVerifyDomainWithTXTRecord(t *testing.T) {
+	resolver := &stubResolver{records: map[string][]string{}}
+	svc, _ := newTestDomainService(resolver)
+	userID := uuid.New()
+
+	domain, err := svc.AddDomain(userID, "go.example.com")
+	if err != nil {
+		t.Fatalf("AddDomain: %v", err)
+	}
+	name, value := VerificationRecord(domain)
+	if name != "_linkvault.go.example.com" {
+		t.Fatalf("record name = %q", name)
+	}
+
+	// Записи ещё нет
+	if _, err := svc.VerifyDomain(context.Background(), userID, domain.ID); !errors.Is(err, ErrVerificationFailed) {
+		t.Fatalf("VerifyDomain without record err = %v, want ErrVerificationFailed", err)
+	}
+
+	resolver.records[name] = []string{"v=spf1 -all", " " + value + " "}
+	verified, err := svc.VerifyDomain(context.Background(), userID, domain.ID)
+	if err != nil {
+		t.Fatalf("VerifyDomain: %v", err)
+	}
+	if !verified.Verified || verified.VerifiedAt == nil {
+		t.Fatalf("domain not marked verified: %+v", verified)
+	}
+	if got := resolver.lookups[len(resolver.lookups)-1]; got != name {
+		t.Errorf("looked up %q, want %q", got, name)
+	}
+}
+
+func TestVerifyDomainRejectsWrongToken(t *testing.T) {
+	resolver := &stubResolver{records: map[string][]string{}}
+	svc, _ := newTestDomainService(resolver)
+	userID := uuid.New()
+
+	domain, _ := svc.AddDomain(userID, "go.example.com")
+	name, _ := VerificationRecord(domain)
+	resolver.records[name] = []string{"linkvault-verify=someone-elses-token"}
+
+	if _, err := svc.VerifyDomain(context.Background(), userID, domain.ID); !errors.Is(err, ErrVerificationFailed) {
+		t.Fatalf("err = %v, want ErrVerificationFailed", err)
+	}
+}
+
+func TestVerifyDomainLookupError(t *testing.T) {
+	svc, _ := newTestDomainService(&stubResolver{err: errors.New("NXDOMAIN")})
+	userID := uuid.New()
+	domain, _ := svc.AddDomain(userID, "go.example.com")
+
+	if _, err := svc.VerifyDomain(context.Background(), userID, domain.ID); !errors.Is(err, ErrVerificationFailed) {
+		t.Fatalf("err = %v, want ErrVerificationFailed", err)
+	}
+}
+
+func TestVerifyDomainOfAnotherUserNotFound(t *testing.T) {
+	svc, _ := newTestDomainService(&stubResolver{})
+	domain, _ := svc.AddDomain(uuid.New(), "go.example.com")
+
+	if _, err := svc.VerifyDomain(context.Background(), uuid.New(), domain.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("err = %v, want ErrRecordNotFound", err)
+	}
+}
+
+func TestPendingClaimsDoNotBlockOwner(t *testing.T) {
+	resolver := &stubResolver{records: map[string][]string{}}
+	svc, _ := newTestDomainService(resolver)
+	squatter, owner := uuid.New(), uuid.New()
+
+	// Неподтверждённая заявка не мешает настоящему владельцу добавить и подтвердить хост
+	if _, err := svc.AddDomain(squatter, "go.example.com"); err != nil {
+		t.Fatalf("AddDomain squatter: %v", err)
+	}
+	domain, err := svc.AddDomain(owner, "go.example.com")
+	if err != nil {
+		t.Fatalf("AddDomain owner: %v", err)
+	}
+	name, value := VerificationRecord(domain)
+	resolver.records[name] = []string{value}
+	if _, err := svc.VerifyDomain(context.Background(), owner, domain.ID); err != nil {
+		t.Fatalf("VerifyDomain owner: %v", err)
+	}
+
+	// После подтверждения хост занят для остальных
+	if _, err := svc.AddDomain(uuid.New(), "go.example.com"); !errors.Is(err, ErrDomainTaken) {
+		t.Fatalf("AddDomain after verification err = %v, want ErrDomainTaken", err)
+	}
+}
+
+func TestVerifyDomainTakenByAnotherUser(t *testing.T) {
+	resolver := &stubResolver{records: map[string][]string{}}
+	svc, _ := newTestDomainService(resolver)
+	first, second := uuid.New(), uuid.New()
+
+	d1, _ := svc.AddDomain(first, "go.example.com")
+	d2, _ := svc.AddDomain(second, "go.example.com")
+	name, v1 := VerificationRecord(d1)
+	_, v2 := VerificationRecord(d2)
+	resolver.records[name] = []string{v1, v2}
+
+	if _, err := svc.VerifyDomain(context.Background(), first, d1.ID); err != nil {
+		t.Fatalf("VerifyDomain first: %v", err)
+	}
+	if _, err := svc.VerifyDomain(context.Background(), second, d2.ID); !errors.Is(err, ErrDomainTaken) {
+		t.Fatalf("VerifyDomain second err = %v, want ErrDomainTaken", err)
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"link-service/internal/events"
+	"link-service/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// linkEventData — общая часть payload событий о ссылке
+func linkEventData(link *models.ShortLink) map[string]any {
+	data := map[string]any{
+		"short_code":   link.ShortCode,
+		"original_url": link.OriginalURL,
+		"is_active":    link.IsActive,
+		"expire_at":    link.ExpireAt,
+		"created_at":   link.CreatedAt,
+	}
+	if link.DomainID != nil {
+		data["domain_id"] = link.DomainID.String()
+	}
+	return data
+}
+
+// enqueueLinkEvent кладёт событие о ссылке в outbox в транзакции tx
+func enqueueLinkEvent(outbox *events.Outbox, tx *gorm.DB, eventType string, link *models.ShortLink, extra map[string]any) error {
+	if outbox == nil {
+		return nil
+	}
+	data := linkEventData(link)
+	for k, v := range extra {
+		data[k] = v
+	}
+	return outbox.Enqueue(tx, events.New(eventType, link.ID, link.UserID, data))
+}
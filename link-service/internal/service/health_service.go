@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"link-service/internal/events"
+	"link-service/internal/linkcheck"
+	"link-service/internal/models"
+	"link-service/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// Ссылка считается сломанной после стольких неудачных проверок подряд
+	brokenThreshold = 3
+	healthBatchSize = 200
+	healthInterval  = 6 * time.Hour
+)
+
+// HealthService периодически проверяет доступность целевых URL пользовательских ссылок
+type HealthService struct {
+	linkRepo   *repository.ShortLinkRepository
+	healthRepo *repository.LinkHealthRepository
+	checker    *linkcheck.Checker
+	tx         *repository.Transactor
+	outbox     *events.Outbox
+	log        *zap.Logger
+}
+
+func NewHealthService(
+	linkRepo *repository.ShortLinkRepository,
+	healthRepo *repository.LinkHealthRepository,
+	checker *linkcheck.Checker,
+	tx *repository.Transactor,
+	outbox *events.Outbox,
+	log *zap.Logger,
+) *HealthService {
+	return &HealthService{
+		linkRepo:   linkRepo,
+		healthRepo: healthRepo,
+		checker:    checker,
+		tx:         tx,
+		outbox:     outbox,
+		log:        log,
+	}
+}
+
+// RunChecks проверяет очередную пачку ссылок и возвращает число проверенных
+func (s *HealthService) RunChecks(ctx context.Context) (int, error) {
+	links, err := s.linkRepo.FindDueForHealthCheck(time.Now().Add(-healthInterval), healthBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	byID := make(map[string]*models.ShortLink, len(links))
+	targets := make([]linkcheck.Target, 0, len(links))
+	for _, l := range links {
+		byID[l.ID.String()] = l
+		targets = append(targets, linkcheck.Target{ID: l.ID.String(), URL: l.OriginalURL})
+	}
+
+	s.checker.CheckAll(ctx, targets, func(t linkcheck.Target, res linkcheck.Result) {
+		s.record(byID[t.ID], res)
+	})
+	return len(links), nil
+}
+
+func (s *HealthService) record(link *models.ShortLink, res linkcheck.Result) {
+	now := time.Now()
+	check := &models.LinkHealthCheck{
+		ShortLinkID: link.ID,
+		StatusCode:  res.StatusCode,
+		Healthy:     !res.Broken(),
+		LatencyMs:   res.Latency.Milliseconds(),
+		CheckedAt:   now,
+	}
+	if res.Err != nil {
+		check.Error = res.Err.Error()
+	}
+	if err := s.healthRepo.Create(check); err != nil {
+		s.log.Warn("Failed to save health check", zap.String("id", link.ID.String()), zap.Error(err))
+	}
+
+	failures := 0
+	if res.Broken() {
+		failures = link.HealthFailures + 1
+	}
+	broken := failures >= brokenThreshold
+
+	// События только при смене состояния, чтобы владелец не получал повторных писем
+	var eventType string
+	switch {
+	case broken && !link.IsBroken:
+		eventType = events.TypeLinkBroken
+	case !broken && link.IsBroken:
+		eventType = events.TypeLinkRecovered
+	}
+
+	err := s.tx.Do(func(tx *gorm.DB) error {
+		if err := s.linkRepo.WithTx(tx).UpdateHealth(link.ID, broken, failures, now); err != nil {
+			return err
+		}
+		if eventType == "" {
+			return nil
+		}
+		return enqueueLinkEvent(s.outbox, tx, eventType, link, map[string]any{
+			"status_code": check.StatusCode,
+			"error":       check.Error,
+			"checked_at":  check.CheckedAt,
+		})
+	})
+	if err != nil {
+		s.log.Warn("Failed to update link health", zap.String("id", link.ID.String()), zap.Error(err))
+	}
+}
+
+// History — последние проверки ссылки владельца
+func (s *HealthService) History(linkID, userID uuid.UUID, limit int) ([]models.LinkHealthCheck, error) {
+	if _, err := s.linkRepo.GetShortLinkByID(linkID.String(), userID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.healthRepo.ListByShortLinkID(linkID, limit)
+}
+
+// CleanupHistory удаляет историю проверок старше retention
+func (s *HealthService) CleanupHistory(retention time.Duration) (int64, error) {
+	return s.healthRepo.DeleteOlderThan(time.Now().Add(-retention))
+}
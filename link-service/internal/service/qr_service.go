@@ -0,0 +1,66 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"link-service/internal/models"
+	"link-service/internal/qr"
+	"link-service/internal/repository"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported qr format")
+
+const (
+	QRFormatPNG = "png"
+	QRFormatSVG = "svg"
+)
+
+type QRService struct {
+	repo   *repository.ShortLinkRepository
+	domain string
+	log    *zap.Logger
+}
+
+func NewQRService(repo *repository.ShortLinkRepository, domain string, log *zap.Logger) *QRService {
+	return &QRService{
+		repo:   repo,
+		domain: domain,
+		log:    log,
+	}
+}
+
+// QRContent — URL, кодируемый в QR; маркер позволяет считать сканирования отдельно
+func (s *QRService) QRContent(link *models.ShortLink) string {
+	return fmt.Sprintf("%s?%s=1", ShortURL(s.domain, link), QRMarkerParam)
+}
+
+// GenerateForLink рисует QR-код ссылки пользователя; возвращает данные и content-type
+func (s *QRService) GenerateForLink(linkID, userID uuid.UUID, format string, opts qr.Options) ([]byte, string, error) {
+	link, err := s.repo.GetShortLinkByID(linkID.String(), userID)
+	if err != nil {
+		return nil, "", err
+	}
+	content := s.QRContent(link)
+
+	switch format {
+	case "", QRFormatPNG:
+		data, err := qr.PNG(content, opts)
+		if err != nil {
+			s.log.Warn("Failed to render qr png", zap.String("id", linkID.String()), zap.Error(err))
+			return nil, "", err
+		}
+		return data, "image/png", nil
+	case QRFormatSVG:
+		data, err := qr.SVG(content, opts)
+		if err != nil {
+			s.log.Warn("Failed to render qr svg", zap.String("id", linkID.String()), zap.Error(err))
+			return nil, "", err
+		}
+		return data, "image/svg+xml", nil
+	default:
+		return nil, "", ErrUnsupportedFormat
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"link-service/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var ErrQuotaExceeded = errors.New("quota exceeded")
+var ErrUnknownPlan = errors.New("unknown plan")
+
+const (
+	PlanFree     = "free"
+	PlanPro      = "pro"
+	PlanBusiness = "business"
+)
+
+// PlanLimits — ограничения тарифного плана; 0 — без ограничения
+type PlanLimits struct {
+	MaxActiveLinks         int64
+	MaxCustomAliases       int64
+	AnalyticsRetentionDays int
+	MaxAPICallsPerDay      int64
+}
+
+var plans = map[string]PlanLimits{
+	PlanFree:     {MaxActiveLinks: 100, MaxCustomAliases: 5, AnalyticsRetentionDays: 30, MaxAPICallsPerDay: 1000},
+	PlanPro:      {MaxActiveLinks: 5000, MaxCustomAliases: 500, AnalyticsRetentionDays: 365, MaxAPICallsPerDay: 50000},
+	PlanBusiness: {AnalyticsRetentionDays: 730},
+}
+
+// Usage — текущее потребление квот пользователем
+type Usage struct {
+	Plan          string
+	Limits        PlanLimits
+	ActiveLinks   int64
+	CustomAliases int64
+	APICallsToday int64
+}
+
+// QuotaService применяет лимиты тарифного плана пользователя
+type QuotaService struct {
+	planRepo    *repository.PlanRepository
+	linkRepo    *repository.ShortLinkRepository
+	defaultPlan string
+	log         *zap.Logger
+}
+
+func NewQuotaService(planRepo *repository.PlanRepository, linkRepo *repository.ShortLinkRepository, defaultPlan string, log *zap.Logger) *QuotaService {
+	if _, ok := plans[defaultPlan]; !ok {
+		log.Warn("Unknown default plan, falling back to free", zap.String("plan", defaultPlan))
+		defaultPlan = PlanFree
+	}
+	return &QuotaService{
+		planRepo:    planRepo,
+		linkRepo:    linkRepo,
+		defaultPlan: defaultPlan,
+		log:         log,
+	}
+}
+
+// Limits возвращает план пользователя и его ограничения
+func (s *QuotaService) Limits(userID uuid.UUID) (string, PlanLimits, error) {
+	plan, err := s.planRepo.GetPlan(userID)
+	if err != nil {
+		return "", PlanLimits{}, err
+	}
+	limits, ok := plans[plan]
+	if !ok {
+		plan = s.defaultPlan
+		limits = plans[plan]
+	}
+	return plan, limits, nil
+}
+
+// SetPlan назначает пользователю план (вызывается биллингом / администратором)
+func (s *QuotaService) SetPlan(userID uuid.UUID, plan string) error {
+	if _, ok := plans[plan]; !ok {
+		return ErrUnknownPlan
+	}
+	return s.planRepo.SetPlan(userID, plan)
+}
+
+// CheckLinkQuota проверяет лимиты перед созданием ссылки.
+// Вызывается в транзакции создания: блокировка по пользователю исключает гонку параллельных запросов.
+func (s *QuotaService) CheckLinkQuota(tx *gorm.DB, userID uuid.UUID, customAlias bool) error {
+	_, limits, err := s.Limits(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.planRepo.WithTx(tx).LockUser(userID); err != nil {
+		return err
+	}
+	linkRepo := s.linkRepo.WithTx(tx)
+	if limits.MaxActiveLinks > 0 {
+		count, err := linkRepo.CountActiveByUser(userID)
+		if err != nil {
+			return err
+		}
+		if count >= limits.MaxActiveLinks {
+			return fmt.Errorf("%w: active links limit %d", ErrQuotaExceeded, limits.MaxActiveLinks)
+		}
+	}
+	if customAlias && limits.MaxCustomAliases > 0 {
+		count, err := linkRepo.CountAliasesByUser(userID)
+		if err != nil {
+			return err
+		}
+		if count >= limits.MaxCustomAliases {
+			return fmt.Errorf("%w: custom aliases limit %d", ErrQuotaExceeded, limits.MaxCustomAliases)
+		}
+	}
+	return nil
+}
+
+// ConsumeAPICall учитывает вызов API и проверяет дневной лимит
+func (s *QuotaService) ConsumeAPICall(userID uuid.UUID) error {
+	_, limits, err := s.Limits(userID)
+	if err != nil {
+		return err
+	}
+	calls, err := s.planRepo.IncrementAPICalls(userID, today())
+	if err != nil {
+		return err
+	}
+	if limits.MaxAPICallsPerDay > 0 && calls > limits.MaxAPICallsPerDay {
+		return fmt.Errorf("%w: api calls per day limit %d", ErrQuotaExceeded, limits.MaxAPICallsPerDay)
+	}
+	return nil
+}
+
+// AnalyticsSince — начало окна статистики, доступного по плану; нулевое время — без ограничения
+func (s *QuotaService) AnalyticsSince(userID uuid.UUID) (time.Time, error) {
+	_, limits, err := s.Limits(userID)
+	if err != nil || limits.AnalyticsRetentionDays <= 0 {
+		return time.Time{}, err
+	}
+	return time.Now().AddDate(0, 0, -limits.AnalyticsRetentionDays), nil
+}
+
+func (s *QuotaService) Usage(userID uuid.UUID) (*Usage, error) {
+	plan, limits, err := s.Limits(userID)
+	if err != nil {
+		return nil, err
+	}
+	usage := &Usage{Plan: plan, Limits: limits}
+	if usage.ActiveLinks, err = s.linkRepo.CountActiveByUser(userID); err != nil {
+		return nil, err
+	}
+	if usage.CustomAliases, err = s.linkRepo.CountAliasesByUser(userID); err != nil {
+		return nil, err
+	}
+	if usage.APICallsToday, err = s.planRepo.GetAPICalls(userID, today()); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// CleanupAPIUsage удаляет дневные счётчики старше keepDays
+func (s *QuotaService) CleanupAPIUsage(keepDays int) (int64, error) {
+	return s.planRepo.DeleteAPIUsageBefore(today().AddDate(0, 0, -keepDays))
+}
+
+func today() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
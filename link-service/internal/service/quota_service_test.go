@@ -0,0 +1,124 @@
+package service
+
+import (
+	"errors"
+	"link-service/internal/models"
+	"link-service/internal/repository"
+	"link-service/internal/storage/testdb"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func newTestQuotaService(db *gorm.DB) *QuotaService {
+	return NewQuotaService(repository.NewPlanRepository(db), repository.NewShortLinkRepository(db), PlanFree, zap.NewNop())
+}
+
+// seedLinks создаёт n ссылок пользователя; edit меняет каждую перед вставкой
+func seedLinks(t *testing.T, db *gorm.DB, userID uuid.UUID, n int, edit func(*models.ShortLink)) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		link := &models.ShortLink{UserID: &userID, OriginalURL: "https://example.com", ShortCode: uuid.NewString(), IsActive: true}
+		if edit != nil {
+			edit(link)
+		}
+		if err := db.Create(link).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCheckLinkQuota(t *testing.T) {
+	db := testdb.Open(t)
+	quota := newTestQuotaService(db)
+	past := time.Now().Add(-time.Hour)
+	alias := func(l *models.ShortLink) { l.IsCustomAlias = true }
+
+	cases := []struct {
+		name        string
+		plan        string
+		seed        func(t *testing.T, userID uuid.UUID)
+		customAlias bool
+		want        error
+	}{
+		{"free below limit", "", func(t *testing.T, id uuid.UUID) { seedLinks(t, db, id, 99, nil) }, false, nil},
+		{"free at limit", "", func(t *testing.T, id uuid.UUID) { seedLinks(t, db, id, 100, nil) }, false, ErrQuotaExceeded},
+		{"inactive links not counted", "", func(t *testing.T, id uuid.UUID) {
+			seedLinks(t, db, id, 99, nil)
+			seedLinks(t, db, id, 5, func(l *models.ShortLink) { l.IsActive = false })
+		}, false, nil},
+		{"expired links not counted", "", func(t *testing.T, id uuid.UUID) {
+			seedLinks(t, db, id, 99, nil)
+			seedLinks(t, db, id, 5, func(l *models.ShortLink) { l.ExpireAt = &past })
+		}, false, nil},
+		{"alias at limit", "", func(t *testing.T, id uuid.UUID) { seedLinks(t, db, id, 5, alias) }, true, ErrQuotaExceeded},
+		{"generated code with aliases at limit", "", func(t *testing.T, id uuid.UUID) { seedLinks(t, db, id, 5, alias) }, false, nil},
+		{"inactive aliases not counted", "", func(t *testing.T, id uuid.UUID) {
+			seedLinks(t, db, id, 4, alias)
+			seedLinks(t, db, id, 3, func(l *models.ShortLink) { alias(l); l.IsActive = false })
+		}, true, nil},
+		{"pro above free limit", PlanPro, func(t *testing.T, id uuid.UUID) {
+			seedLinks(t, db, id, 100, nil)
+			seedLinks(t, db, id, 5, alias)
+		}, true, nil},
+		{"business unlimited", PlanBusiness, func(t *testing.T, id uuid.UUID) { seedLinks(t, db, id, 600, alias) }, true, nil},
+		{"unknown plan falls back to default", "legacy", func(t *testing.T, id uuid.UUID) { seedLinks(t, db, id, 100, nil) }, false, ErrQuotaExceeded},
+	}
+	for _, tc := range cases {
+		userID := uuid.New()
+		if tc.plan != "" {
+			if err := db.Create(&models.UserPlan{UserID: userID, Plan: tc.plan}).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+		tc.seed(t, userID)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return quota.CheckLinkQuota(tx, userID, tc.customAlias)
+		})
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: CheckLinkQuota = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+// Параллельные создания у последней свободной ссылки: пройти проверку должно только одно
+func TestCheckLinkQuotaConcurrent(t *testing.T) {
+	db := testdb.Open(t)
+	quota := newTestQuotaService(db)
+	userID := uuid.New()
+	seedLinks(t, db, userID, 99, nil)
+
+	const workers = 5
+	results := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = db.Transaction(func(tx *gorm.DB) error {
+				if err := quota.CheckLinkQuota(tx, userID, false); err != nil {
+					return err
+				}
+				return tx.Create(&models.ShortLink{UserID: &userID, OriginalURL: "https://example.com", ShortCode: uuid.NewString(), IsActive: true}).Error
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range results {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, ErrQuotaExceeded):
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("created %d links concurrently at the limit, want 1", created)
+	}
+}
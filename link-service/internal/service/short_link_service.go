@@ -1,32 +1,137 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"link-service/internal/events"
+	"link-service/internal/metadata"
 	"link-service/internal/models"
 	"link-service/internal/repository"
+	"link-service/internal/urlpolicy"
+	"net/url"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/teris-io/shortid"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type ShortLinkService struct {
-	repo *repository.ShortLinkRepository
-	Log  *zap.Logger
+	repo       *repository.ShortLinkRepository
+	domainRepo *repository.DomainRepository
+	policy     *urlpolicy.Policy
+	fetcher    *metadata.Fetcher
+	tx         *repository.Transactor
+	outbox     *events.Outbox
+	quota      *QuotaService
+	Log        *zap.Logger
 }
 
-func NewShortLinkService(repo *repository.ShortLinkRepository, log *zap.Logger) *ShortLinkService {
+func NewShortLinkService(
+	repo *repository.ShortLinkRepository,
+	domainRepo *repository.DomainRepository,
+	policy *urlpolicy.Policy,
+	fetcher *metadata.Fetcher,
+	tx *repository.Transactor,
+	outbox *events.Outbox,
+	quota *QuotaService,
+	log *zap.Logger,
+) *ShortLinkService {
 	return &ShortLinkService{
-		repo: repo,
-		Log:  log,
+		repo:       repo,
+		domainRepo: domainRepo,
+		policy:     policy,
+		fetcher:    fetcher,
+		tx:         tx,
+		outbox:     outbox,
+		quota:      quota,
+		Log:        log,
 	}
 }
 
 var ErrGenerateShortCode = errors.New("error generating short code")
 var ErrCreateShortLink = errors.New("error creating short link")
+var ErrURLNotAllowed = errors.New("destination url not allowed")
+var ErrInvalidAlias = errors.New("invalid custom alias")
+var ErrAliasTaken = errors.New("custom alias already taken")
+
+// Сколько раз генерировать код заново, если сгенерированный уже занят
+const maxShortCodeAttempts = 5
+
+// CreateLinkOptions — необязательные параметры создания ссылки
+type CreateLinkOptions struct {
+	UTM          UTMParams
+	ForwardQuery bool
+	Title        string
+	Notes        string
+	// Подтверждённый брендированный домен пользователя
+	DomainID *uuid.UUID
+	// Собственный короткий код вместо сгенерированного (только для авторизованных)
+	Alias string
+}
+
+var ErrInvalidDetails = errors.New("invalid title or notes")
+
+const (
+	maxTitleLength = 256
+	maxNotesLength = 4096
+)
+
+var aliasPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,32}$`)
+
+// Коды, которые не должны становиться псевдонимами
+var reservedAliases = map[string]bool{
+	"api": true, "admin": true, "blocked": true, "health": true, "login": true, "static": true,
+}
+
+func validAlias(alias string) bool {
+	return aliasPattern.MatchString(alias) && !reservedAliases[strings.ToLower(alias)]
+}
+
+func (s *ShortLinkService) CreateShortLink(originalURL string, userID *uuid.UUID, expireAfter *time.Duration, opts CreateLinkOptions) (*models.ShortLink, error) {
+	utm, err := opts.UTM.normalize()
+	if err != nil {
+		return nil, err
+	}
+	title := strings.TrimSpace(opts.Title)
+	notes := strings.TrimSpace(opts.Notes)
+	if len(title) > maxTitleLength || len(notes) > maxNotesLength {
+		return nil, ErrInvalidDetails
+	}
+	alias := strings.TrimSpace(opts.Alias)
+	if alias != "" && (userID == nil || !validAlias(alias)) {
+		return nil, ErrInvalidAlias
+	}
+
+	if s.policy != nil {
+		if err := s.policy.Check(context.Background(), originalURL); err != nil {
+			s.Log.Warn("Destination url rejected by policy", zap.String("url", originalURL), zap.Error(err))
+			return nil, fmt.Errorf("%w: %v", ErrURLNotAllowed, err)
+		}
+	}
+	// Ссылка на любой из брендированных доменов тоже была бы петлёй редиректов
+	if u, err := url.Parse(originalURL); err == nil && s.domainRepo != nil {
+		if _, err := s.domainRepo.GetVerifiedByHost(NormalizeHost(u.Host)); err == nil {
+			return nil, fmt.Errorf("%w: %v", ErrURLNotAllowed, urlpolicy.ErrRedirectLoop)
+		}
+	}
+
+	var domain *models.Domain
+	if opts.DomainID != nil {
+		if userID == nil {
+			return nil, ErrDomainNotVerified
+		}
+		d, err := s.domainRepo.GetByID(*opts.DomainID, *userID)
+		if err != nil || !d.Verified {
+			return nil, ErrDomainNotVerified
+		}
+		domain = d
+	}
 
-func (s *ShortLinkService) CreateShortLink(originalURL string, userID *uuid.UUID, expireAfter *time.Duration) (*models.ShortLink, error) {
 	var finalExpireAt *time.Time
 	if expireAfter != nil {
 		exp := time.Now().Add(*expireAfter)
@@ -38,10 +143,13 @@ func (s *ShortLinkService) CreateShortLink(originalURL string, userID *uuid.UUID
 		finalExpireAt = nil
 	}
 
-	shortCode, err := generateShortCode()
-	if err != nil {
-		s.Log.Error("Failed to generate short code", zap.Error(err))
-		return nil, ErrGenerateShortCode
+	shortCode := alias
+	if shortCode == "" {
+		shortCode, err = generateShortCode()
+		if err != nil {
+			s.Log.Error("Failed to generate short code", zap.Error(err))
+			return nil, ErrGenerateShortCode
+		}
 	}
 
 	shortLink := &models.ShortLink{
@@ -50,17 +158,68 @@ func (s *ShortLinkService) CreateShortLink(originalURL string, userID *uuid.UUID
 		ShortCode:   shortCode,
 		IsActive:    true,
 		ExpireAt:    finalExpireAt,
+
+		UTMSource:    utm.Source,
+		UTMMedium:    utm.Medium,
+		UTMCampaign:  utm.Campaign,
+		UTMTerm:      utm.Term,
+		UTMContent:   utm.Content,
+		ForwardQuery: opts.ForwardQuery,
+
+		Title: title,
+		Notes: notes,
+
+		IsCustomAlias: alias != "",
+	}
+	if domain != nil {
+		shortLink.DomainID = &domain.ID
 	}
 
-	if err := s.repo.Create(shortLink); err != nil {
+	// Занятость кода определяет уникальный индекс: после нарушения транзакция в PostgreSQL
+	// прервана, поэтому сгенерированный код подбирается заново в новой транзакции
+	for attempt := 1; ; attempt++ {
+		err = s.tx.Do(func(tx *gorm.DB) error {
+			if s.quota != nil && userID != nil {
+				if err := s.quota.CheckLinkQuota(tx, *userID, shortLink.IsCustomAlias); err != nil {
+					return err
+				}
+			}
+			if err := s.repo.WithTx(tx).Create(shortLink); err != nil {
+				return err
+			}
+			return enqueueLinkEvent(s.outbox, tx, events.TypeLinkCreated, shortLink, map[string]any{"title": shortLink.Title})
+		})
+		if !errors.Is(err, gorm.ErrDuplicatedKey) {
+			break
+		}
+		if shortLink.IsCustomAlias {
+			err = ErrAliasTaken
+			break
+		}
+		if attempt == maxShortCodeAttempts {
+			break
+		}
+		s.Log.Warn("Generated short code already taken", zap.String("short_code", shortLink.ShortCode))
+		if shortLink.ShortCode, err = generateShortCode(); err != nil {
+			s.Log.Error("Failed to generate short code", zap.Error(err))
+			return nil, ErrGenerateShortCode
+		}
+	}
+	if errors.Is(err, ErrQuotaExceeded) || errors.Is(err, ErrAliasTaken) {
+		s.Log.Info("Short link rejected", zap.Error(err))
+		return nil, err
+	}
+	if err != nil {
 		s.Log.Error("Failed to create short link", zap.Error(err))
 		return nil, ErrCreateShortLink
 	}
+	shortLink.Domain = domain
 
 	return shortLink, nil
 }
 
-func generateShortCode() (string, error) {
+// generateShortCode — переменная, чтобы тесты могли подставить повторяющиеся коды
+var generateShortCode = func() (string, error) {
 	id, err := shortid.Generate()
 	if err != nil {
 		return "", err
@@ -78,6 +237,22 @@ func (s *ShortLinkService) GetLinkByCode(shortCode string) (*models.ShortLink, e
 	return &shortLink, nil
 }
 
+// GetLinkByHostAndCode ищет ссылку по хосту запроса: брендированный домен или основной
+func (s *ShortLinkService) GetLinkByHostAndCode(host, shortCode string) (*models.ShortLink, error) {
+	host = NormalizeHost(host)
+	if host != "" && s.domainRepo != nil {
+		if domain, err := s.domainRepo.GetVerifiedByHost(host); err == nil {
+			var shortLink models.ShortLink
+			if err := s.repo.GetByDomainAndCode(&shortLink, domain.ID, shortCode); err != nil {
+				s.Log.Warn("Short link not found or inactive/expired", zap.String("host", host), zap.String("shortCode", shortCode), zap.Error(err))
+				return nil, err
+			}
+			return &shortLink, nil
+		}
+	}
+	return s.GetLinkByCode(shortCode)
+}
+
 func (s *ShortLinkService) GetLinksUser(userID uuid.UUID) ([]*models.ShortLink, error) {
 	shortLinks, err := s.repo.GetByUserID(userID)
 	if err != nil {
@@ -87,8 +262,28 @@ func (s *ShortLinkService) GetLinksUser(userID uuid.UUID) ([]*models.ShortLink,
 	return shortLinks, nil
 }
 
+// GetLinksUserFiltered — список ссылок пользователя с фильтром по тегу / папке
+func (s *ShortLinkService) GetLinksUserFiltered(userID uuid.UUID, filter repository.LinkFilter) ([]*models.ShortLink, error) {
+	shortLinks, err := s.repo.GetByUserIDFiltered(userID, filter)
+	if err != nil {
+		s.Log.Warn("Failed to get filtered short links for user", zap.String("userID", userID.String()), zap.Error(err))
+		return nil, err
+	}
+	return shortLinks, nil
+}
+
 func (s *ShortLinkService) DeactivateShortLink(id, userID uuid.UUID) error {
-	err := s.repo.DeactivateByID(id, userID)
+	link, _ := s.repo.GetShortLinkByID(id.String(), userID)
+	err := s.tx.Do(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).DeactivateByID(id, userID); err != nil {
+			return err
+		}
+		if link == nil {
+			return nil
+		}
+		link.IsActive = false
+		return enqueueLinkEvent(s.outbox, tx, events.TypeLinkDeactivated, link, map[string]any{"reason": "owner"})
+	})
 	if err != nil {
 		s.Log.Warn("Failed to deactivate short link", zap.String("id", id.String()), zap.Error(err))
 		return err
@@ -96,6 +291,104 @@ func (s *ShortLinkService) DeactivateShortLink(id, userID uuid.UUID) error {
 	return nil
 }
 
+// DeactivateExpiredLinks деактивирует истёкшие ссылки и записывает события link.expired
+func (s *ShortLinkService) DeactivateExpiredLinks() (int, error) {
+	count := 0
+	err := s.tx.Do(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		anonLinks, err := repo.DeactivateExpiredAnonLinks()
+		if err != nil {
+			return err
+		}
+		userLinks, err := repo.DeactivateExpiredUserLinks()
+		if err != nil {
+			return err
+		}
+		for _, link := range append(anonLinks, userLinks...) {
+			if err := enqueueLinkEvent(s.outbox, tx, events.TypeLinkExpired, link, nil); err != nil {
+				return err
+			}
+		}
+		count = len(anonLinks) + len(userLinks)
+		return nil
+	})
+	return count, err
+}
+
 func (s *ShortLinkService) GetShortLinkByID(id string, userID uuid.UUID) (*models.ShortLink, error) {
 	return s.repo.GetShortLinkByID(id, userID)
 }
+
+// ResolveDestination возвращает URL для редиректа с учётом UTM-меток и проброса query.
+// Некорректная query-строка посетителя отбрасывается: редирект не должен ломаться из-за неё.
+func (s *ShortLinkService) ResolveDestination(link *models.ShortLink, incomingQuery string) string {
+	dest, err := BuildDestinationURL(link, incomingQuery)
+	if err == nil {
+		return dest
+	}
+	s.Log.Warn("Failed to build destination url", zap.String("shortCode", link.ShortCode), zap.Error(err))
+	if incomingQuery != "" {
+		if dest, err := BuildDestinationURL(link, ""); err == nil {
+			return dest
+		}
+	}
+	return link.OriginalURL
+}
+
+// UpdateLinkDetails меняет пользовательские заголовок и заметки; nil — поле не меняется
+func (s *ShortLinkService) UpdateLinkDetails(id, userID uuid.UUID, title, notes *string) (*models.ShortLink, error) {
+	fields := map[string]interface{}{}
+	if title != nil {
+		t := strings.TrimSpace(*title)
+		if len(t) > maxTitleLength {
+			return nil, ErrInvalidDetails
+		}
+		fields["title"] = t
+	}
+	if notes != nil {
+		n := strings.TrimSpace(*notes)
+		if len(n) > maxNotesLength {
+			return nil, ErrInvalidDetails
+		}
+		fields["notes"] = n
+	}
+
+	if _, err := s.repo.GetShortLinkByID(id.String(), userID); err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return s.repo.GetShortLinkByID(id.String(), userID)
+	}
+	var link *models.ShortLink
+	err := s.tx.Do(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.UpdateDetails(id, userID, fields); err != nil {
+			return err
+		}
+		updated, err := repo.GetShortLinkByID(id.String(), userID)
+		if err != nil {
+			return err
+		}
+		link = updated
+		return enqueueLinkEvent(s.outbox, tx, events.TypeLinkUpdated, link, map[string]any{"changed": fields})
+	})
+	if err != nil {
+		s.Log.Warn("Failed to update short link details", zap.String("id", id.String()), zap.Error(err))
+		return nil, err
+	}
+	return link, nil
+}
+
+// RefreshMetadata загружает метаданные целевой страницы и сохраняет их в ссылке
+func (s *ShortLinkService) RefreshMetadata(ctx context.Context, link *models.ShortLink) error {
+	if s.fetcher == nil {
+		return nil
+	}
+	meta, err := s.fetcher.Fetch(ctx, link.OriginalURL)
+	if err != nil {
+		s.Log.Debug("Failed to fetch destination metadata", zap.String("shortCode", link.ShortCode), zap.Error(err))
+		// Отмечаем попытку, чтобы не повторять её при каждом запуске планировщика
+		return s.repo.UpdateMetadata(link.ID, link.MetaTitle, link.MetaDescription, link.MetaImage, link.MetaFavicon)
+	}
+	return s.repo.UpdateMetadata(link.ID, meta.Title, meta.Description, meta.Image, meta.Favicon)
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"errors"
+	"link-service/internal/repository"
+	"link-service/internal/storage/testdb"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func newTestShortLinkService(db *gorm.DB) *ShortLinkService {
+	return NewShortLinkService(repository.NewShortLinkRepository(db), nil, nil, nil, repository.NewTransactor(db), nil, nil, zap.NewNop())
+}
+
+func TestCreateShortLinkAliasTaken(t *testing.T) {
+	svc := newTestShortLinkService(testdb.Open(t))
+	owner, other := uuid.New(), uuid.New()
+
+	if _, err := svc.CreateShortLink("https://example.com", &owner, nil, CreateLinkOptions{Alias: "promo"}); err != nil {
+		t.Fatalf("CreateShortLink: %v", err)
+	}
+	_, err := svc.CreateShortLink("https://example.org", &other, nil, CreateLinkOptions{Alias: "promo"})
+	if !errors.Is(err, ErrAliasTaken) {
+		t.Errorf("CreateShortLink with taken alias = %v, want ErrAliasTaken", err)
+	}
+}
+
+// Параллельные запросы одного псевдонима: создаёт ссылку только один, остальные получают ErrAliasTaken
+func TestCreateShortLinkAliasConcurrent(t *testing.T) {
+	svc := newTestShortLinkService(testdb.Open(t))
+
+	const workers = 5
+	results := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			userID := uuid.New()
+			_, results[i] = svc.CreateShortLink("https://example.com", &userID, nil, CreateLinkOptions{Alias: "race"})
+		}(i)
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range results {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, ErrAliasTaken):
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("created %d links with one alias concurrently, want 1", created)
+	}
+}
+
+func TestCreateShortLinkRetriesGeneratedCode(t *testing.T) {
+	svc := newTestShortLinkService(testdb.Open(t))
+	generate := generateShortCode
+	t.Cleanup(func() { generateShortCode = generate })
+
+	// Генератор сначала повторяет занятый код, затем выдаёт новые
+	var codes []string
+	stub := func(seq ...string) {
+		codes = seq
+		generateShortCode = func() (string, error) {
+			code := codes[0]
+			if len(codes) > 1 {
+				codes = codes[1:]
+			}
+			return code, nil
+		}
+	}
+
+	stub("taken")
+	if _, err := svc.CreateShortLink("https://example.com", nil, nil, CreateLinkOptions{}); err != nil {
+		t.Fatalf("CreateShortLink: %v", err)
+	}
+	stub("taken", "taken", "fresh")
+	link, err := svc.CreateShortLink("https://example.com", nil, nil, CreateLinkOptions{})
+	if err != nil {
+		t.Fatalf("CreateShortLink with colliding code: %v", err)
+	}
+	if link.ShortCode != "fresh" {
+		t.Errorf("short code = %q, want %q", link.ShortCode, "fresh")
+	}
+
+	stub("taken")
+	if _, err := svc.CreateShortLink("https://example.com", nil, nil, CreateLinkOptions{}); !errors.Is(err, ErrCreateShortLink) {
+		t.Errorf("CreateShortLink when every code is taken = %v, want ErrCreateShortLink", err)
+	}
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"errors"
+	"link-service/internal/models"
+	"link-service/internal/repository"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidName = errors.New("invalid name")
+var ErrTooManyTags = errors.New("too many tags")
+
+const (
+	maxNameLength  = 64
+	maxTagsPerLink = 20
+)
+
+// TagService управляет тегами и папками пользователя
+type TagService struct {
+	tagRepo    *repository.TagRepository
+	folderRepo *repository.FolderRepository
+	linkRepo   *repository.ShortLinkRepository
+	log        *zap.Logger
+}
+
+func NewTagService(tagRepo *repository.TagRepository, folderRepo *repository.FolderRepository, linkRepo *repository.ShortLinkRepository, log *zap.Logger) *TagService {
+	return &TagService{
+		tagRepo:    tagRepo,
+		folderRepo: folderRepo,
+		linkRepo:   linkRepo,
+		log:        log,
+	}
+}
+
+func normalizeName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxNameLength {
+		return "", ErrInvalidName
+	}
+	return name, nil
+}
+
+func (s *TagService) CreateTag(userID uuid.UUID, name string) (*models.Tag, error) {
+	name, err := normalizeName(strings.ToLower(name))
+	if err != nil {
+		return nil, err
+	}
+	tag := &models.Tag{UserID: userID, Name: name}
+	if err := s.tagRepo.Create(tag); err != nil {
+		s.log.Warn("Failed to create tag", zap.String("name", name), zap.Error(err))
+		return nil, err
+	}
+	return tag, nil
+}
+
+func (s *TagService) ListTags(userID uuid.UUID) ([]models.Tag, error) {
+	return s.tagRepo.GetByUserID(userID)
+}
+
+// GetTag проверяет принадлежность тега пользователю (например, перед запросом статистики)
+func (s *TagService) GetTag(userID, tagID uuid.UUID) (*models.Tag, error) {
+	return s.tagRepo.GetByID(tagID, userID)
+}
+
+func (s *TagService) RenameTag(userID, tagID uuid.UUID, name string) error {
+	name, err := normalizeName(strings.ToLower(name))
+	if err != nil {
+		return err
+	}
+	if _, err := s.tagRepo.GetByID(tagID, userID); err != nil {
+		return err
+	}
+	return s.tagRepo.Rename(tagID, userID, name)
+}
+
+func (s *TagService) DeleteTag(userID, tagID uuid.UUID) error {
+	tag, err := s.tagRepo.GetByID(tagID, userID)
+	if err != nil {
+		return err
+	}
+	return s.tagRepo.Delete(tag)
+}
+
+// SetLinkTags заменяет теги ссылки; отсутствующие теги создаются
+func (s *TagService) SetLinkTags(userID, linkID uuid.UUID, names []string) ([]models.Tag, error) {
+	link, err := s.linkRepo.GetShortLinkByID(linkID.String(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(names))
+	normalized := make([]string, 0, len(names))
+	for _, n := range names {
+		name, err := normalizeName(strings.ToLower(n))
+		if err != nil {
+			return nil, err
+		}
+		if !seen[name] {
+			seen[name] = true
+			normalized = append(normalized, name)
+		}
+	}
+	if len(normalized) > maxTagsPerLink {
+		return nil, ErrTooManyTags
+	}
+
+	tags, err := s.tagRepo.FindOrCreateByNames(userID, normalized)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.linkRepo.ReplaceTags(link, tags); err != nil {
+		s.log.Warn("Failed to set link tags", zap.String("id", linkID.String()), zap.Error(err))
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (s *TagService) CreateFolder(userID uuid.UUID, name string) (*models.Folder, error) {
+	name, err := normalizeName(name)
+	if err != nil {
+		return nil, err
+	}
+	folder := &models.Folder{UserID: userID, Name: name}
+	if err := s.folderRepo.Create(folder); err != nil {
+		s.log.Warn("Failed to create folder", zap.String("name", name), zap.Error(err))
+		return nil, err
+	}
+	return folder, nil
+}
+
+func (s *TagService) ListFolders(userID uuid.UUID) ([]models.Folder, error) {
+	return s.folderRepo.GetByUserID(userID)
+}
+
+func (s *TagService) RenameFolder(userID, folderID uuid.UUID, name string) error {
+	name, err := normalizeName(name)
+	if err != nil {
+		return err
+	}
+	if _, err := s.folderRepo.GetByID(folderID, userID); err != nil {
+		return err
+	}
+	return s.folderRepo.Rename(folderID, userID, name)
+}
+
+func (s *TagService) DeleteFolder(userID, folderID uuid.UUID) error {
+	folder, err := s.folderRepo.GetByID(folderID, userID)
+	if err != nil {
+		return err
+	}
+	return s.folderRepo.Delete(folder)
+}
+
+// MoveLinkToFolder перемещает ссылку в папку; folderID == nil — убрать из папки
+func (s *TagService) MoveLinkToFolder(userID, linkID uuid.UUID, folderID *uuid.UUID) error {
+	if _, err := s.linkRepo.GetShortLinkByID(linkID.String(), userID); err != nil {
+		return err
+	}
+	if folderID != nil {
+		if _, err := s.folderRepo.GetByID(*folderID, userID); err != nil {
+			return err
+		}
+	}
+	return s.linkRepo.SetFolder(linkID, userID, folderID)
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"link-service/internal/events"
+	"link-service/internal/models"
+	"link-service/internal/repository"
+	"link-service/internal/urlpolicy"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidWebhookURL = errors.New("invalid webhook url")
+var ErrInvalidEventTypes = errors.New("invalid webhook event types")
+var ErrTooManyWebhooks = errors.New("too many webhook endpoints")
+var ErrDeliveryNotFailed = errors.New("only failed deliveries can be replayed")
+
+const maxWebhooksPerUser = 10
+
+// События, на которые можно подписать вебхук
+var webhookEventTypes = map[string]bool{
+	events.TypeLinkCreated:     true,
+	events.TypeLinkUpdated:     true,
+	events.TypeLinkDeactivated: true,
+	events.TypeLinkExpired:     true,
+	events.TypeClickRecorded:   true,
+	events.TypeLinkBroken:      true,
+	events.TypeLinkRecovered:   true,
+}
+
+// WebhookService управляет endpoint'ами вебхуков и журналом доставок
+type WebhookService struct {
+	repo   *repository.WebhookRepository
+	policy *urlpolicy.Policy
+	log    *zap.Logger
+}
+
+func NewWebhookService(repo *repository.WebhookRepository, policy *urlpolicy.Policy, log *zap.Logger) *WebhookService {
+	return &WebhookService{repo: repo, policy: policy, log: log}
+}
+
+func normalizeEventTypes(types []string) (string, error) {
+	seen := make(map[string]bool, len(types))
+	for _, t := range types {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if !webhookEventTypes[t] {
+			return "", fmt.Errorf("%w: %q", ErrInvalidEventTypes, t)
+		}
+		seen[t] = true
+	}
+	if len(seen) == 0 {
+		return "", ErrInvalidEventTypes
+	}
+	list := make([]string, 0, len(seen))
+	for t := range seen {
+		list = append(list, t)
+	}
+	sort.Strings(list)
+	return strings.Join(list, ","), nil
+}
+
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+func (s *WebhookService) checkURL(rawURL string) error {
+	if !strings.HasPrefix(rawURL, "https://") && !strings.HasPrefix(rawURL, "http://") {
+		return ErrInvalidWebhookURL
+	}
+	if s.policy != nil {
+		if err := s.policy.Check(context.Background(), rawURL); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidWebhookURL, err)
+		}
+	}
+	return nil
+}
+
+// CreateEndpoint регистрирует endpoint; секрет подписи возвращается в модели
+func (s *WebhookService) CreateEndpoint(userID uuid.UUID, rawURL string, eventTypes []string) (*models.WebhookEndpoint, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if err := s.checkURL(rawURL); err != nil {
+		return nil, err
+	}
+	types, err := normalizeEventTypes(eventTypes)
+	if err != nil {
+		return nil, err
+	}
+	count, err := s.repo.CountEndpoints(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxWebhooksPerUser {
+		return nil, ErrTooManyWebhooks
+	}
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := &models.WebhookEndpoint{
+		UserID:     userID,
+		URL:        rawURL,
+		Secret:     secret,
+		EventTypes: types,
+		Active:     true,
+	}
+	if err := s.repo.CreateEndpoint(endpoint); err != nil {
+		s.log.Error("Failed to create webhook endpoint", zap.Error(err))
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+func (s *WebhookService) ListEndpoints(userID uuid.UUID) ([]models.WebhookEndpoint, error) {
+	return s.repo.ListEndpoints(userID)
+}
+
+// UpdateEndpoint меняет URL, подписки и активность; nil — поле не меняется
+func (s *WebhookService) UpdateEndpoint(id, userID uuid.UUID, rawURL *string, eventTypes []string, active *bool) (*models.WebhookEndpoint, error) {
+	if _, err := s.repo.GetEndpoint(id, userID); err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if rawURL != nil {
+		u := strings.TrimSpace(*rawURL)
+		if err := s.checkURL(u); err != nil {
+			return nil, err
+		}
+		fields["url"] = u
+	}
+	if eventTypes != nil {
+		types, err := normalizeEventTypes(eventTypes)
+		if err != nil {
+			return nil, err
+		}
+		fields["event_types"] = types
+	}
+	if active != nil {
+		fields["active"] = *active
+	}
+	if len(fields) > 0 {
+		if err := s.repo.UpdateEndpoint(id, userID, fields); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.GetEndpoint(id, userID)
+}
+
+// RotateSecret выдаёт новый секрет подписи; старый перестаёт действовать сразу
+func (s *WebhookService) RotateSecret(id, userID uuid.UUID) (string, error) {
+	if _, err := s.repo.GetEndpoint(id, userID); err != nil {
+		return "", err
+	}
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return "", err
+	}
+	if err := s.repo.UpdateEndpoint(id, userID, map[string]interface{}{"secret": secret}); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+func (s *WebhookService) DeleteEndpoint(id, userID uuid.UUID) error {
+	endpoint, err := s.repo.GetEndpoint(id, userID)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteEndpoint(endpoint)
+}
+
+// ListDeliveries — журнал доставок endpoint'а, новые первыми
+func (s *WebhookService) ListDeliveries(endpointID, userID uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := s.repo.GetEndpoint(endpointID, userID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	return s.repo.ListDeliveries(endpointID, limit)
+}
+
+// ReplayDelivery ставит неуспешную доставку в очередь заново отдельной записью журнала
+func (s *WebhookService) ReplayDelivery(deliveryID, userID uuid.UUID) (*models.WebhookDelivery, error) {
+	original, err := s.repo.GetDelivery(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	// Проверка владельца через endpoint: чужая доставка неотличима от несуществующей
+	if _, err := s.repo.GetEndpoint(original.EndpointID, userID); err != nil {
+		return nil, err
+	}
+	if original.Status != models.WebhookDeliveryFailed {
+		return nil, ErrDeliveryNotFailed
+	}
+
+	replay := &models.WebhookDelivery{
+		EndpointID:    original.EndpointID,
+		EventID:       original.EventID,
+		EventType:     original.EventType,
+		Payload:       original.Payload,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: time.Now(),
+		ReplayOf:      &original.ID,
+	}
+	if err := s.repo.CreateDelivery(replay); err != nil {
+		s.log.Error("Failed to replay webhook delivery", zap.String("id", deliveryID.String()), zap.Error(err))
+		return nil, err
+	}
+	return replay, nil
+}
@@ -9,10 +9,34 @@ import (
 
 func Migrate(db *gorm.DB, log *zap.Logger) {
 	if err := db.AutoMigrate(
+		&models.Domain{},
+		&models.Folder{},
+		&models.Tag{},
 		&models.ShortLink{},
 		&models.Click{},
+		&models.LinkReport{},
+		&models.AuditEntry{},
+		&models.LinkHealthCheck{},
+		&models.OutboxMessage{},
+		&models.WebhookEndpoint{},
+		&models.WebhookDelivery{},
+		&models.UserPlan{},
+		&models.APIUsage{},
+		&models.RateLimitBucket{},
 	); err != nil {
 		log.Fatal("Не удалось выполнить миграцию базы данных", zap.Error(err))
 	}
+	// Короткий код теперь уникален в пределах домена — снимаем прежнее глобальное ограничение
+	if db.Migrator().HasConstraint(&models.ShortLink{}, "uni_short_links_short_code") {
+		if err := db.Migrator().DropConstraint(&models.ShortLink{}, "uni_short_links_short_code"); err != nil {
+			log.Fatal("Не удалось удалить ограничение уникальности short_code", zap.Error(err))
+		}
+	}
+	// Хост уникален только среди подтверждённых доменов — снимаем прежний глобальный индекс
+	if db.Migrator().HasIndex(&models.Domain{}, "idx_domains_host") {
+		if err := db.Migrator().DropIndex(&models.Domain{}, "idx_domains_host"); err != nil {
+			log.Fatal("Не удалось удалить индекс уникальности host", zap.Error(err))
+		}
+	}
 	log.Info("Миграция базы данных успешно выполнена")
 }
@@ -13,7 +13,7 @@ func ConnectDB(cfg *config.DBConfig, log *zap.Logger) *gorm.DB {
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{PrepareStmt: false})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{PrepareStmt: false, TranslateError: true})
 	if err != nil {
 		log.Fatal("Не удалось подключиться к базе данных", zap.Error(err))
 		return nil
@@ -0,0 +1,67 @@
+// Package testdb даёт тестам отдельную схему PostgreSQL с актуальными миграциями.
+// Адрес базы задаёт TEST_DATABASE_URL; без него тесты с БД пропускаются.
+package testdb
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"link-service/internal/storage"
+	"os"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Open создаёт новую схему, мигрирует её и удаляет после теста
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL не задан: тест с PostgreSQL пропущен")
+	}
+	cfg := &gorm.Config{Logger: logger.Discard, TranslateError: true}
+	admin, err := gorm.Open(postgres.Open(dsn), cfg)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		t.Fatal(err)
+	}
+	schema := "test_" + hex.EncodeToString(suffix)
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	db, err := gorm.Open(postgres.Open(withSearchPath(dsn, schema)), cfg)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+		_ = admin.Exec("DROP SCHEMA " + schema + " CASCADE").Error
+		if sqlDB, err := admin.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	// Ошибка миграции должна завершить тест, а не весь процесс
+	storage.Migrate(db, zap.NewNop().WithOptions(zap.OnFatal(zapcore.WriteThenGoexit)))
+	return db
+}
+
+// withSearchPath добавляет к DSN (URL или key=value) параметр подключения search_path
+func withSearchPath(dsn, schema string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		if strings.Contains(dsn, "?") {
+			return dsn + "&search_path=" + schema
+		}
+		return dsn + "?search_path=" + schema
+	}
+	return dsn + " search_path=" + schema
+}
@@ -2,18 +2,30 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"link-service/config"
+	"link-service/internal/authclient"
+	"link-service/internal/clientip"
+	"link-service/internal/ratelimit"
+	"link-service/internal/service"
+	"math"
+	"strconv"
 	"strings"
+	"time"
 
-	authv1 "github.com/Anabol1ks/linkvault-proto/auth/v1"
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
-func AuthInterceptor(authClient authv1.AuthServiceClient) grpc.UnaryServerInterceptor {
+func AuthInterceptor(validator *authclient.Validator) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
 		req interface{},
@@ -33,23 +45,21 @@ func AuthInterceptor(authClient authv1.AuthServiceClient) grpc.UnaryServerInterc
 		}
 		tokenStr := strings.TrimPrefix(authHeaders[0], "Bearer ")
 
-		resp, err := authClient.ValidateAccessToken(ctx, &authv1.ValidateAccessTokenRequest{
-			AccessToken: tokenStr,
-		})
-		if err != nil || !resp.Valid {
-			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		userID, err := validator.Validate(ctx, tokenStr)
+		if errors.Is(err, authclient.ErrUnavailable) {
+			return nil, status.Error(codes.Unavailable, "auth service unavailable")
 		}
-
-		userID, err := uuid.Parse(resp.UserId)
 		if err != nil {
-			return nil, status.Error(codes.Unauthenticated, "invalid user_id in token")
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
 		}
 		ctx = context.WithValue(ctx, "user_id", userID)
 		return handler(ctx, req)
 	}
 }
 
-func OptionalAuthInterceptor(authClient authv1.AuthServiceClient) grpc.UnaryServerInterceptor {
+// OptionalAuthInterceptor при недоступности Auth Service либо продолжает вызов анонимно (failOpen),
+// либо возвращает Unavailable
+func OptionalAuthInterceptor(validator *authclient.Validator, failOpen bool) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
 		req interface{},
@@ -63,22 +73,118 @@ func OptionalAuthInterceptor(authClient authv1.AuthServiceClient) grpc.UnaryServ
 		authHeaders := md.Get("authorization")
 		if len(authHeaders) > 0 && strings.HasPrefix(authHeaders[0], "Bearer ") {
 			tokenStr := strings.TrimPrefix(authHeaders[0], "Bearer ")
-			resp, err := authClient.ValidateAccessToken(ctx, &authv1.ValidateAccessTokenRequest{
-				AccessToken: tokenStr,
-			})
-			if err == nil && resp.Valid {
-				userID, err := uuid.Parse(resp.UserId)
-				if err != nil {
-					return nil, status.Error(codes.Unauthenticated, "invalid user_id in token")
-				}
-
+			userID, err := validator.Validate(ctx, tokenStr)
+			switch {
+			case err == nil:
 				ctx = context.WithValue(ctx, "user_id", userID)
+			case errors.Is(err, authclient.ErrUnavailable) && !failOpen:
+				return nil, status.Error(codes.Unavailable, "auth service unavailable")
 			}
 		}
 		return handler(ctx, req)
 	}
 }
 
+// QuotaInterceptor учитывает вызовы API авторизованных пользователей и ограничивает их дневным лимитом плана.
+// Должен стоять в цепочке после интерсепторов авторизации.
+func QuotaInterceptor(quota *service.QuotaService, log *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		userID, ok := ctx.Value("user_id").(uuid.UUID)
+		if !ok {
+			return handler(ctx, req)
+		}
+		if err := quota.ConsumeAPICall(userID); err != nil {
+			if errors.Is(err, service.ErrQuotaExceeded) {
+				return nil, status.Error(codes.ResourceExhausted, err.Error())
+			}
+			// Сбой учёта не должен блокировать API
+			log.Warn("Failed to count api call", zap.String("userID", userID.String()), zap.Error(err))
+		}
+		return handler(ctx, req)
+	}
+}
+
+// RateLimitInterceptor ограничивает частоту вызовов по политикам методов: анонимные — по IP клиента,
+// авторизованные — по пользователю. IP берётся из соединения, x-forwarded-for учитывается только
+// от доверенных шлюзов clients. Должен стоять в цепочке после интерсепторов авторизации.
+func RateLimitInterceptor(store ratelimit.Store, policies map[string]ratelimit.Policy, clients *clientip.Resolver, log *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		policy, ok := policies[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+		var key string
+		var limit ratelimit.Limit
+		if userID, ok := ctx.Value("user_id").(uuid.UUID); ok {
+			key, limit = info.FullMethod+"|user:"+userID.String(), policy.User
+		} else {
+			key, limit = info.FullMethod+"|ip:"+clientIP(ctx, clients), policy.IP
+		}
+		if !limit.Enabled() {
+			return handler(ctx, req)
+		}
+
+		allowed, retryAfter, err := store.Take(ctx, key, limit)
+		if err != nil {
+			// Недоступность хранилища лимитов не должна останавливать сервис
+			log.Warn("Rate limit store failed", zap.String("method", info.FullMethod), zap.Error(err))
+			return handler(ctx, req)
+		}
+		if !allowed {
+			return nil, rateLimitedError(ctx, retryAfter)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitedError — ResourceExhausted с заголовком retry-after (секунды) и RetryInfo в деталях статуса
+func rateLimitedError(ctx context.Context, retryAfter time.Duration) error {
+	secs := int(math.Ceil(retryAfter.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(secs)))
+	st := status.New(codes.ResourceExhausted, "rate limit exceeded")
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(time.Duration(secs) * time.Second)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// clientIP — адрес соединения; x-forwarded-for учитывается, только если соединение пришло от доверенного шлюза
+func clientIP(ctx context.Context, clients *clientip.Resolver) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	return clients.Resolve(p.Addr.String(), strings.Join(md.Get("x-forwarded-for"), ","))
+}
+
+// RateLimitPolicies — политики ограничения частоты по методам
+func RateLimitPolicies(cfg config.RateLimitConfig) map[string]ratelimit.Policy {
+	return map[string]ratelimit.Policy{
+		"/link.v1.LinkService/CreateShortLink": {
+			IP:   ratelimit.PerMinute(cfg.CreateAnonPerMinute),
+			User: ratelimit.PerMinute(cfg.CreateUserPerMinute),
+		},
+		// Редиректы анонимны: лимит по IP посетителя
+		"/link.v1.LinkService/RedirectLink": {
+			IP: ratelimit.PerMinute(cfg.RedirectPerMinute),
+		},
+	}
+}
+
 var authRequiredMethods = map[string]bool{
 	"/link.v1.LinkService/ListShortLinks":  true,
 	"/link.v1.LinkService/DeleteShortLink": true,
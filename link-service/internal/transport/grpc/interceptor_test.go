@@ -0,0 +1,57 @@
+package grpc
+
+import (
+	"context"
+	"link-service/internal/clientip"
+	"link-service/internal/ratelimit"
+	"net"
+	"testing"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const redirectMethod = "/link.v1.LinkService/RedirectLink"
+
+// callFrom вызывает интерсептор так, будто запрос пришёл с адреса remote с metadata x-forwarded-for
+func callFrom(interceptor grpc.UnaryServerInterceptor, remote, forwarded string) error {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(remote), Port: 40000}})
+	if forwarded != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", forwarded))
+	}
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: redirectMethod}, func(context.Context, interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	return err
+}
+
+func TestRateLimitInterceptorClientIP(t *testing.T) {
+	policies := map[string]ratelimit.Policy{redirectMethod: {IP: ratelimit.PerMinute(1)}}
+	clients := clientip.NewResolver([]string{"10.0.0.0/8"}, zap.NewNop())
+
+	cases := []struct {
+		name string
+		// Два запроса подряд: адрес соединения и x-forwarded-for каждого
+		first, second [2]string
+		wantLimited   bool
+	}{
+		{"spoofed header from untrusted peer", [2]string{"203.0.113.7", "198.51.100.1"}, [2]string{"203.0.113.7", "198.51.100.2"}, true},
+		{"untrusted peer without header", [2]string{"203.0.113.7", ""}, [2]string{"203.0.113.7", "198.51.100.2"}, true},
+		{"different clients behind trusted proxy", [2]string{"10.1.2.3", "198.51.100.1"}, [2]string{"10.1.2.3", "198.51.100.2"}, false},
+		{"same client behind trusted proxy", [2]string{"10.1.2.3", "198.51.100.1"}, [2]string{"10.1.2.3", "192.0.2.66, 198.51.100.1"}, true},
+	}
+	for _, tc := range cases {
+		interceptor := RateLimitInterceptor(ratelimit.NewMemoryStore(), policies, clients, zap.NewNop())
+		if err := callFrom(interceptor, tc.first[0], tc.first[1]); err != nil {
+			t.Fatalf("%s: first call: %v", tc.name, err)
+		}
+		err := callFrom(interceptor, tc.second[0], tc.second[1])
+		if limited := status.Code(err) == codes.ResourceExhausted; limited != tc.wantLimited {
+			t.Errorf("%s: second call err = %v, want limited %v", tc.name, err, tc.wantLimited)
+		}
+	}
+}
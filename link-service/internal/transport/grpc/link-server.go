@@ -5,7 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"link-service/config"
+	"link-service/internal/repository"
 	"link-service/internal/service"
+	"net/url"
+	"strconv"
 	"sync"
 	"time"
 
@@ -15,6 +18,7 @@ import (
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
@@ -24,14 +28,16 @@ type LinkServer struct {
 	linkv1.UnimplementedLinkServiceServer
 	shortService *service.ShortLinkService
 	clickService *service.ClickService
+	quotaService *service.QuotaService
 	cfg          *config.Config
 	wg           *sync.WaitGroup
 }
 
-func NewLinkServer(shortService *service.ShortLinkService, clickService *service.ClickService, cfg *config.Config, wg *sync.WaitGroup) *LinkServer {
+func NewLinkServer(shortService *service.ShortLinkService, clickService *service.ClickService, quotaService *service.QuotaService, cfg *config.Config, wg *sync.WaitGroup) *LinkServer {
 	return &LinkServer{
 		shortService: shortService,
 		clickService: clickService,
+		quotaService: quotaService,
 		cfg:          cfg,
 		wg:           wg,
 	}
@@ -68,13 +74,43 @@ func (s *LinkServer) CreateShortLink(ctx context.Context, req *linkv1.CreateShor
 		userIDPtr = nil
 	}
 
-	shortLink, err := s.shortService.CreateShortLink(req.OriginalUrl, userIDPtr, expireAfter)
+	// Необязательные параметры создания передаются в метаданных до появления полей в запросе
+	md, _ := metadata.FromIncomingContext(ctx)
+	opts, err := createLinkOptions(md)
 	if err != nil {
 		s.shortService.Log.Warn("failed", zap.String("op", "CreateShortLink"), zap.Error(err))
-		return nil, status.Errorf(codes.Internal, "failed to create short link: %v", err)
+		return nil, status.Errorf(codes.InvalidArgument, "invalid metadata: %v", err)
 	}
 
-	shortURL := fmt.Sprintf("%s/%s", s.cfg.Domain, shortLink.ShortCode)
+	shortLink, err := s.shortService.CreateShortLink(req.OriginalUrl, userIDPtr, expireAfter, opts)
+	if err != nil {
+		s.shortService.Log.Warn("failed", zap.String("op", "CreateShortLink"), zap.Error(err))
+		switch {
+		case errors.Is(err, service.ErrURLNotAllowed), errors.Is(err, service.ErrInvalidUTM), errors.Is(err, service.ErrInvalidDetails), errors.Is(err, service.ErrInvalidAlias):
+			return nil, status.Errorf(codes.InvalidArgument, "invalid short link: %v", err)
+		case errors.Is(err, service.ErrAliasTaken):
+			return nil, status.Errorf(codes.AlreadyExists, "alias already taken: %v", err)
+		case errors.Is(err, service.ErrQuotaExceeded):
+			return nil, status.Errorf(codes.ResourceExhausted, "%v", err)
+		case errors.Is(err, service.ErrDomainNotVerified):
+			return nil, status.Errorf(codes.FailedPrecondition, "domain not verified: %v", err)
+		default:
+			return nil, status.Errorf(codes.Internal, "failed to create short link: %v", err)
+		}
+	}
+
+	// Метаданные целевой страницы загружаются в фоне
+	if s.wg != nil {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			defer cancel()
+			_ = s.shortService.RefreshMetadata(ctx, shortLink)
+		}()
+	}
+
+	shortURL := service.ShortURL(s.cfg.Domain, shortLink)
 
 	var userIdValue *wrapperspb.StringValue
 	if userIDPtr != nil && *userIDPtr != uuid.Nil {
@@ -110,13 +146,7 @@ func (s *LinkServer) RedirectLink(ctx context.Context, req *linkv1.RedirectLinkR
 		return nil, status.Errorf(codes.InvalidArgument, "validation failed: %v", err)
 	}
 
-	shortLink, err := s.shortService.GetLinkByCode(req.ShortCode)
-	if err != nil {
-		return nil, status.Error(codes.NotFound, "short link not found")
-	}
-	originalURL := shortLink.OriginalURL
-
-	var ip, userAgent string
+	var ip, userAgent, query, host string
 	if md, ok := metadata.FromIncomingContext(ctx); ok {
 		if vals := md.Get("x-forwarded-for"); len(vals) > 0 {
 			ip = vals[0]
@@ -124,12 +154,40 @@ func (s *LinkServer) RedirectLink(ctx context.Context, req *linkv1.RedirectLinkR
 		if vals := md.Get("user-agent"); len(vals) > 0 {
 			userAgent = vals[0]
 		}
+		// query-строка исходного запроса к короткой ссылке (передаётся фронтом/шлюзом)
+		if vals := md.Get("x-forwarded-query"); len(vals) > 0 {
+			query = vals[0]
+		}
+		// хост короткой ссылки — для брендированных доменов
+		if vals := md.Get("x-forwarded-host"); len(vals) > 0 {
+			host = vals[0]
+		}
+	}
+
+	shortLink, err := s.shortService.GetLinkByHostAndCode(host, req.ShortCode)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "short link not found")
+	}
+
+	// Заблокированная ссылка ведёт на страницу-предупреждение, клик не фиксируется
+	if shortLink.IsBlocked {
+		return &linkv1.RedirectLinkResponse{
+			OriginalUrl: fmt.Sprintf("%s?code=%s", s.cfg.BlockedPageURL, url.QueryEscape(shortLink.ShortCode)),
+		}, nil
 	}
+
+	query, fromQR := service.ExtractQRMarker(query)
+	source := service.ClickSourceDirect
+	if fromQR {
+		source = service.ClickSourceQR
+	}
+
+	originalURL := s.shortService.ResolveDestination(shortLink, query)
 	if shortLink.UserID != nil && s.wg != nil {
 		s.wg.Add(1)
 		go func() {
 			defer s.wg.Done()
-			_ = s.clickService.CreateClick(shortLink.ID, ip, userAgent)
+			_ = s.clickService.CreateClick(shortLink.ID, ip, userAgent, source)
 		}()
 	}
 
@@ -148,7 +206,15 @@ func (s *LinkServer) ListShortLinks(ctx context.Context, _ *emptypb.Empty) (*lin
 		return nil, status.Errorf(codes.Unauthenticated, "user not found: %v", "user_id not found in context")
 	}
 
-	shortLinks, err := s.shortService.GetLinksUser(userID)
+	// Фильтр по тегу / папке передаётся в метаданных до появления полей в запросе
+	md, _ := metadata.FromIncomingContext(ctx)
+	filter, err := linkFilter(md)
+	if err != nil {
+		s.shortService.Log.Warn("failed", zap.String("op", "ListShortLinks"), zap.Error(err))
+		return nil, status.Errorf(codes.InvalidArgument, "invalid metadata: %v", err)
+	}
+
+	shortLinks, err := s.shortService.GetLinksUserFiltered(userID, filter)
 	if err != nil {
 		s.shortService.Log.Warn("failed", zap.String("op", "ListShortLinks"), zap.Error(err))
 		return nil, status.Errorf(codes.Internal, "failed to list short links: %v", err)
@@ -162,7 +228,7 @@ func (s *LinkServer) ListShortLinks(ctx context.Context, _ *emptypb.Empty) (*lin
 		for _, link := range shortLinks {
 			resp.Links = append(resp.Links, &linkv1.ShortLinkResponse{
 				Id:          link.ID.String(),
-				ShortUrl:    fmt.Sprintf("%s/%s", s.cfg.Domain, link.ShortCode),
+				ShortUrl:    service.ShortURL(s.cfg.Domain, link),
 				OriginalUrl: link.OriginalURL,
 				ShortCode:   link.ShortCode,
 				UserId:      wrapperspb.String(link.UserID.String()),
@@ -225,9 +291,12 @@ func (s *LinkServer) GetShortLink(ctx context.Context, req *linkv1.GetShortLinkR
 		return nil, status.Errorf(codes.NotFound, "short link not found: %v", err)
 	}
 
+	// Состояние мониторинга возвращается в заголовке ответа до появления поля в ShortLinkResponse
+	_ = grpc.SetHeader(ctx, metadata.Pairs("x-link-is-broken", strconv.FormatBool(shortLink.IsBroken)))
+
 	return &linkv1.ShortLinkResponse{
 		Id:          shortLink.ID.String(),
-		ShortUrl:    fmt.Sprintf("%s/%s", s.cfg.Domain, shortLink.ShortCode),
+		ShortUrl:    service.ShortURL(s.cfg.Domain, shortLink),
 		OriginalUrl: shortLink.OriginalURL,
 		ShortCode:   shortLink.ShortCode,
 		UserId:      wrapperspb.String(shortLink.UserID.String()),
@@ -256,7 +325,13 @@ func (s *LinkServer) GetLinkStats(ctx context.Context, req *linkv1.GetLinkStatsR
 		return nil, status.Errorf(codes.NotFound, "short link not found: %v", err)
 	}
 
-	stats, err := s.clickService.GetStats(req.ShortLinkId)
+	since, err := s.analyticsSince(userID)
+	if err != nil {
+		s.shortService.Log.Warn("failed", zap.String("op", "GetLinkStats"), zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "failed to get plan limits: %v", err)
+	}
+
+	stats, err := s.clickService.GetStats(req.ShortLinkId, since)
 	if err != nil {
 		s.shortService.Log.Warn("failed", zap.String("op", "GetLinkStats"), zap.Error(err))
 		return nil, status.Errorf(codes.Internal, "failed to get link stats: %v", err)
@@ -296,7 +371,13 @@ func (s *LinkServer) GetLinkClicks(ctx context.Context, req *linkv1.GetLinkClick
 		return nil, status.Errorf(codes.NotFound, "short link not found: %v", err)
 	}
 
-	clicks, err := s.clickService.GetClicks(req.ShortLinkId)
+	since, err := s.analyticsSince(userID)
+	if err != nil {
+		s.shortService.Log.Warn("failed", zap.String("op", "GetLinkClicks"), zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "failed to get plan limits: %v", err)
+	}
+
+	clicks, err := s.clickService.GetClicks(req.ShortLinkId, since)
 	if err != nil {
 		s.shortService.Log.Warn("failed", zap.String("op", "GetLinkClicks"), zap.Error(err))
 		return nil, status.Errorf(codes.Internal, "failed to get link clicks: %v", err)
@@ -321,3 +402,75 @@ func (s *LinkServer) GetLinkClicks(ctx context.Context, req *linkv1.GetLinkClick
 
 	return resp, nil
 }
+
+// analyticsSince — начало окна статистики по плану пользователя
+func (s *LinkServer) analyticsSince(userID uuid.UUID) (time.Time, error) {
+	if s.quotaService == nil {
+		return time.Time{}, nil
+	}
+	return s.quotaService.AnalyticsSince(userID)
+}
+
+// createLinkOptions читает необязательные параметры создания ссылки из метаданных.
+// Заголовок и заметки передаются в percent-encoding: значения метаданных — только ASCII.
+func createLinkOptions(md metadata.MD) (service.CreateLinkOptions, error) {
+	get := func(key string) string {
+		if vals := md.Get(key); len(vals) > 0 {
+			return vals[0]
+		}
+		return ""
+	}
+
+	opts := service.CreateLinkOptions{
+		Alias: get("x-link-alias"),
+		UTM: service.UTMParams{
+			Source:   get("x-utm-source"),
+			Medium:   get("x-utm-medium"),
+			Campaign: get("x-utm-campaign"),
+			Term:     get("x-utm-term"),
+			Content:  get("x-utm-content"),
+		},
+	}
+	if v := get("x-forward-query"); v != "" {
+		forward, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("x-forward-query: %w", err)
+		}
+		opts.ForwardQuery = forward
+	}
+	var err error
+	if opts.Title, err = url.PathUnescape(get("x-link-title")); err != nil {
+		return opts, fmt.Errorf("x-link-title: %w", err)
+	}
+	if opts.Notes, err = url.PathUnescape(get("x-link-notes")); err != nil {
+		return opts, fmt.Errorf("x-link-notes: %w", err)
+	}
+	if v := get("x-link-domain-id"); v != "" {
+		domainID, err := uuid.Parse(v)
+		if err != nil {
+			return opts, fmt.Errorf("x-link-domain-id: %w", err)
+		}
+		opts.DomainID = &domainID
+	}
+	return opts, nil
+}
+
+// linkFilter читает фильтр списка ссылок из метаданных x-filter-tag-id / x-filter-folder-id
+func linkFilter(md metadata.MD) (repository.LinkFilter, error) {
+	var filter repository.LinkFilter
+	for key, dst := range map[string]**uuid.UUID{
+		"x-filter-tag-id":    &filter.TagID,
+		"x-filter-folder-id": &filter.FolderID,
+	} {
+		vals := md.Get(key)
+		if len(vals) == 0 || vals[0] == "" {
+			continue
+		}
+		id, err := uuid.Parse(vals[0])
+		if err != nil {
+			return filter, fmt.Errorf("%s: %w", key, err)
+		}
+		*dst = &id
+	}
+	return filter, nil
+}
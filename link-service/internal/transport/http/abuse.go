@@ -0,0 +1,162 @@
+package http
+
+import (
+	"errors"
+	"link-service/internal/models"
+	"link-service/internal/service"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// registerAbuse подключает публичные жалобы и модерацию; права администратора проверяет AbuseService
+func registerAbuse(mux *http.ServeMux, a *api, abuse *service.AbuseService) {
+	h := &abuseHandler{api: a, abuse: abuse}
+	mux.HandleFunc("POST /api/reports", h.report)
+	mux.HandleFunc("GET /api/admin/reports", a.authed(h.listReports))
+	mux.HandleFunc("POST /api/admin/reports/{id}/dismiss", a.authed(h.dismissReport))
+	mux.HandleFunc("POST /api/admin/links/{id}/block", a.authed(h.blockLink))
+	mux.HandleFunc("DELETE /api/admin/links/{id}/block", a.authed(h.unblockLink))
+}
+
+type abuseHandler struct {
+	*api
+	abuse *service.AbuseService
+}
+
+type reportRequest struct {
+	// Короткая ссылка целиком: по хосту определяется брендированный домен
+	ShortURL string `json:"short_url"`
+	Reason   string `json:"reason"`
+	Details  string `json:"details"`
+}
+
+type reportResponse struct {
+	ID          string     `json:"id"`
+	ShortLinkID string     `json:"short_link_id"`
+	ShortCode   string     `json:"short_code,omitempty"`
+	OriginalURL string     `json:"original_url,omitempty"`
+	Reason      string     `json:"reason"`
+	Details     string     `json:"details,omitempty"`
+	Status      string     `json:"status"`
+	ReporterIP  string     `json:"reporter_ip,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+}
+
+func toReportResponse(r *models.LinkReport, withReporter bool) reportResponse {
+	resp := reportResponse{
+		ID:          r.ID.String(),
+		ShortLinkID: r.ShortLinkID.String(),
+		ShortCode:   r.ShortLink.ShortCode,
+		OriginalURL: r.ShortLink.OriginalURL,
+		Reason:      r.Reason,
+		Details:     r.Details,
+		Status:      r.Status,
+		CreatedAt:   r.CreatedAt,
+		ResolvedAt:  r.ResolvedAt,
+	}
+	if withReporter {
+		resp.ReporterIP = r.ReporterIP
+	}
+	return resp
+}
+
+func (h *abuseHandler) report(w http.ResponseWriter, r *http.Request) {
+	var req reportRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	shortURL := strings.TrimSpace(req.ShortURL)
+	if !strings.Contains(shortURL, "://") {
+		shortURL = "https://" + shortURL
+	}
+	u, err := url.Parse(shortURL)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid short_url")
+		return
+	}
+	code := strings.Trim(u.Path, "/")
+	if code == "" || strings.Contains(code, "/") {
+		writeError(w, http.StatusBadRequest, "invalid short_url")
+		return
+	}
+
+	report, err := h.abuse.ReportLink(u.Host, code, req.Reason, req.Details, h.clientIP(r), h.optionalUser(r))
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusCreated, toReportResponse(report, false))
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		writeError(w, http.StatusNotFound, "short link not found")
+	case errors.Is(err, service.ErrInvalidReportReason):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrReportAlreadyExists):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		h.fail(w, r, err)
+	}
+}
+
+func (h *abuseHandler) listReports(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	limit, offset := page(r)
+	reports, err := h.abuse.ListReports(userID, r.URL.Query().Get("status"), limit, offset)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	resp := make([]reportResponse, 0, len(reports))
+	for i := range reports {
+		resp = append(resp, toReportResponse(&reports[i], true))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"reports": resp})
+}
+
+func (h *abuseHandler) dismissReport(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	err := h.abuse.DismissReport(userID, id)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, service.ErrReportNotOpen):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		h.fail(w, r, err)
+	}
+}
+
+func (h *abuseHandler) blockLink(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if r.ContentLength != 0 && !decodeJSON(w, r, &req) {
+		return
+	}
+	if err := h.abuse.BlockLink(userID, id, req.Reason); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *abuseHandler) unblockLink(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	if err := h.abuse.UnblockLink(userID, id); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,125 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"link-service/internal/authclient"
+	"link-service/internal/clientip"
+	"link-service/internal/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const maxBodyBytes = 64 << 10
+
+type api struct {
+	auth    *authclient.Validator
+	clients *clientip.Resolver
+	// Основной домен коротких ссылок
+	domain string
+	log    *zap.Logger
+}
+
+// authed пропускает запрос только с действительным access-токеном и передаёт пользователя обработчику
+func (a *api) authed(next func(w http.ResponseWriter, r *http.Request, userID uuid.UUID)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		userID, err := a.auth.Validate(r.Context(), token)
+		if errors.Is(err, authclient.ErrUnavailable) {
+			writeError(w, http.StatusServiceUnavailable, "auth service unavailable")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, "invalid token")
+			return
+		}
+		next(w, r, userID)
+	}
+}
+
+// optionalUser — пользователь запроса, если передан действительный токен
+func (a *api) optionalUser(r *http.Request) *uuid.UUID {
+	token, ok := bearerToken(r)
+	if !ok {
+		return nil
+	}
+	userID, err := a.auth.Validate(r.Context(), token)
+	if err != nil {
+		return nil
+	}
+	return &userID
+}
+
+// clientIP — адрес соединения; X-Forwarded-For учитывается, только если запрос пришёл от доверенного шлюза
+func (a *api) clientIP(r *http.Request) string {
+	return a.clients.Resolve(r.RemoteAddr, strings.Join(r.Header.Values("X-Forwarded-For"), ","))
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return token, ok && token != ""
+}
+
+// decodeJSON читает тело запроса; при ошибке ответ уже отправлен
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes)).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return false
+	}
+	return true
+}
+
+// pathUUID — UUID из параметра пути; при ошибке ответ уже отправлен
+func pathUUID(w http.ResponseWriter, r *http.Request, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue(name))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid "+name)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// page — limit / offset из query с ограничением размера страницы
+func page(r *http.Request) (limit, offset int) {
+	limit, _ = strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ = strconv.Atoi(r.URL.Query().Get("offset"))
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// fail отвечает на ошибку сервиса, которую обработчик не разобрал сам
+func (a *api) fail(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		writeError(w, http.StatusNotFound, "not found")
+	case errors.Is(err, service.ErrForbidden):
+		writeError(w, http.StatusForbidden, "forbidden")
+	default:
+		a.log.Error("HTTP request failed", zap.String("method", r.Method), zap.String("path", r.URL.Path), zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,36 @@
+package http
+
+import (
+	"html/template"
+	"net/http"
+)
+
+var blockedTemplate = template.Must(template.New("blocked").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Ссылка заблокирована — LinkVault</title>
+</head>
+<body style="font-family: sans-serif; max-width: 560px; margin: 64px auto; padding: 0 16px;">
+<h1>Ссылка заблокирована</h1>
+<p>Короткая ссылка{{if .}} <code>{{.}}</code>{{end}} заблокирована администрацией LinkVault по жалобе:
+она может вести на фишинговую, вредоносную или запрещённую страницу.</p>
+<p>Переход по ней недоступен. Если вы владелец ссылки и считаете блокировку ошибочной, обратитесь в поддержку.</p>
+</body>
+</html>
+`))
+
+// blockedPage — страница-предупреждение, на которую RedirectLink отправляет с заблокированных ссылок
+func blockedPage(w http.ResponseWriter, r *http.Request) {
+	code := r.URL.Query().Get("code")
+	if len(code) > 64 {
+		code = ""
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+	w.WriteHeader(http.StatusForbidden)
+	_ = blockedTemplate.Execute(w, code)
+}
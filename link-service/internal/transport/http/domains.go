@@ -0,0 +1,122 @@
+package http
+
+import (
+	"errors"
+	"link-service/internal/models"
+	"link-service/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// registerDomains подключает управление брендированными доменами
+func registerDomains(mux *http.ServeMux, a *api, domains *service.DomainService) {
+	h := &domainHandler{api: a, domains: domains}
+	mux.HandleFunc("GET /api/domains", a.authed(h.list))
+	mux.HandleFunc("POST /api/domains", a.authed(h.add))
+	mux.HandleFunc("POST /api/domains/{id}/verify", a.authed(h.verify))
+	mux.HandleFunc("DELETE /api/domains/{id}", a.authed(h.delete))
+}
+
+type domainHandler struct {
+	*api
+	domains *service.DomainService
+}
+
+type verificationRecord struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type domainResponse struct {
+	ID         string     `json:"id"`
+	Host       string     `json:"host"`
+	Verified   bool       `json:"verified"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	// TXT-запись, которую нужно создать для подтверждения
+	Verification *verificationRecord `json:"verification,omitempty"`
+}
+
+func toDomainResponse(d *models.Domain) domainResponse {
+	resp := domainResponse{
+		ID:         d.ID.String(),
+		Host:       d.Host,
+		Verified:   d.Verified,
+		VerifiedAt: d.VerifiedAt,
+		CreatedAt:  d.CreatedAt,
+	}
+	if !d.Verified {
+		name, value := service.VerificationRecord(d)
+		resp.Verification = &verificationRecord{Type: "TXT", Name: name, Value: value}
+	}
+	return resp
+}
+
+func (h *domainHandler) list(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	domains, err := h.domains.ListDomains(userID)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	resp := make([]domainResponse, 0, len(domains))
+	for i := range domains {
+		resp = append(resp, toDomainResponse(&domains[i]))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"domains": resp})
+}
+
+func (h *domainHandler) add(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	var req struct {
+		Host string `json:"host"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	domain, err := h.domains.AddDomain(userID, req.Host)
+	if err != nil {
+		h.domainError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toDomainResponse(domain))
+}
+
+func (h *domainHandler) verify(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	domain, err := h.domains.VerifyDomain(r.Context(), userID, id)
+	if err != nil {
+		h.domainError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toDomainResponse(domain))
+}
+
+func (h *domainHandler) delete(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	if err := h.domains.DeleteDomain(userID, id); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *domainHandler) domainError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidDomain):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrDomainTaken):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrVerificationFailed):
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+	default:
+		h.fail(w, r, err)
+	}
+}
@@ -0,0 +1,138 @@
+package http
+
+import (
+	"errors"
+	"link-service/internal/models"
+	"link-service/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// registerLinks подключает поля ссылки, которых нет в ShortLinkResponse: заголовок, заметки, метаданные
+func registerLinks(mux *http.ServeMux, a *api, links *service.ShortLinkService, health *service.HealthService) {
+	h := &linkHandler{api: a, links: links, health: health}
+	mux.HandleFunc("GET /api/links/{id}", a.authed(h.get))
+	mux.HandleFunc("PATCH /api/links/{id}", a.authed(h.update))
+	if health != nil {
+		mux.HandleFunc("GET /api/links/{id}/health", a.authed(h.healthHistory))
+	}
+}
+
+type linkHandler struct {
+	*api
+	links  *service.ShortLinkService
+	health *service.HealthService
+}
+
+type linkMetadata struct {
+	Title       string     `json:"title,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Image       string     `json:"image,omitempty"`
+	Favicon     string     `json:"favicon,omitempty"`
+	FetchedAt   *time.Time `json:"fetched_at,omitempty"`
+}
+
+type linkResponse struct {
+	ID          string       `json:"id"`
+	ShortURL    string       `json:"short_url"`
+	OriginalURL string       `json:"original_url"`
+	ShortCode   string       `json:"short_code"`
+	Title       string       `json:"title"`
+	Notes       string       `json:"notes"`
+	Metadata    linkMetadata `json:"metadata"`
+	IsActive    bool         `json:"is_active"`
+	IsBlocked   bool         `json:"is_blocked"`
+	// Цель недоступна по данным мониторинга
+	IsBroken        bool       `json:"is_broken"`
+	HealthCheckedAt *time.Time `json:"health_checked_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ExpireAt        *time.Time `json:"expire_at,omitempty"`
+}
+
+func (h *linkHandler) toLinkResponse(l *models.ShortLink) linkResponse {
+	return linkResponse{
+		ID:          l.ID.String(),
+		ShortURL:    service.ShortURL(h.domain, l),
+		OriginalURL: l.OriginalURL,
+		ShortCode:   l.ShortCode,
+		Title:       l.Title,
+		Notes:       l.Notes,
+		Metadata: linkMetadata{
+			Title:       l.MetaTitle,
+			Description: l.MetaDescription,
+			Image:       l.MetaImage,
+			Favicon:     l.MetaFavicon,
+			FetchedAt:   l.MetaFetchedAt,
+		},
+		IsActive:        l.IsActive,
+		IsBlocked:       l.IsBlocked,
+		IsBroken:        l.IsBroken,
+		HealthCheckedAt: l.HealthLastCheckedAt,
+		CreatedAt:       l.CreatedAt,
+		ExpireAt:        l.ExpireAt,
+	}
+}
+
+func (h *linkHandler) get(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	link, err := h.links.GetShortLinkByID(id.String(), userID)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.toLinkResponse(link))
+}
+
+// update меняет заголовок и заметки; отсутствующее в теле поле не меняется
+func (h *linkHandler) update(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	var req struct {
+		Title *string `json:"title"`
+		Notes *string `json:"notes"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	link, err := h.links.UpdateLinkDetails(id, userID, req.Title, req.Notes)
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, h.toLinkResponse(link))
+	case errors.Is(err, service.ErrInvalidDetails):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		h.fail(w, r, err)
+	}
+}
+
+// healthHistory — последние проверки доступности цели (?limit=, до 100)
+func (h *linkHandler) healthHistory(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	limit, _ := page(r)
+	checks, err := h.health.History(id, userID, limit)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	resp := make([]map[string]any, 0, len(checks))
+	for _, c := range checks {
+		resp = append(resp, map[string]any{
+			"status_code": c.StatusCode,
+			"error":       c.Error,
+			"healthy":     c.Healthy,
+			"latency_ms":  c.LatencyMs,
+			"checked_at":  c.CheckedAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"checks": resp})
+}
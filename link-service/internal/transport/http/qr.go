@@ -0,0 +1,73 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"link-service/internal/qr"
+	"link-service/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+const maxLogoBytes = 512 << 10
+
+// registerQR подключает генерацию QR-кода ссылки. GET — без логотипа,
+// POST — с логотипом PNG/JPEG в теле запроса.
+func registerQR(mux *http.ServeMux, a *api, qrService *service.QRService) {
+	h := &qrHandler{api: a, qr: qrService}
+	mux.HandleFunc("GET /api/links/{id}/qr", a.authed(h.generate))
+	mux.HandleFunc("POST /api/links/{id}/qr", a.authed(h.generate))
+}
+
+type qrHandler struct {
+	*api
+	qr *service.QRService
+}
+
+func (h *qrHandler) generate(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	opts := qr.Options{
+		Level:      q.Get("level"),
+		Foreground: q.Get("fg"),
+		Background: q.Get("bg"),
+		NoBorder:   q.Get("border") == "false",
+	}
+	if v := q.Get("size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, qr.ErrInvalidSize.Error())
+			return
+		}
+		opts.Size = size
+	}
+	if r.Method == http.MethodPost {
+		logo, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxLogoBytes))
+		if err != nil {
+			writeError(w, http.StatusRequestEntityTooLarge, "logo is too large")
+			return
+		}
+		opts.Logo = logo
+	}
+
+	data, contentType, err := h.qr.GenerateForLink(id, userID, q.Get("format"), opts)
+	switch {
+	case err == nil:
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", "private, max-age=300")
+		_, _ = w.Write(data)
+	case errors.Is(err, service.ErrUnsupportedFormat),
+		errors.Is(err, qr.ErrInvalidSize),
+		errors.Is(err, qr.ErrInvalidLevel),
+		errors.Is(err, qr.ErrInvalidColor),
+		errors.Is(err, qr.ErrInvalidLogo):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		h.fail(w, r, err)
+	}
+}
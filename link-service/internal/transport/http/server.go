@@ -0,0 +1,64 @@
+package http
+
+import (
+	"link-service/config"
+	"link-service/internal/authclient"
+	"link-service/internal/clientip"
+	"link-service/internal/service"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Services — сервисы, доступные через HTTP API; nil — группа маршрутов не подключается
+type Services struct {
+	Links    *service.ShortLinkService
+	Health   *service.HealthService
+	QR       *service.QRService
+	Domains  *service.DomainService
+	Abuse    *service.AbuseService
+	Tags     *service.TagService
+	Clicks   *service.ClickService
+	Quota    *service.QuotaService
+	Webhooks *service.WebhookService
+}
+
+// NewServer — HTTP API для возможностей, которых пока нет в linkvault-proto, и страница-предупреждение
+// для заблокированных ссылок. Авторизация — тот же access-токен, что и в gRPC (Authorization: Bearer).
+func NewServer(cfg *config.Config, validator *authclient.Validator, svc Services, log *zap.Logger) *http.Server {
+	mux := http.NewServeMux()
+	a := &api{
+		auth:    validator,
+		clients: clientip.NewResolver(cfg.HTTP.TrustedProxies, log),
+		domain:  cfg.Domain,
+		log:     log,
+	}
+	mux.HandleFunc("GET /blocked", blockedPage)
+	if svc.Links != nil {
+		registerLinks(mux, a, svc.Links, svc.Health)
+	}
+	if svc.QR != nil {
+		registerQR(mux, a, svc.QR)
+	}
+	if svc.Domains != nil {
+		registerDomains(mux, a, svc.Domains)
+	}
+	if svc.Abuse != nil {
+		registerAbuse(mux, a, svc.Abuse)
+	}
+	if svc.Tags != nil {
+		registerTags(mux, a, svc.Tags, svc.Clicks, svc.Quota)
+	}
+	if svc.Quota != nil {
+		registerUsage(mux, a, svc.Quota)
+	}
+	if svc.Webhooks != nil {
+		registerWebhooks(mux, a, svc.Webhooks)
+	}
+	return &http.Server{
+		Addr:              cfg.HTTP.Port,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
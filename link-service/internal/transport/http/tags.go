@@ -0,0 +1,244 @@
+package http
+
+import (
+	"errors"
+	"link-service/internal/models"
+	"link-service/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// registerTags подключает теги, папки и статистику по тегу
+func registerTags(mux *http.ServeMux, a *api, tags *service.TagService, clicks *service.ClickService, quota *service.QuotaService) {
+	h := &tagHandler{api: a, tags: tags, clicks: clicks, quota: quota}
+	mux.HandleFunc("GET /api/tags", a.authed(h.listTags))
+	mux.HandleFunc("POST /api/tags", a.authed(h.createTag))
+	mux.HandleFunc("PATCH /api/tags/{id}", a.authed(h.renameTag))
+	mux.HandleFunc("DELETE /api/tags/{id}", a.authed(h.deleteTag))
+	mux.HandleFunc("GET /api/tags/{id}/stats", a.authed(h.tagStats))
+	mux.HandleFunc("GET /api/folders", a.authed(h.listFolders))
+	mux.HandleFunc("POST /api/folders", a.authed(h.createFolder))
+	mux.HandleFunc("PATCH /api/folders/{id}", a.authed(h.renameFolder))
+	mux.HandleFunc("DELETE /api/folders/{id}", a.authed(h.deleteFolder))
+	mux.HandleFunc("PUT /api/links/{id}/tags", a.authed(h.setLinkTags))
+	mux.HandleFunc("PUT /api/links/{id}/folder", a.authed(h.moveLink))
+}
+
+type tagHandler struct {
+	*api
+	tags   *service.TagService
+	clicks *service.ClickService
+	quota  *service.QuotaService
+}
+
+type nameRequest struct {
+	Name string `json:"name"`
+}
+
+type tagResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func toTagResponse(t models.Tag) tagResponse {
+	return tagResponse{ID: t.ID.String(), Name: t.Name, CreatedAt: t.CreatedAt}
+}
+
+func toFolderResponse(f models.Folder) tagResponse {
+	return tagResponse{ID: f.ID.String(), Name: f.Name, CreatedAt: f.CreatedAt}
+}
+
+// tagError разбирает ошибки TagService, общие для тегов и папок
+func (h *tagHandler) tagError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidName), errors.Is(err, service.ErrTooManyTags):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		h.fail(w, r, err)
+	}
+}
+
+func (h *tagHandler) listTags(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	tags, err := h.tags.ListTags(userID)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	resp := make([]tagResponse, 0, len(tags))
+	for _, t := range tags {
+		resp = append(resp, toTagResponse(t))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"tags": resp})
+}
+
+func (h *tagHandler) createTag(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	var req nameRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	tag, err := h.tags.CreateTag(userID, req.Name)
+	if err != nil {
+		h.tagError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toTagResponse(*tag))
+}
+
+func (h *tagHandler) renameTag(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	var req nameRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if err := h.tags.RenameTag(userID, id, req.Name); err != nil {
+		h.tagError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *tagHandler) deleteTag(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	if err := h.tags.DeleteTag(userID, id); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *tagHandler) tagStats(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	since, err := h.quota.AnalyticsSince(userID)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	stats, err := h.clicks.GetTagStats(userID, id, since)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	links := make([]map[string]any, 0, len(stats.Links))
+	for _, l := range stats.Links {
+		links = append(links, map[string]any{
+			"short_link_id": l.ShortLinkID.String(),
+			"short_code":    l.ShortCode,
+			"clicks":        l.Clicks,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"total":           stats.Total,
+		"unique_ip_count": stats.UniqueIPCount,
+		"links":           links,
+		"daily_stats":     stats.DailyStats,
+	})
+}
+
+func (h *tagHandler) listFolders(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	folders, err := h.tags.ListFolders(userID)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	resp := make([]tagResponse, 0, len(folders))
+	for _, f := range folders {
+		resp = append(resp, toFolderResponse(f))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"folders": resp})
+}
+
+func (h *tagHandler) createFolder(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	var req nameRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	folder, err := h.tags.CreateFolder(userID, req.Name)
+	if err != nil {
+		h.tagError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toFolderResponse(*folder))
+}
+
+func (h *tagHandler) renameFolder(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	var req nameRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if err := h.tags.RenameFolder(userID, id, req.Name); err != nil {
+		h.tagError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *tagHandler) deleteFolder(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	if err := h.tags.DeleteFolder(userID, id); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *tagHandler) setLinkTags(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	var req struct {
+		Tags []string `json:"tags"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	tags, err := h.tags.SetLinkTags(userID, id, req.Tags)
+	if err != nil {
+		h.tagError(w, r, err)
+		return
+	}
+	resp := make([]tagResponse, 0, len(tags))
+	for _, t := range tags {
+		resp = append(resp, toTagResponse(t))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"tags": resp})
+}
+
+// moveLink перемещает ссылку в папку; folder_id: null — убрать из папки
+func (h *tagHandler) moveLink(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	var req struct {
+		FolderID *uuid.UUID `json:"folder_id"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if err := h.tags.MoveLinkToFolder(userID, id, req.FolderID); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
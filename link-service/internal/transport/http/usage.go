@@ -0,0 +1,40 @@
+package http
+
+import (
+	"link-service/internal/service"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// registerUsage подключает план пользователя и потребление квот
+func registerUsage(mux *http.ServeMux, a *api, quota *service.QuotaService) {
+	h := &usageHandler{api: a, quota: quota}
+	mux.HandleFunc("GET /api/usage", a.authed(h.usage))
+}
+
+type usageHandler struct {
+	*api
+	quota *service.QuotaService
+}
+
+// quotaUsage — потребление и лимит; лимит 0 — без ограничения
+type quotaUsage struct {
+	Used  int64 `json:"used"`
+	Limit int64 `json:"limit"`
+}
+
+func (h *usageHandler) usage(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	usage, err := h.quota.Usage(userID)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"plan":                     usage.Plan,
+		"active_links":             quotaUsage{Used: usage.ActiveLinks, Limit: usage.Limits.MaxActiveLinks},
+		"custom_aliases":           quotaUsage{Used: usage.CustomAliases, Limit: usage.Limits.MaxCustomAliases},
+		"api_calls_today":          quotaUsage{Used: usage.APICallsToday, Limit: usage.Limits.MaxAPICallsPerDay},
+		"analytics_retention_days": usage.Limits.AnalyticsRetentionDays,
+	})
+}
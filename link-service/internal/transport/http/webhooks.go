@@ -0,0 +1,204 @@
+package http
+
+import (
+	"errors"
+	"link-service/internal/models"
+	"link-service/internal/service"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// registerWebhooks подключает endpoint'ы вебхуков, журнал доставок и повтор доставки
+func registerWebhooks(mux *http.ServeMux, a *api, webhooks *service.WebhookService) {
+	h := &webhookHandler{api: a, webhooks: webhooks}
+	mux.HandleFunc("GET /api/webhooks", a.authed(h.list))
+	mux.HandleFunc("POST /api/webhooks", a.authed(h.create))
+	mux.HandleFunc("PATCH /api/webhooks/{id}", a.authed(h.update))
+	mux.HandleFunc("DELETE /api/webhooks/{id}", a.authed(h.delete))
+	mux.HandleFunc("POST /api/webhooks/{id}/rotate-secret", a.authed(h.rotateSecret))
+	mux.HandleFunc("GET /api/webhooks/{id}/deliveries", a.authed(h.deliveries))
+	mux.HandleFunc("POST /api/webhook-deliveries/{id}/replay", a.authed(h.replay))
+}
+
+type webhookHandler struct {
+	*api
+	webhooks *service.WebhookService
+}
+
+type webhookResponse struct {
+	ID         string   `json:"id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Active     bool     `json:"active"`
+	// Секрет подписи отдаётся только при создании
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func toWebhookResponse(e *models.WebhookEndpoint) webhookResponse {
+	return webhookResponse{
+		ID:         e.ID.String(),
+		URL:        e.URL,
+		EventTypes: strings.Split(e.EventTypes, ","),
+		Active:     e.Active,
+		CreatedAt:  e.CreatedAt,
+	}
+}
+
+type deliveryResponse struct {
+	ID            string     `json:"id"`
+	EventID       string     `json:"event_id"`
+	EventType     string     `json:"event_type"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	ResponseCode  int        `json:"response_code,omitempty"`
+	ResponseBody  string     `json:"response_body,omitempty"`
+	Error         string     `json:"error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	ReplayOf      *uuid.UUID `json:"replay_of,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func toDeliveryResponse(d *models.WebhookDelivery) deliveryResponse {
+	return deliveryResponse{
+		ID:            d.ID.String(),
+		EventID:       d.EventID,
+		EventType:     d.EventType,
+		Status:        d.Status,
+		Attempts:      d.Attempts,
+		ResponseCode:  d.ResponseCode,
+		ResponseBody:  d.ResponseBody,
+		Error:         d.Error,
+		NextAttemptAt: d.NextAttemptAt,
+		DeliveredAt:   d.DeliveredAt,
+		ReplayOf:      d.ReplayOf,
+		CreatedAt:     d.CreatedAt,
+	}
+}
+
+func (h *webhookHandler) webhookError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidWebhookURL),
+		errors.Is(err, service.ErrInvalidEventTypes),
+		errors.Is(err, service.ErrTooManyWebhooks):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrDeliveryNotFailed):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		h.fail(w, r, err)
+	}
+}
+
+func (h *webhookHandler) list(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	endpoints, err := h.webhooks.ListEndpoints(userID)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	resp := make([]webhookResponse, 0, len(endpoints))
+	for i := range endpoints {
+		resp = append(resp, toWebhookResponse(&endpoints[i]))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"webhooks": resp})
+}
+
+func (h *webhookHandler) create(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	var req struct {
+		URL        string   `json:"url"`
+		EventTypes []string `json:"event_types"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	endpoint, err := h.webhooks.CreateEndpoint(userID, req.URL, req.EventTypes)
+	if err != nil {
+		h.webhookError(w, r, err)
+		return
+	}
+	resp := toWebhookResponse(endpoint)
+	resp.Secret = endpoint.Secret
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// update меняет URL, подписки и активность; отсутствующее в теле поле не меняется
+func (h *webhookHandler) update(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	var req struct {
+		URL        *string  `json:"url"`
+		EventTypes []string `json:"event_types"`
+		Active     *bool    `json:"active"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	endpoint, err := h.webhooks.UpdateEndpoint(id, userID, req.URL, req.EventTypes, req.Active)
+	if err != nil {
+		h.webhookError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toWebhookResponse(endpoint))
+}
+
+func (h *webhookHandler) delete(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	if err := h.webhooks.DeleteEndpoint(id, userID); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *webhookHandler) rotateSecret(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	secret, err := h.webhooks.RotateSecret(id, userID)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"secret": secret})
+}
+
+// deliveries — журнал доставок endpoint'а, новые первыми (?limit=, до 100)
+func (h *webhookHandler) deliveries(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	limit, _ := page(r)
+	deliveries, err := h.webhooks.ListDeliveries(id, userID, limit)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	resp := make([]deliveryResponse, 0, len(deliveries))
+	for i := range deliveries {
+		resp = append(resp, toDeliveryResponse(&deliveries[i]))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"deliveries": resp})
+}
+
+func (h *webhookHandler) replay(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	id, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	delivery, err := h.webhooks.ReplayDelivery(id, userID)
+	if err != nil {
+		h.webhookError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, toDeliveryResponse(delivery))
+}
@@ -0,0 +1,20 @@
+package urlpolicy
+
+import (
+	"net"
+	"syscall"
+)
+
+// DialControl запрещает исходящие соединения на приватные адреса.
+// Вызывается после DNS-резолва, поэтому защищает и от DNS rebinding.
+func DialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || isPrivateIP(ip) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package urlpolicy
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// DomainList — набор доменов; запись совпадает с самим доменом и всеми его поддоменами
+type DomainList struct {
+	domains map[string]bool
+}
+
+func NewDomainList(domains ...string) *DomainList {
+	l := &DomainList{domains: make(map[string]bool, len(domains))}
+	for _, d := range domains {
+		if h := hostOf(d); h != "" {
+			l.domains[h] = true
+		}
+	}
+	return l
+}
+
+// LoadDomainList читает список доменов из файла: по одному на строку, `#` — комментарий
+func LoadDomainList(path string) (*DomainList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var domains []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line != "" {
+			domains = append(domains, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return NewDomainList(domains...), nil
+}
+
+func (l *DomainList) Len() int {
+	return len(l.domains)
+}
+
+func (l *DomainList) Match(host string) bool {
+	host = normalizeHost(host)
+	for host != "" {
+		if l.domains[host] {
+			return true
+		}
+		i := strings.IndexByte(host, '.')
+		if i < 0 {
+			break
+		}
+		host = host[i+1:]
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package urlpolicy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMalformedURL     = errors.New("malformed url")
+	ErrSchemeNotAllowed = errors.New("url scheme not allowed")
+	ErrCredentialsInURL = errors.New("credentials in url are not allowed")
+	ErrPrivateAddress   = errors.New("private or loopback destination")
+	ErrDomainBlocked    = errors.New("destination domain is blocked")
+	ErrDomainNotAllowed = errors.New("destination domain is not in allowlist")
+	ErrUnsafeURL        = errors.New("destination flagged as unsafe")
+	ErrRedirectLoop     = errors.New("destination points back to short link domain")
+)
+
+// Resolver — источник IP-адресов хоста (net.DefaultResolver или заглушка)
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// Policy проверяет целевые URL перед созданием короткой ссылки
+type Policy struct {
+	allowedSchemes  map[string]bool
+	blockPrivateIPs bool
+	blocklist       *DomainList
+	allowlist       *DomainList
+	checkers        []ReputationChecker
+	ownHosts        map[string]bool
+	resolver        Resolver
+	lookupTimeout   time.Duration
+}
+
+type Option func(*Policy)
+
+func WithAllowedSchemes(schemes ...string) Option {
+	return func(p *Policy) {
+		p.allowedSchemes = make(map[string]bool, len(schemes))
+		for _, s := range schemes {
+			p.allowedSchemes[strings.ToLower(strings.TrimSpace(s))] = true
+		}
+	}
+}
+
+func WithPrivateIPBlocking(enabled bool) Option {
+	return func(p *Policy) { p.blockPrivateIPs = enabled }
+}
+
+func WithBlocklist(list *DomainList) Option {
+	return func(p *Policy) { p.blocklist = list }
+}
+
+func WithAllowlist(list *DomainList) Option {
+	return func(p *Policy) { p.allowlist = list }
+}
+
+func WithReputationChecker(c ReputationChecker) Option {
+	return func(p *Policy) { p.checkers = append(p.checkers, c) }
+}
+
+// WithOwnDomains задаёт домены сервиса (например, cfg.Domain), на которые нельзя ссылаться
+func WithOwnDomains(domains ...string) Option {
+	return func(p *Policy) {
+		for _, d := range domains {
+			if h := hostOf(d); h != "" {
+				p.ownHosts[h] = true
+			}
+		}
+	}
+}
+
+func WithResolver(r Resolver) Option {
+	return func(p *Policy) { p.resolver = r }
+}
+
+func New(opts ...Option) *Policy {
+	p := &Policy{
+		allowedSchemes:  map[string]bool{"http": true, "https": true},
+		blockPrivateIPs: true,
+		ownHosts:        make(map[string]bool),
+		resolver:        net.DefaultResolver,
+		lookupTimeout:   2 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Check возвращает ошибку, если URL нарушает политику
+func (p *Policy) Check(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return ErrMalformedURL
+	}
+	if !p.allowedSchemes[strings.ToLower(u.Scheme)] {
+		return ErrSchemeNotAllowed
+	}
+	if u.User != nil {
+		return ErrCredentialsInURL
+	}
+
+	host := normalizeHost(u.Hostname())
+	if host == "" {
+		return ErrMalformedURL
+	}
+	if p.ownHosts[host] {
+		return ErrRedirectLoop
+	}
+	if p.allowlist != nil && p.allowlist.Len() > 0 && !p.allowlist.Match(host) {
+		return ErrDomainNotAllowed
+	}
+	if p.blocklist != nil && p.blocklist.Match(host) {
+		return ErrDomainBlocked
+	}
+	if p.blockPrivateIPs {
+		if err := p.checkAddress(ctx, host); err != nil {
+			return err
+		}
+	}
+	for _, c := range p.checkers {
+		unsafe, err := c.IsUnsafe(ctx, u)
+		if err != nil {
+			return err
+		}
+		if unsafe {
+			return ErrUnsafeURL
+		}
+	}
+	return nil
+}
+
+func (p *Policy) checkAddress(ctx context.Context, host string) error {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateAddress
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if isPrivateIP(ip) {
+			return ErrPrivateAddress
+		}
+		return nil
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, p.lookupTimeout)
+	defer cancel()
+	addrs, err := p.resolver.LookupIPAddr(lookupCtx, host)
+	if err != nil {
+		// Неразрешимый хост не считаем нарушением: DNS может быть временно недоступен
+		return nil
+	}
+	for _, a := range addrs {
+		if isPrivateIP(a.IP) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || isCGNAT(ip)
+}
+
+var cgnat = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func isCGNAT(ip net.IP) bool {
+	return ip.To4() != nil && cgnat.Contains(ip)
+}
+
+func normalizeHost(h string) string {
+	return strings.TrimSuffix(strings.ToLower(h), ".")
+}
+
+func hostOf(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return normalizeHost(u.Hostname())
+}
@@ -0,0 +1,83 @@
+package urlpolicy
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// ReputationChecker — внешний или локальный источник репутации URL
+type ReputationChecker interface {
+	IsUnsafe(ctx context.Context, u *url.URL) (bool, error)
+}
+
+// HashListChecker сверяет SHA-256 хоста и URL без query с локальным списком хешей
+type HashListChecker struct {
+	hashes map[string]bool
+}
+
+func NewHashListChecker(hashes ...string) *HashListChecker {
+	c := &HashListChecker{hashes: make(map[string]bool, len(hashes))}
+	for _, h := range hashes {
+		if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+			c.hashes[h] = true
+		}
+	}
+	return c
+}
+
+// LoadHashListChecker читает hex-хеши из файла: по одному на строку, `#` — комментарий
+func LoadHashListChecker(path string) (*HashListChecker, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var hashes []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hashes = append(hashes, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return NewHashListChecker(hashes...), nil
+}
+
+func (c *HashListChecker) IsUnsafe(_ context.Context, u *url.URL) (bool, error) {
+	for _, candidate := range hashCandidates(u) {
+		sum := sha256.Sum256([]byte(candidate))
+		if c.hashes[hex.EncodeToString(sum[:])] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hashCandidates — канонические формы URL: хост, его родительские домены и хост+путь
+func hashCandidates(u *url.URL) []string {
+	host := normalizeHost(u.Hostname())
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	candidates := []string{host + path}
+	for h := host; h != ""; {
+		candidates = append(candidates, h)
+		i := strings.IndexByte(h, '.')
+		if i < 0 {
+			break
+		}
+		h = h[i+1:]
+	}
+	return candidates
+}
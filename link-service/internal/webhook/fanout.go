@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"encoding/json"
+	"link-service/internal/events"
+	"link-service/internal/models"
+	"link-service/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Fanout создаёт доставки для endpoint'ов владельца ссылки, подписанных на тип события
+type Fanout struct {
+	repo *repository.WebhookRepository
+}
+
+func NewFanout(repo *repository.WebhookRepository) *Fanout {
+	return &Fanout{repo: repo}
+}
+
+func (f *Fanout) Handle(tx *gorm.DB, e events.Event) error {
+	linkID, err := uuid.Parse(e.LinkID)
+	if err != nil {
+		return nil
+	}
+	repo := f.repo.WithTx(tx)
+	endpoints, err := repo.ActiveEndpointsForLink(linkID)
+	if err != nil || len(endpoints) == 0 {
+		return err
+	}
+
+	var payload []byte
+	for _, endpoint := range endpoints {
+		if !endpoint.Subscribed(e.Type) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(e); err != nil {
+				return err
+			}
+		}
+		if err := repo.CreateDelivery(&models.WebhookDelivery{
+			EndpointID:    endpoint.ID,
+			EventID:       e.ID,
+			EventType:     e.Type,
+			Payload:       payload,
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: time.Now(),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
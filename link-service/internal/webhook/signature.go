@@ -0,0 +1,31 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+)
+
+// Заголовки запроса вебхука
+const (
+	HeaderEvent     = "X-LinkVault-Event"
+	HeaderDelivery  = "X-LinkVault-Delivery"
+	HeaderTimestamp = "X-LinkVault-Timestamp"
+	HeaderSignature = "X-LinkVault-Signature"
+)
+
+// Sign — HMAC-SHA256 от "<timestamp>.<body>" в виде "sha256=<hex>".
+// Метка времени в подписи не даёт переиспользовать перехваченный запрос.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify проверяет подпись за постоянное время (для получателей и тестов)
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,191 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"link-service/internal/models"
+	"link-service/internal/repository"
+	"link-service/internal/urlpolicy"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	maxResponseBody = 1024
+	userAgent       = "LinkVault-Webhooks/1.0"
+)
+
+type Config struct {
+	Interval    time.Duration
+	Timeout     time.Duration
+	Concurrency int
+	// После стольких неудач доставка помечается failed
+	MaxAttempts int
+	// Разрешить приватные адреса (только для локальной разработки)
+	AllowPrivate bool
+}
+
+// Store — доставки и endpoint'ы, нужные воркеру (repository.WebhookRepository или заглушка в тестах)
+type Store interface {
+	ClaimDue(limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	GetEndpointByID(id uuid.UUID) (*models.WebhookEndpoint, error)
+	UpdateDelivery(id uuid.UUID, fields map[string]interface{}) error
+}
+
+var _ Store = (*repository.WebhookRepository)(nil)
+
+// Worker отправляет ожидающие доставки вебхуков
+type Worker struct {
+	repo   Store
+	client *http.Client
+	cfg    Config
+	log    *zap.Logger
+}
+
+func NewWorker(repo Store, cfg Config, log *zap.Logger) *Worker {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 2 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 4
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 8
+	}
+
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivate {
+		dialer.Control = urlpolicy.DialControl
+	}
+	client := &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: cfg.Timeout,
+			MaxIdleConnsPerHost: 2,
+		},
+		// Редирект считается неуспешной доставкой: подпись привязана к исходному URL
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return &Worker{repo: repo, client: client, cfg: cfg, log: log}
+}
+
+// Run работает до отмены ctx
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.processBatch(ctx)
+		}
+	}
+}
+
+func (w *Worker) processBatch(ctx context.Context) {
+	// Lease больше таймаута запроса, чтобы доставку не забрал другой экземпляр во время отправки
+	deliveries, err := w.repo.ClaimDue(w.cfg.Concurrency*8, 2*w.cfg.Timeout+time.Minute)
+	if err != nil {
+		w.log.Error("Ошибка выборки доставок вебхуков", zap.Error(err))
+		return
+	}
+
+	sem := make(chan struct{}, w.cfg.Concurrency)
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(d *models.WebhookDelivery) {
+			defer func() { <-sem; wg.Done() }()
+			w.deliver(ctx, d)
+		}(&deliveries[i])
+	}
+	wg.Wait()
+}
+
+func (w *Worker) deliver(ctx context.Context, d *models.WebhookDelivery) {
+	endpoint, err := w.repo.GetEndpointByID(d.EndpointID)
+	if err != nil || !endpoint.Active {
+		w.finish(d, map[string]interface{}{"status": models.WebhookDeliveryFailed, "error": "endpoint disabled or deleted"})
+		return
+	}
+
+	code, body, err := w.send(ctx, endpoint, d)
+	attempts := d.Attempts + 1
+	fields := map[string]interface{}{
+		"attempts":      attempts,
+		"response_code": code,
+		"response_body": body,
+		"error":         "",
+	}
+	switch {
+	case err == nil && code >= 200 && code < 300:
+		fields["status"] = models.WebhookDeliverySucceeded
+		fields["delivered_at"] = time.Now()
+	default:
+		if err != nil {
+			fields["error"] = err.Error()
+		} else {
+			fields["error"] = "unexpected status " + strconv.Itoa(code)
+		}
+		if attempts >= w.cfg.MaxAttempts {
+			fields["status"] = models.WebhookDeliveryFailed
+		} else {
+			fields["next_attempt_at"] = time.Now().Add(Backoff(attempts))
+		}
+	}
+	w.finish(d, fields)
+}
+
+func (w *Worker) send(ctx context.Context, endpoint *models.WebhookEndpoint, d *models.WebhookDelivery) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderEvent, d.EventType)
+	req.Header.Set(HeaderDelivery, d.ID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(endpoint.Secret, ts, d.Payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	// Тело ответа попадает в журнал, в text-колонку PostgreSQL допустим только UTF-8
+	return resp.StatusCode, strings.ToValidUTF8(string(body), "\uFFFD"), nil
+}
+
+func (w *Worker) finish(d *models.WebhookDelivery, fields map[string]interface{}) {
+	if err := w.repo.UpdateDelivery(d.ID, fields); err != nil {
+		w.log.Error("Не удалось сохранить результат доставки вебхука", zap.String("id", d.ID.String()), zap.Error(err))
+	}
+}
+
+// Backoff — 30s, 1m, 2m, ... но не больше 6 часов
+func Backoff(attempts int) time.Duration {
+	d := 30 * time.Second << min(attempts-1, 10)
+	return min(d, 6*time.Hour)
+}
@@ -0,0 +1,213 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"link-service/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// memoryStore — Store в памяти: ClaimDue отдаёт все доставки один раз
+type memoryStore struct {
+	mu         sync.Mutex
+	endpoints  map[uuid.UUID]*models.WebhookEndpoint
+	deliveries []models.WebhookDelivery
+	updates    map[uuid.UUID]map[string]interface{}
+}
+
+func (s *memoryStore) ClaimDue(limit int, _ time.Duration) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	claimed := s.deliveries[:min(limit, len(s.deliveries))]
+	s.deliveries = s.deliveries[len(claimed):]
+	return claimed, nil
+}
+
+func (s *memoryStore) GetEndpointByID(id uuid.UUID) (*models.WebhookEndpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.endpoints[id]; ok {
+		copied := *e
+		return &copied, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *memoryStore) UpdateDelivery(id uuid.UUID, fields map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updates[id] = fields
+	return nil
+}
+
+const testSecret = "whsec_test"
+
+var testPayload = []byte(`{"type":"link.created","data":{"id":"42"}}`)
+
+// newTestStore — один активный endpoint на url и одна ожидающая доставка с attempts попытками
+func newTestStore(url string, active bool, attempts int) (*memoryStore, uuid.UUID) {
+	endpoint := &models.WebhookEndpoint{ID: uuid.New(), URL: url, Secret: testSecret, EventTypes: "link.created", Active: active}
+	delivery := models.WebhookDelivery{
+		ID:         uuid.New(),
+		EndpointID: endpoint.ID,
+		EventID:    "evt-1",
+		EventType:  "link.created",
+		Payload:    testPayload,
+		Status:     models.WebhookDeliveryPending,
+		Attempts:   attempts,
+	}
+	return &memoryStore{
+		endpoints:  map[uuid.UUID]*models.WebhookEndpoint{endpoint.ID: endpoint},
+		deliveries: []models.WebhookDelivery{delivery},
+		updates:    map[uuid.UUID]map[string]interface{}{},
+	}, delivery.ID
+}
+
+func runOnce(store *memoryStore, allowPrivate bool) {
+	w := NewWorker(store, Config{Timeout: 2 * time.Second, MaxAttempts: 3, AllowPrivate: allowPrivate}, zap.NewNop())
+	w.processBatch(context.Background())
+}
+
+func TestWorkerDeliversSignedRequest(t *testing.T) {
+	var deliveryID uuid.UUID
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		if string(body) != string(testPayload) {
+			t.Errorf("body = %s", body)
+		}
+		if got := r.Header.Get(HeaderEvent); got != "link.created" {
+			t.Errorf("%s = %q", HeaderEvent, got)
+		}
+		if got := r.Header.Get(HeaderDelivery); got != deliveryID.String() {
+			t.Errorf("%s = %q, want %s", HeaderDelivery, got, deliveryID)
+		}
+		ts, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if err != nil || time.Since(time.Unix(ts, 0)) > time.Minute {
+			t.Errorf("bad timestamp %q", r.Header.Get(HeaderTimestamp))
+		}
+		if !Verify(testSecret, ts, body, r.Header.Get(HeaderSignature)) {
+			t.Errorf("signature %q does not verify", r.Header.Get(HeaderSignature))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	store, id := newTestStore(srv.URL+"/hooks", true, 0)
+	deliveryID = id
+	runOnce(store, true)
+
+	got := store.updates[id]
+	if got["status"] != models.WebhookDeliverySucceeded || got["attempts"] != 1 || got["response_code"] != http.StatusNoContent {
+		t.Fatalf("update = %v, want succeeded on first attempt", got)
+	}
+	if _, ok := got["delivered_at"]; !ok {
+		t.Fatal("delivered_at not set")
+	}
+}
+
+func TestWorkerSchedulesRetryOnErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	store, id := newTestStore(srv.URL, true, 0)
+	runOnce(store, true)
+
+	got := store.updates[id]
+	if _, ok := got["status"]; ok {
+		t.Fatalf("status changed to %v, want the delivery to stay pending", got["status"])
+	}
+	if got["error"] != "unexpected status 500" || got["response_body"] != "boom\n" {
+		t.Fatalf("update = %v", got)
+	}
+	next, ok := got["next_attempt_at"].(time.Time)
+	if !ok || next.Before(time.Now().Add(20*time.Second)) {
+		t.Fatalf("next_attempt_at = %v, want ~30s backoff", got["next_attempt_at"])
+	}
+}
+
+func TestWorkerFailsAfterMaxAttempts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	store, id := newTestStore(srv.URL, true, 2)
+	runOnce(store, true)
+
+	if got := store.updates[id]; got["status"] != models.WebhookDeliveryFailed || got["attempts"] != 3 {
+		t.Fatalf("update = %v, want failed after 3 attempts", got)
+	}
+}
+
+func TestWorkerDoesNotFollowRedirects(t *testing.T) {
+	var followed bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hooks", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/elsewhere", http.StatusTemporaryRedirect)
+	})
+	mux.HandleFunc("/elsewhere", func(w http.ResponseWriter, r *http.Request) {
+		followed = true
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	store, id := newTestStore(srv.URL+"/hooks", true, 0)
+	runOnce(store, true)
+
+	if followed {
+		t.Fatal("worker followed the redirect")
+	}
+	if got := store.updates[id]; got["response_code"] != http.StatusTemporaryRedirect || got["status"] == models.WebhookDeliverySucceeded {
+		t.Fatalf("update = %v, want a failed attempt with 307", got)
+	}
+}
+
+func TestWorkerSkipsInactiveEndpoint(t *testing.T) {
+	var called bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	store, id := newTestStore(srv.URL, false, 0)
+	runOnce(store, true)
+
+	if called {
+		t.Fatal("request sent to an inactive endpoint")
+	}
+	if got := store.updates[id]; got["status"] != models.WebhookDeliveryFailed {
+		t.Fatalf("update = %v, want failed", got)
+	}
+}
+
+func TestWorkerBlocksPrivateAddresses(t *testing.T) {
+	var called bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	store, id := newTestStore(srv.URL, true, 0)
+	runOnce(store, false)
+
+	if called {
+		t.Fatal("request reached the loopback receiver")
+	}
+	if got := store.updates[id]; got["error"] == "" || got["status"] == models.WebhookDeliverySucceeded {
+		t.Fatalf("update = %v, want a dial error", got)
+	}
+}